@@ -0,0 +1,207 @@
+// Package changelog generates release notes from commit messages.
+// Commits following Conventional Commits (https://www.conventionalcommits.org),
+// or starting with a gitmoji in the gitmoji style, are grouped by type;
+// anything else is listed under "Other Changes".
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Commit is a commit to include in the changelog.
+// This package doesn't depend on git; callers convert their log entries.
+type Commit struct {
+	Hash    string
+	Subject string
+}
+
+// Entry is a single changelog line parsed from a commit subject.
+type Entry struct {
+	Type        string `json:"type,omitempty"`  // Conventional commit type (e.g., "feat"); empty if not conventional
+	Scope       string `json:"scope,omitempty"` // Optional scope from "type(scope): ..."
+	Breaking    bool   `json:"breaking"`        // Marked with "!" (e.g., "feat!: ...")
+	Description string `json:"description"`     // Subject text after the type prefix
+	Hash        string `json:"hash"`            // Commit hash
+}
+
+// Section is a group of entries under one heading.
+type Section struct {
+	Title   string  `json:"title"`
+	Entries []Entry `json:"entries"`
+}
+
+// conventionalPattern matches "type(scope)!: description".
+var conventionalPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// sectionOrder maps commit types to section titles, in display order.
+var sectionOrder = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+}
+
+const (
+	breakingTitle = "Breaking Changes"
+	otherTitle    = "Other Changes"
+)
+
+// DefaultExclude are the default changelog_exclude patterns: merge
+// commits, which only repeat the work they merge.
+var DefaultExclude = []string{"^Merge "}
+
+// CompileExclude compiles changelog_exclude patterns for CategorizeStyle.
+func CompileExclude(patterns []string) ([]*regexp.Regexp, error) {
+	exclude := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+		exclude = append(exclude, re)
+	}
+	return exclude, nil
+}
+
+// Parse parses a commit subject as a conventional commit.
+// ok is false if the subject doesn't follow the convention.
+func Parse(subject string) (entry Entry, ok bool) {
+	matches := conventionalPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if matches == nil {
+		return Entry{Description: strings.TrimSpace(subject)}, false
+	}
+
+	return Entry{
+		Type:        strings.ToLower(matches[1]),
+		Scope:       matches[2],
+		Breaking:    matches[3] == "!",
+		Description: matches[4],
+	}, true
+}
+
+// Categorize groups conventional commits into sections.
+// Breaking changes come first, then known types, then everything else.
+func Categorize(commits []Commit) []Section {
+	return CategorizeStyle(commits, StyleConventional)
+}
+
+// CategorizeStyle groups commits into sections like Categorize, parsing
+// their subjects in the given style. Commits whose subject matches any
+// exclude pattern are left out.
+func CategorizeStyle(commits []Commit, style Style, exclude ...*regexp.Regexp) []Section {
+	byTitle := make(map[string][]Entry)
+	for _, c := range commits {
+		if excluded(c.Subject, exclude) {
+			continue
+		}
+		entry, _ := style.Parse(c.Subject)
+		entry.Hash = c.Hash
+
+		title := otherTitle
+		if entry.Breaking {
+			title = breakingTitle
+		} else {
+			for _, s := range sectionOrder {
+				if s.Type == entry.Type {
+					title = s.Title
+					break
+				}
+			}
+		}
+		byTitle[title] = append(byTitle[title], entry)
+	}
+
+	titles := []string{breakingTitle}
+	for _, s := range sectionOrder {
+		titles = append(titles, s.Title)
+	}
+	titles = append(titles, otherTitle)
+
+	var sections []Section
+	for _, title := range titles {
+		if entries := byTitle[title]; len(entries) > 0 {
+			sections = append(sections, Section{Title: title, Entries: entries})
+		}
+	}
+	return sections
+}
+
+// excluded reports whether subject matches any of the patterns.
+func excluded(subject string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render formats sections as a Markdown changelog section for a version.
+func Render(version string, date time.Time, sections []Section) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n", version, date.Format("2006-01-02"))
+
+	if len(sections) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString("- ")
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+			b.WriteString(entry.Description)
+			if entry.Hash != "" {
+				fmt.Fprintf(&b, " (%s)", shortHash(entry.Hash))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// RenderPlain formats sections as plain text, for terminals and
+// tools that don't render Markdown.
+func RenderPlain(version string, date time.Time, sections []Section) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", version, date.Format("2006-01-02"))
+
+	if len(sections) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n%s:\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString("  - ")
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "%s: ", entry.Scope)
+			}
+			b.WriteString(entry.Description)
+			if entry.Hash != "" {
+				fmt.Fprintf(&b, " (%s)", shortHash(entry.Hash))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// shortHash abbreviates a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,171 @@
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    Entry
+		wantOK  bool
+	}{
+		{
+			subject: "feat: add hotfix base option",
+			want:    Entry{Type: "feat", Description: "add hotfix base option"},
+			wantOK:  true,
+		},
+		{
+			subject: "fix(git): handle empty repositories",
+			want:    Entry{Type: "fix", Scope: "git", Description: "handle empty repositories"},
+			wantOK:  true,
+		},
+		{
+			subject: "feat(cli)!: rename --force flag",
+			want:    Entry{Type: "feat", Scope: "cli", Breaking: true, Description: "rename --force flag"},
+			wantOK:  true,
+		},
+		{
+			subject: "Feat: uppercase type",
+			want:    Entry{Type: "feat", Description: "uppercase type"},
+			wantOK:  true,
+		},
+		{
+			subject: "Update README",
+			want:    Entry{Description: "Update README"},
+			wantOK:  false,
+		},
+		{
+			subject: "Merge branch 'release/1.2.0'",
+			want:    Entry{Description: "Merge branch 'release/1.2.0'"},
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got, ok := Parse(tt.subject)
+			if ok != tt.wantOK {
+				t.Errorf("Parse(%q) ok = %v, want %v", tt.subject, ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	commits := []Commit{
+		{Hash: "a1", Subject: "chore: bump deps"},
+		{Hash: "b2", Subject: "fix: off-by-one"},
+		{Hash: "c3", Subject: "feat!: new config format"},
+		{Hash: "d4", Subject: "feat(cli): add --push"},
+		{Hash: "e5", Subject: "Tweak wording"},
+	}
+
+	got := Categorize(commits)
+
+	var titles []string
+	for _, s := range got {
+		titles = append(titles, s.Title)
+	}
+	wantTitles := []string{"Breaking Changes", "Features", "Bug Fixes", "Other Changes"}
+	if !reflect.DeepEqual(titles, wantTitles) {
+		t.Fatalf("Categorize() titles = %v, want %v", titles, wantTitles)
+	}
+
+	// Non-conventional and unknown types share "Other Changes", in commit order
+	other := got[3].Entries
+	if len(other) != 2 || other[0].Hash != "a1" || other[1].Hash != "e5" {
+		t.Errorf("Other Changes = %+v, want commits a1 and e5", other)
+	}
+}
+
+func TestCategorizeStyle_Exclude(t *testing.T) {
+	commits := []Commit{
+		{Hash: "a1", Subject: "Merge branch 'feature/x' into develop"},
+		{Hash: "b2", Subject: "chore: bump deps"},
+		{Hash: "c3", Subject: "fix: handle Merge conflicts"},
+		{Hash: "d4", Subject: "Tweak wording"},
+	}
+
+	hashes := func(sections []Section) []string {
+		var got []string
+		for _, s := range sections {
+			for _, e := range s.Entries {
+				got = append(got, e.Hash)
+			}
+		}
+		return got
+	}
+
+	exclude, err := CompileExclude([]string{"^Merge ", "^chore"})
+	if err != nil {
+		t.Fatalf("CompileExclude() error = %v", err)
+	}
+	if got, want := hashes(CategorizeStyle(commits, StyleConventional, exclude...)), []string{"c3", "d4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CategorizeStyle() kept %v, want %v", got, want)
+	}
+
+	// No patterns keeps everything, merge commits included
+	if got := hashes(CategorizeStyle(commits, StyleConventional)); len(got) != len(commits) {
+		t.Errorf("CategorizeStyle() kept %v, want all commits", got)
+	}
+
+	if _, err := CompileExclude([]string{"("}); err == nil {
+		t.Error("CompileExclude() expected error for an invalid pattern")
+	}
+}
+
+func TestRender(t *testing.T) {
+	date := time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)
+	sections := Categorize([]Commit{
+		{Hash: "0123456789abcdef", Subject: "feat(git): add Log"},
+		{Hash: "fedcba9876543210", Subject: "fix: trim output"},
+	})
+
+	want := `## 1.3.0 (2025-12-25)
+
+### Features
+
+- **git:** add Log (0123456)
+
+### Bug Fixes
+
+- trim output (fedcba9)
+`
+	if got := Render("1.3.0", date, sections); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	date := time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)
+	sections := Categorize([]Commit{
+		{Hash: "0123456789abcdef", Subject: "feat(git): add Log"},
+		{Hash: "fedcba9876543210", Subject: "fix: trim output"},
+	})
+
+	want := `1.3.0 (2025-12-25)
+
+Features:
+  - git: add Log (0123456)
+
+Bug Fixes:
+  - trim output (fedcba9)
+`
+	if got := RenderPlain("1.3.0", date, sections); got != want {
+		t.Errorf("RenderPlain() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRender_NoChanges(t *testing.T) {
+	got := Render("1.3.0", time.Now(), nil)
+	if !strings.Contains(got, "No changes.") {
+		t.Errorf("Render() = %q, want it to say there are no changes", got)
+	}
+}
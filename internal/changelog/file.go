@@ -0,0 +1,83 @@
+package changelog
+
+import "strings"
+
+// FileHeader starts a changelog file Prepend creates.
+const FileHeader = "# Changelog\n"
+
+// Prepend inserts the section for version (as rendered by Render) into
+// the contents of a changelog file, above the previous entries and below
+// the title and any introduction before them. An empty file gets
+// FileHeader first. If the file already has an entry for version, it is
+// returned unchanged and added is false.
+func Prepend(content, version, section string) (updated string, added bool) {
+	section = strings.TrimRight(section, "\n") + "\n"
+	if strings.TrimSpace(content) == "" {
+		return FileHeader + "\n" + section, true
+	}
+	if HasEntry(content, version) {
+		return content, false
+	}
+
+	// Entries start at the first "## " heading
+	offset := len(content)
+	for _, line := range lines(content) {
+		if strings.HasPrefix(line.text, "## ") {
+			offset = line.start
+			break
+		}
+	}
+	head := strings.TrimRight(content[:offset], "\n")
+	rest := content[offset:]
+	if head == "" {
+		return section + "\n" + rest, true
+	}
+	if rest == "" {
+		return head + "\n\n" + section, true
+	}
+	return head + "\n\n" + section + "\n" + rest, true
+}
+
+// HasEntry reports whether a changelog has a "## " heading for version,
+// such as "## 1.3.0 (2025-01-15)", "## v1.3.0" or "## [1.3.0] - 2025-01-15".
+func HasEntry(content, version string) bool {
+	for _, line := range lines(content) {
+		heading, ok := strings.CutPrefix(line.text, "## ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(heading)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.Trim(fields[0], "[]"), "v")
+		if name == version {
+			return true
+		}
+	}
+	return false
+}
+
+// line is a line of a file and its byte offset.
+type line struct {
+	text  string
+	start int
+}
+
+// lines splits content into lines outside fenced code blocks, where a
+// "## " is example text rather than a heading.
+func lines(content string) []line {
+	var result []line
+	var fenced bool
+	start := 0
+	for _, text := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(text, "\r\n")
+		if strings.HasPrefix(trimmed, "```") {
+			fenced = !fenced
+		} else if !fenced {
+			result = append(result, line{text: trimmed, start: start})
+		}
+		start += len(text)
+	}
+	return result
+}
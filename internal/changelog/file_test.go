@@ -0,0 +1,73 @@
+package changelog
+
+import "testing"
+
+func TestPrepend(t *testing.T) {
+	section := "## 1.3.0 (2025-01-15)\n\n### Features\n\n- add export (abc1234)\n"
+
+	tests := []struct {
+		name      string
+		content   string
+		want      string
+		wantAdded bool
+	}{
+		{
+			name:      "empty",
+			content:   "",
+			want:      "# Changelog\n\n" + section,
+			wantAdded: true,
+		},
+		{
+			name:      "header only",
+			content:   "# Changelog\n\nAll notable changes.\n",
+			want:      "# Changelog\n\nAll notable changes.\n\n" + section,
+			wantAdded: true,
+		},
+		{
+			name:      "headered",
+			content:   "# Changelog\n\n## 1.2.0 (2024-12-01)\n\nNo changes.\n",
+			want:      "# Changelog\n\n" + section + "\n## 1.2.0 (2024-12-01)\n\nNo changes.\n",
+			wantAdded: true,
+		},
+		{
+			name:      "no header",
+			content:   "## 1.2.0 (2024-12-01)\n\nNo changes.\n",
+			want:      section + "\n## 1.2.0 (2024-12-01)\n\nNo changes.\n",
+			wantAdded: true,
+		},
+		{
+			name:      "heading in a code block",
+			content:   "# Changelog\n\n```\n## 0.0.0\n```\n\n## 1.2.0\n",
+			want:      "# Changelog\n\n```\n## 0.0.0\n```\n\n" + section + "\n## 1.2.0\n",
+			wantAdded: true,
+		},
+		{
+			name:    "existing version",
+			content: "# Changelog\n\n## 1.3.0 (2025-01-14)\n\nNo changes.\n",
+			want:    "# Changelog\n\n## 1.3.0 (2025-01-14)\n\nNo changes.\n",
+		},
+		{
+			name:    "existing version, keep a changelog style",
+			content: "# Changelog\n\n## [v1.3.0] - 2025-01-14\n",
+			want:    "# Changelog\n\n## [v1.3.0] - 2025-01-14\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, added := Prepend(tt.content, "1.3.0", section)
+			if got != tt.want || added != tt.wantAdded {
+				t.Errorf("Prepend() = %q, %v\nwant %q, %v", got, added, tt.want, tt.wantAdded)
+			}
+		})
+	}
+}
+
+func TestHasEntry(t *testing.T) {
+	content := "# Changelog\n\n## 1.3.0-rc.1 (2025-01-10)\n\n## 1.2.0\n"
+	for ver, want := range map[string]bool{"1.2.0": true, "1.3.0-rc.1": true, "1.3.0": false, "1.2": false} {
+		if got := HasEntry(content, ver); got != want {
+			t.Errorf("HasEntry(%q) = %v, want %v", ver, got, want)
+		}
+	}
+}
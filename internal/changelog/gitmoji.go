@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style selects how commit subjects are classified.
+type Style string
+
+const (
+	StyleConventional Style = "conventional" // "feat(scope): ..." (default)
+	StyleGitmoji      Style = "gitmoji"      // "✨ ..." or ":sparkles: ..." (https://gitmoji.dev)
+)
+
+// ParseStyle converts a string to a Style.
+func ParseStyle(s string) (Style, error) {
+	switch Style(strings.ToLower(s)) {
+	case StyleConventional, "":
+		return StyleConventional, nil
+	case StyleGitmoji:
+		return StyleGitmoji, nil
+	default:
+		return "", fmt.Errorf("unknown changelog style: %s (use 'conventional' or 'gitmoji')", s)
+	}
+}
+
+// Parse parses a commit subject in this style.
+// ok is false if the subject doesn't follow it.
+func (s Style) Parse(subject string) (entry Entry, ok bool) {
+	if s == StyleGitmoji {
+		return ParseGitmoji(subject)
+	}
+	return Parse(subject)
+}
+
+// gitmojiTypes maps gitmoji, by emoji and by shortcode, to the
+// conventional commit types used for sections. An empty type marks a
+// breaking change.
+var gitmojiTypes = map[string]string{
+	"✨": "feat", ":sparkles:": "feat",
+	"🐛": "fix", ":bug:": "fix",
+	"🚑": "fix", ":ambulance:": "fix",
+	"🔒": "fix", ":lock:": "fix",
+	"⚡": "perf", ":zap:": "perf",
+	"♻": "refactor", ":recycle:": "refactor",
+	"📝": "docs", ":memo:": "docs",
+	"💥": "", ":boom:": "",
+}
+
+// variationSelector follows some emoji to request emoji presentation
+// (e.g., "⚡️" is U+26A1 U+FE0F); it isn't part of the gitmoji itself.
+const variationSelector = "\uFE0F"
+
+// ParseGitmoji parses a commit subject starting with a gitmoji, either
+// the emoji ("✨ add widgets") or its shortcode (":sparkles: add widgets").
+// ok is false if the subject doesn't start with a known gitmoji.
+func ParseGitmoji(subject string) (entry Entry, ok bool) {
+	subject = strings.TrimSpace(subject)
+
+	var code, rest string
+	if strings.HasPrefix(subject, ":") {
+		end := strings.Index(subject[1:], ":")
+		if end < 0 {
+			return Entry{Description: subject}, false
+		}
+		code, rest = subject[:end+2], subject[end+2:]
+	} else {
+		// Emoji are multi-byte; take the whole first rune
+		r, size := utf8.DecodeRuneInString(subject)
+		if r == utf8.RuneError {
+			return Entry{Description: subject}, false
+		}
+		code, rest = subject[:size], strings.TrimPrefix(subject[size:], variationSelector)
+	}
+
+	typ, known := gitmojiTypes[code]
+	rest = strings.TrimSpace(rest)
+	if !known || rest == "" {
+		return Entry{Description: subject}, false
+	}
+	return Entry{Type: typ, Breaking: typ == "", Description: rest}, true
+}
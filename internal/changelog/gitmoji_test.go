@@ -0,0 +1,67 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGitmoji(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    Entry
+		wantOK  bool
+	}{
+		{"✨ add widgets", Entry{Type: "feat", Description: "add widgets"}, true},
+		{"🐛 fix off-by-one", Entry{Type: "fix", Description: "fix off-by-one"}, true},
+		// With the emoji presentation variation selector (U+FE0F)
+		{"⚡️ faster startup", Entry{Type: "perf", Description: "faster startup"}, true},
+		{"♻️ simplify parser", Entry{Type: "refactor", Description: "simplify parser"}, true},
+		{"⚡faster startup", Entry{Type: "perf", Description: "faster startup"}, true},
+		{"💥 drop Go 1.20", Entry{Breaking: true, Description: "drop Go 1.20"}, true},
+		{":sparkles: add widgets", Entry{Type: "feat", Description: "add widgets"}, true},
+		{":memo: update README", Entry{Type: "docs", Description: "update README"}, true},
+		{"🎉 initial commit", Entry{Description: "🎉 initial commit"}, false},
+		{":unknown: thing", Entry{Description: ":unknown: thing"}, false},
+		{"✨", Entry{Description: "✨"}, false},
+		{"feat: add widgets", Entry{Description: "feat: add widgets"}, false},
+		{"", Entry{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got, ok := ParseGitmoji(tt.subject)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseGitmoji(%q) = %+v, %v, want %+v, %v", tt.subject, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCategorizeStyle_Gitmoji(t *testing.T) {
+	sections := CategorizeStyle([]Commit{
+		{Hash: "a", Subject: "✨ add widgets"},
+		{Hash: "b", Subject: "🐛 fix off-by-one"},
+		{Hash: "c", Subject: "💥 remove old API"},
+		{Hash: "d", Subject: "feat: conventional, not gitmoji"},
+	}, StyleGitmoji)
+
+	var titles []string
+	for _, s := range sections {
+		titles = append(titles, s.Title)
+	}
+	want := []string{"Breaking Changes", "Features", "Bug Fixes", "Other Changes"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("CategorizeStyle() sections = %v, want %v", titles, want)
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for input, want := range map[string]Style{"": StyleConventional, "conventional": StyleConventional, "Gitmoji": StyleGitmoji} {
+		if got, err := ParseStyle(input); err != nil || got != want {
+			t.Errorf("ParseStyle(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseStyle("emoji"); err == nil {
+		t.Error("ParseStyle() expected error for unknown style")
+	}
+}
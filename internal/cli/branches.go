@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// branchesCmd lists local branches.
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "List local branches",
+	Long: `List local branches.
+
+With --merged, only branches fully merged into develop (or main when
+running without develop) are listed: these are safe to delete, and are
+what prune_features removes on "release finish" (for feature/* branches).`,
+
+	Args: cobra.NoArgs,
+	RunE: runBranches,
+}
+
+func init() {
+	rootCmd.AddCommand(branchesCmd)
+
+	branchesCmd.Flags().Bool("merged", false, "only list branches merged into develop")
+}
+
+// runBranches executes the branches command.
+func runBranches(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	merged, _ := cmd.Flags().GetBool("merged")
+	branches, target, err := f.Branches(merged)
+	if err != nil {
+		return err
+	}
+
+	if len(branches) == 0 {
+		if merged {
+			fmt.Printf("No branches merged into %s.\n", target)
+		} else {
+			fmt.Println("No branches.")
+		}
+		return nil
+	}
+	for _, branch := range branches {
+		fmt.Println(branch)
+	}
+	return nil
+}
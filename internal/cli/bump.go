@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/config"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// bumpCmd prints the version after a bump, without touching git.
+var bumpCmd = &cobra.Command{
+	Use:   "bump <minor|patch|hotfix> <version>",
+	Short: "Print the next version after a bump",
+	Long: `Print the version that follows a given one, using the same rules as
+releases and hotfixes (e.g., "mkrel bump minor 1.2.3" prints 1.3.0 and
+"mkrel bump patch 1.2.3" prints 1.2.4). No repository is needed.
+
+The scheme comes from the config, or from --scheme.`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: runBump,
+}
+
+func init() {
+	rootCmd.AddCommand(bumpCmd)
+	bumpCmd.Flags().String("scheme", "", "versioning scheme: semver or calver (default: from config)")
+}
+
+// runBump executes the bump command.
+func runBump(cmd *cobra.Command, args []string) error {
+	bump, err := version.ParseBumpType(args[0])
+	if err != nil {
+		return err
+	}
+
+	scheme, err := flagScheme(cmd)
+	if err != nil {
+		return err
+	}
+
+	versioner, err := version.New(scheme, nil)
+	if err != nil {
+		return err
+	}
+	current := args[1]
+	if !versioner.IsValid(current) {
+		return fmt.Errorf("invalid %s version: %s", scheme, current)
+	}
+
+	next, err := versioner.Next(current, bump)
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, next)
+}
+
+// flagScheme returns the versioning scheme from the --scheme flag, or
+// from the config if the flag isn't set.
+func flagScheme(cmd *cobra.Command) (version.Scheme, error) {
+	if s, _ := cmd.Flags().GetString("scheme"); s != "" {
+		return version.ParseScheme(s)
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Scheme, nil
+}
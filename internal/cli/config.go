@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/config"
+)
+
+// configCmd is a parent command - it groups config subcommands.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change configuration",
+	Long: `Read and change .mkrel.yaml from scripts, without editing YAML.
+
+Keys are named as in the file, with dots for nested keys
+(e.g., "branches.main" or "branch_schemes.lts").`,
+}
+
+// configGetCmd prints a config value.
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a config value",
+	Long: `Print the effective value of a config key, after merging the
+environment, repository config, user config, and defaults
+(e.g., "mkrel config get remote").`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+// configSetCmd changes a config value.
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a config value",
+	Long: `Validate and save a config value (e.g., "mkrel config set scheme semver").
+
+Only the key and the keys already in the repository config file are
+written; the file is created if it doesn't exist. Defaults, the user config
+and environment are not copied into it. Comments in an existing file are
+not preserved.`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
+
+// runConfigGet executes the config get command.
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// runConfigSet executes the config set command.
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		configPath = ".mkrel.yaml"
+	}
+
+	cfg := config.Default()
+	if _, err := os.Stat(configPath); err == nil {
+		if cfg, err = config.LoadFile(configPath); err != nil {
+			return err
+		}
+	}
+
+	if err := cfg.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	if err := cfg.SaveKeys(configPath, args[0]); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
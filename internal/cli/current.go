@@ -0,0 +1,35 @@
+package cli
+
+import "github.com/spf13/cobra"
+
+// currentCmd prints the current version.
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the current version",
+	Long: `Print the version of the latest release tag.
+
+The output is empty if there are no releases yet. With --output, the version
+is written to a file instead (e.g., for CI artifacts).`,
+
+	Args: cobra.NoArgs,
+	RunE: runCurrent,
+}
+
+func init() {
+	rootCmd.AddCommand(currentCmd)
+}
+
+// runCurrent executes the current command.
+func runCurrent(cmd *cobra.Command, args []string) error {
+	f, err := newQueryFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	f.CheckShallow()
+	current, err := f.CurrentVersion()
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, current)
+}
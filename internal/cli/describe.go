@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// describeCmd prints a version for the current commit.
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Print a version for the current commit",
+	Long: `Print a "git describe"-style version for HEAD, to stamp builds between
+releases: the release version on a tagged commit (1.2.3), the commits since
+it and the commit SHA otherwise (1.2.3-5-gabc1234), or just the SHA if there
+are no releases yet.
+
+With --output, the version is written to a file instead.`,
+
+	Args: cobra.NoArgs,
+	RunE: runDescribe,
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+}
+
+// runDescribe executes the describe command.
+func runDescribe(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	described, err := f.Describe()
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, described)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+)
+
+// graphCmd draws what finishing a release or hotfix would do.
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show the branches a release or hotfix goes through",
+	Long: `Draw the merges and tag "mkrel release finish" would make for the
+release in progress, or for the one "mkrel release start" would create.
+With --type hotfix, draw the hotfix flow instead.
+
+Nothing is changed. The graph follows the configuration: without a
+develop branch nothing is merged back, and hotfixes from a support
+branch or an older tag stay off main.`,
+
+	Args: cobra.NoArgs,
+	RunE: runGraph,
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().String("type", "release", "what to draw: release or hotfix")
+}
+
+// runGraph executes the graph command.
+func runGraph(cmd *cobra.Command, args []string) error {
+	kind, _ := cmd.Flags().GetString("type")
+	if kind != "release" && kind != "hotfix" {
+		return fmt.Errorf("unknown type: %s (use release or hotfix)", kind)
+	}
+
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	var graph *flow.Graph
+	if kind == "hotfix" {
+		graph, err = f.HotfixGraph()
+	} else {
+		graph, err = f.ReleaseGraph()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), graph)
+	return nil
+}
@@ -3,7 +3,6 @@ package cli
 import (
 	"github.com/spf13/cobra"
 
-	"github.com/kloudlabs-io/mkrel/internal/config"
 	"github.com/kloudlabs-io/mkrel/internal/flow"
 )
 
@@ -24,19 +23,25 @@ var hotfixStartCmd = &cobra.Command{
 	Long: `Start a new hotfix branch from main.
 
 This will:
-  1. Verify no hotfix is already in progress
+  1. Verify no hotfix is already in progress (unless --allow-multiple)
   2. Calculate the next hotfix version
-  3. Create hotfix/<version> branch from main`,
+  3. Create hotfix/<version> branch from main
+
+Use --base to hotfix an older release: with a support branch, the hotfix
+merges back into that branch; with a tag, the hotfix is tagged in place.`,
 
 	RunE: runHotfixStart,
 }
 
 // hotfixFinishCmd finishes the current hotfix.
 var hotfixFinishCmd = &cobra.Command{
-	Use:   "finish",
+	Use:   "finish [version]",
 	Short: "Finish the current hotfix",
 	Long: `Finish the current hotfix branch.
 
+If several hotfix branches exist, pass the version to finish
+(e.g., "mkrel hotfix finish 1.2.4").
+
 This will:
   1. Merge hotfix branch to main
   2. Tag the hotfix release
@@ -44,63 +49,124 @@ This will:
   4. Push everything to remote
   5. Delete the local hotfix branch`,
 
+	Args: cobra.MaximumNArgs(1),
 	RunE: runHotfixFinish,
 }
 
+// hotfixApplyCmd cherry-picks a finished hotfix onto another branch.
+var hotfixApplyCmd = &cobra.Command{
+	Use:   "apply <branch>",
+	Short: "Cherry-pick a finished hotfix onto another branch",
+	Long: `Cherry-pick the commits of a finished hotfix onto another branch, such as
+a support branch for an older release.
+
+The commits are those since the release before the hotfix, without merges
+and the version and changelog commits of the finish. Nothing is pushed.
+If a commit doesn't apply cleanly, resolve the conflicts and run
+"git cherry-pick --continue", or "git cherry-pick --abort" to undo it.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: runHotfixApply,
+}
+
+// hotfixListCmd lists the hotfix branches in progress.
+var hotfixListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List hotfixes in progress",
+	Long: `List the hotfix branches that haven't been finished, with their version
+and the branch each merges into (main, or the --base it was started from).
+
+With --json, prints an array of {branch, version, base} objects.`,
+
+	Args: cobra.NoArgs,
+	RunE: runHotfixList,
+}
+
 func init() {
 	rootCmd.AddCommand(hotfixCmd)
 	hotfixCmd.AddCommand(hotfixStartCmd)
 	hotfixCmd.AddCommand(hotfixFinishCmd)
+	hotfixCmd.AddCommand(hotfixListCmd)
+	hotfixCmd.AddCommand(hotfixApplyCmd)
+
+	hotfixCmd.PersistentFlags().Bool("force-unlock", false, "remove a stale lock left by an interrupted mkrel run")
+	hotfixStartCmd.Flags().Bool("allow-multiple", false, "start even if another hotfix is in progress")
+	hotfixStartCmd.Flags().String("base", "", "branch or tag to start the hotfix from (default: main)")
+	hotfixFinishCmd.Flags().Bool("set-upstream", false, "push main and develop with -u if they have no upstream")
+	hotfixFinishCmd.Flags().Bool("draft", false, "publish the GitHub release as a draft (see github_release)")
+	hotfixFinishCmd.Flags().Bool("prerelease", false, "mark the GitHub release as a prerelease (see github_release)")
+	hotfixFinishCmd.Flags().StringP("message", "m", "", "tag annotation (overrides the default and changelog_in_tag)")
+	hotfixFinishCmd.Flags().BoolP("yes", "y", false, "push main without asking (see confirm_main_push)")
+	hotfixFinishCmd.Flags().Bool("force-delete", false, "delete the branch even if git doesn't consider it merged (git branch -D)")
+	hotfixListCmd.Flags().Bool("json", false, "print hotfixes in progress as JSON")
+	hotfixApplyCmd.Flags().String("version", "", "hotfix to apply (default: the latest release on main)")
 }
 
-// runHotfixStart executes the hotfix start command.
-func runHotfixStart(cmd *cobra.Command, args []string) error {
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	configPath, _ := cmd.Flags().GetString("config")
+// runHotfixList executes the hotfix list command.
+func runHotfixList(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
 
-	cfg, err := config.Load(configPath)
+	hotfixes, err := f.HotfixesInProgress()
 	if err != nil {
 		return err
 	}
+	return printInProgress(cmd, "hotfixes", hotfixes)
+}
 
-	f, err := flow.New(flow.Options{
-		Scheme:     cfg.Scheme,
-		Remote:     cfg.Remote,
-		MainBranch: cfg.Branches.Main,
-		DevBranch:  cfg.Branches.Develop,
-		DryRun:     dryRun,
-		Verbose:    verbose,
-	})
+// runHotfixStart executes the hotfix start command.
+func runHotfixStart(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
 	if err != nil {
 		return err
 	}
 
-	return f.HotfixStart()
+	allowMultiple, _ := cmd.Flags().GetBool("allow-multiple")
+	base, _ := cmd.Flags().GetString("base")
+
+	return f.HotfixStart(flow.HotfixStartOptions{
+		AllowMultiple: allowMultiple,
+		Base:          base,
+	})
 }
 
 // runHotfixFinish executes the hotfix finish command.
 func runHotfixFinish(cmd *cobra.Command, args []string) error {
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	configPath, _ := cmd.Flags().GetString("config")
-
-	cfg, err := config.Load(configPath)
+	f, err := newFlow(cmd)
 	if err != nil {
 		return err
 	}
 
-	f, err := flow.New(flow.Options{
-		Scheme:     cfg.Scheme,
-		Remote:     cfg.Remote,
-		MainBranch: cfg.Branches.Main,
-		DevBranch:  cfg.Branches.Develop,
-		DryRun:     dryRun,
-		Verbose:    verbose,
-	})
+	setUpstream, _ := cmd.Flags().GetBool("set-upstream")
+	draft, _ := cmd.Flags().GetBool("draft")
+	prerelease, _ := cmd.Flags().GetBool("prerelease")
+	message, _ := cmd.Flags().GetString("message")
+	forceDelete, _ := cmd.Flags().GetBool("force-delete")
+
+	opts := flow.HotfixFinishOptions{
+		SetUpstream: setUpstream,
+		Draft:       draft,
+		Prerelease:  prerelease,
+		Message:     message,
+		ForceDelete: forceDelete,
+	}
+	if len(args) > 0 {
+		opts.Version = args[0]
+	}
+
+	return f.HotfixFinish(opts)
+}
+
+// runHotfixApply executes the hotfix apply command.
+func runHotfixApply(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
 	if err != nil {
 		return err
 	}
 
-	return f.HotfixFinish()
+	ver, _ := cmd.Flags().GetString("version")
+
+	return f.HotfixApply(args[0], flow.HotfixApplyOptions{Version: ver})
 }
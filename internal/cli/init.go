@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/kloudlabs-io/mkrel/internal/config"
+	"github.com/kloudlabs-io/mkrel/internal/git"
 	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
@@ -20,7 +21,11 @@ The config file controls:
   - Versioning scheme (calver or semver)
   - Branch names (main, develop)
   - Remote name
-  - Optional version file updates`,
+  - Optional version file updates
+
+Use --with-comments for a file that explains each setting. With
+--auto-create-develop, a missing develop branch is created from main
+(and pushed) now, and on the first "release start" if it goes missing.`,
 
 	RunE: runInit,
 }
@@ -31,6 +36,8 @@ func init() {
 	// Flags for init command
 	initCmd.Flags().String("scheme", "calver", "versioning scheme (calver or semver)")
 	initCmd.Flags().Bool("force", false, "overwrite existing config file")
+	initCmd.Flags().Bool("with-comments", false, "explain each setting in the config file, with examples for optional ones")
+	initCmd.Flags().Bool("auto-create-develop", false, "set auto_create_develop and create a missing develop branch from main now")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -52,9 +59,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// Create default config with specified scheme
 	cfg := config.Default()
 	cfg.Scheme = scheme
+	cfg.AutoCreateDevelop, _ = cmd.Flags().GetBool("auto-create-develop")
 
 	// Save to file
-	if err := cfg.Save(".mkrel.yaml"); err != nil {
+	save := cfg.Save
+	if withComments, _ := cmd.Flags().GetBool("with-comments"); withComments {
+		save = cfg.SaveWithComments
+	}
+	if err := save(".mkrel.yaml"); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
@@ -67,5 +79,33 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Println("")
 	fmt.Println("Edit .mkrel.yaml to customize settings.")
 
+	if cfg.AutoCreateDevelop {
+		f, err := newFlow(cmd)
+		if err != nil {
+			return err
+		}
+		created, err := f.CreateDevelop()
+		if err != nil {
+			return err
+		}
+		if created != "" {
+			fmt.Println("")
+			fmt.Printf("Created the %s branch from main\n", created)
+		}
+	}
+
+	// Finishes push to the remote, so point out a missing one now
+	if repo, err := openRepository(cmd); err == nil && !hasRemote(repo, cfg.Remote) {
+		fmt.Println("")
+		fmt.Printf("No remote %q to push releases to; add one with:\n", cfg.Remote)
+		fmt.Printf("  mkrel remote add %s <url>\n", cfg.Remote)
+	}
+
 	return nil
 }
+
+// hasRemote reports whether the repository has the named remote.
+func hasRemote(repo *git.Repository, name string) bool {
+	_, err := repo.RemoteURL(name)
+	return err == nil
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+)
+
+// listCmd lists released versions.
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List released versions",
+	Long: `List version tags, highest version first, with how long ago each was
+released and the first line of the tag message.
+
+With --limit, only the N highest versions are listed, which is much faster
+in repositories with thousands of tags.
+
+Prereleases (e.g., 1.3.0-rc.0) are marked "(prerelease)".
+
+With --json, prints an array of {version, tag, date, subject, prerelease}
+objects with RFC 3339 dates.`,
+
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().Bool("json", false, "print releases as JSON")
+	listCmd.Flags().IntP("limit", "n", 0, "list only the N highest versions (0 = all)")
+}
+
+// releaseJSON is the --json representation of a release.
+type releaseJSON struct {
+	Version    string `json:"version"`
+	Tag        string `json:"tag"`
+	Date       string `json:"date"`
+	Subject    string `json:"subject,omitempty"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// runList executes the list command.
+func runList(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	releases, err := f.Releases(limit)
+	if err != nil {
+		return err
+	}
+
+	return printReleases(cmd, releases, time.Now())
+}
+
+// printReleases prints the releases of "list", one per line with their
+// age at now, or as JSON with --json.
+func printReleases(cmd *cobra.Command, releases []flow.Release, now time.Time) error {
+	w := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		out := make([]releaseJSON, 0, len(releases))
+		for _, r := range releases {
+			out = append(out, releaseJSON{
+				Version:    r.Version,
+				Tag:        r.Tag,
+				Date:       r.Date.Format(time.RFC3339),
+				Subject:    r.Subject,
+				Prerelease: r.Prerelease,
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(releases) == 0 {
+		fmt.Fprintln(w, "No releases yet.")
+		return nil
+	}
+
+	for _, r := range releases {
+		line := fmt.Sprintf("%-20s released %-16s", r.Tag, relativeAge(now.Sub(r.Date)))
+		if r.Subject != "" {
+			line += "  " + r.Subject
+		}
+		if r.Prerelease {
+			line += "  (prerelease)"
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// inProgressJSON is the --json representation of a release or hotfix
+// in progress.
+type inProgressJSON struct {
+	Branch  string `json:"branch"`
+	Version string `json:"version,omitempty"`
+	Base    string `json:"base,omitempty"`
+}
+
+// printInProgress prints the release or hotfix branches of "release
+// list" and "hotfix list", one per line or as JSON with --json.
+func printInProgress(cmd *cobra.Command, kind string, branches []flow.InProgress) error {
+	w := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		out := make([]inProgressJSON, 0, len(branches))
+		for _, b := range branches {
+			out = append(out, inProgressJSON{Branch: b.Branch, Version: b.Version, Base: b.Base})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(branches) == 0 {
+		fmt.Fprintf(w, "No %s in progress.\n", kind)
+		return nil
+	}
+	for _, b := range branches {
+		line := fmt.Sprintf("%-28s %-16s", b.Branch, b.Version)
+		if b.Base != "" {
+			line += " into " + b.Base
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// relativeAge formats a duration as a rough age (e.g., "3 days ago").
+func relativeAge(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year")
+	}
+}
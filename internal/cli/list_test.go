@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+)
+
+func TestPrintInProgress(t *testing.T) {
+	hotfixes := []flow.InProgress{
+		{Branch: "hotfix/1.0.1", Version: "1.0.1", Base: "main"},
+		{Branch: "hotfix/urgent", Base: "lts"},
+	}
+	releases := []flow.InProgress{{Branch: "release/1.1.0-rc.0", Version: "1.1.0-rc.0"}}
+
+	tests := []struct {
+		name     string
+		json     bool
+		branches []flow.InProgress
+		want     string
+	}{
+		{
+			name:     "hotfixes",
+			branches: hotfixes,
+			want: "hotfix/1.0.1                 1.0.1            into main\n" +
+				"hotfix/urgent                                 into lts\n",
+		},
+		{
+			name:     "releases",
+			branches: releases,
+			want:     "release/1.1.0-rc.0           1.1.0-rc.0\n",
+		},
+		{
+			name: "none",
+			want: "No hotfixes in progress.\n",
+		},
+		{
+			name:     "json",
+			json:     true,
+			branches: hotfixes,
+			want: `[
+  {
+    "branch": "hotfix/1.0.1",
+    "version": "1.0.1",
+    "base": "main"
+  },
+  {
+    "branch": "hotfix/urgent",
+    "base": "lts"
+  }
+]
+`,
+		},
+		{
+			name: "json none",
+			json: true,
+			want: "[]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("json", tt.json, "")
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			if err := printInProgress(cmd, "hotfixes", tt.branches); err != nil {
+				t.Fatalf("printInProgress() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("printInProgress() printed:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintReleases(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	releases := []flow.Release{
+		{Version: "1.1.0-rc.0", Tag: "v1.1.0-rc.0", Date: now.Add(-2 * time.Hour), Subject: "Release 1.1.0-rc.0", Prerelease: true},
+		{Version: "1.0.0", Tag: "v1.0.0", Date: now.Add(-3 * 24 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		json     bool
+		releases []flow.Release
+		want     string
+	}{
+		{
+			name:     "releases",
+			releases: releases,
+			want: "v1.1.0-rc.0          released 2 hours ago       Release 1.1.0-rc.0  (prerelease)\n" +
+				"v1.0.0               released 3 days ago\n",
+		},
+		{
+			name: "none",
+			want: "No releases yet.\n",
+		},
+		{
+			name:     "json",
+			json:     true,
+			releases: releases,
+			want: `[
+  {
+    "version": "1.1.0-rc.0",
+    "tag": "v1.1.0-rc.0",
+    "date": "2026-03-10T10:00:00Z",
+    "subject": "Release 1.1.0-rc.0",
+    "prerelease": true
+  },
+  {
+    "version": "1.0.0",
+    "tag": "v1.0.0",
+    "date": "2026-03-07T12:00:00Z",
+    "prerelease": false
+  }
+]
+`,
+		},
+		{
+			name: "json none",
+			json: true,
+			want: "[]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("json", tt.json, "")
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+
+			if err := printReleases(cmd, tt.releases, now); err != nil {
+				t.Fatalf("printReleases() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("printReleases() printed:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
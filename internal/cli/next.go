@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+)
+
+// nextCmd previews the version and branch the next release would get.
+var nextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Preview the next release or hotfix",
+	Long: `Print the version and branch "mkrel release start" would create,
+without changing anything. With --type hotfix, preview "mkrel hotfix
+start" instead.
+
+With --count N (even 1), print the final versions of the next N releases
+(or hotfixes) instead, one per line, each assuming the one before was
+finished: successive minors for SemVer, or -1, -2, ... hotfixes for
+CalVer. CalVer releases are all dated today, so a count only shows
+today's version.
+
+With --output, only the version is written to a file (e.g., for CI
+artifacts).`,
+
+	Args: cobra.NoArgs,
+	RunE: runNext,
+}
+
+func init() {
+	rootCmd.AddCommand(nextCmd)
+
+	nextCmd.Flags().String("type", "release", "what to preview: release or hotfix")
+	nextCmd.Flags().Int("count", 1, "preview the versions of this many upcoming releases or hotfixes")
+}
+
+// runNext executes the next command.
+func runNext(cmd *cobra.Command, args []string) error {
+	kind, _ := cmd.Flags().GetString("type")
+	if kind != "release" && kind != "hotfix" {
+		return fmt.Errorf("unknown type: %s (use release or hotfix)", kind)
+	}
+
+	f, err := newQueryFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	f.CheckShallow()
+	if cmd.Flags().Changed("count") {
+		count, _ := cmd.Flags().GetInt("count")
+		versions, err := f.UpcomingVersions(kind == "hotfix", count)
+		if err != nil {
+			return err
+		}
+		return printResult(cmd, strings.Join(versions, "\n"))
+	}
+
+	var plan *flow.Plan
+	if kind == "hotfix" {
+		plan, err = f.PlanHotfix()
+	} else {
+		plan, err = f.PlanRelease()
+	}
+	if err != nil {
+		return err
+	}
+
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		return printResult(cmd, plan.Version)
+	}
+	fmt.Printf("Version: %s\n", plan.Version)
+	fmt.Printf("Branch:  %s (from %s)\n", plan.Branch, plan.Base)
+	return nil
+}
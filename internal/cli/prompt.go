@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ask prints question and returns the answer read from stdin, trimmed.
+func ask(cmd *cobra.Command, question string) string {
+	fmt.Fprint(cmd.OutOrStdout(), question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+// confirm prints summary and asks whether to proceed. Only "y" or "yes"
+// proceeds.
+func confirm(cmd *cobra.Command, summary string) bool {
+	switch strings.ToLower(ask(cmd, summary+"\nProceed? [y/N] ")) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// confirmVersion shows the current and computed next version and asks
+// whether to proceed. An empty answer or "y" accepts next, "n" declines,
+// and anything else is taken as the version to use instead.
+func confirmVersion(cmd *cobra.Command, current, next string) (string, bool) {
+	if current == "" {
+		current = "none"
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Current version: %s\nNext version:    %s\n", current, next)
+	answer := ask(cmd, "Start the release with "+next+"? [Y/n, or type a version] ")
+	switch strings.ToLower(answer) {
+	case "", "y", "yes":
+		return next, true
+	case "n", "no":
+		return "", false
+	}
+	return answer, true
+}
+
+// mainPushConfirmation returns the question asked before a finish pushes
+// main, with confirm_main_push. When stdin isn't a terminal nobody can
+// answer, so it declines.
+func mainPushConfirmation(cmd *cobra.Command) func(summary string) bool {
+	if !isTerminal(os.Stdin) {
+		return func(string) bool {
+			fmt.Fprintln(cmd.ErrOrStderr(), "confirm_main_push is set but stdin isn't a terminal; rerun with --yes to push")
+			return false
+		}
+	}
+	return func(summary string) bool { return confirm(cmd, summary) }
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+	"github.com/kloudlabs-io/mkrel/internal/github"
+)
+
+// githubPublisher publishes releases as GitHub releases (github_release),
+// attaching the files matching release_assets.
+type githubPublisher struct {
+	ctx    context.Context
+	assets []string // Glob patterns of files to upload
+}
+
+// Check verifies that the remote is on GitHub and a token is set.
+func (p *githubPublisher) Check(remoteURL string) error {
+	if _, err := github.ParseRemote(remoteURL); err != nil {
+		return fmt.Errorf("github_release: %w", err)
+	}
+	if github.Token() == "" {
+		return errors.New("github_release is set, but GITHUB_TOKEN (or GH_TOKEN) is not")
+	}
+	return nil
+}
+
+// Publish creates the GitHub release. With assets, the release stays a
+// draft until they are all uploaded, so nobody sees it with files
+// missing; if anything fails, the temporary draft is deleted, so that
+// publishing can simply be retried.
+func (p *githubPublisher) Publish(pub flow.Publication) (url string, err error) {
+	repo, err := github.ParseRemote(pub.RemoteURL)
+	if err != nil {
+		return "", err
+	}
+	client := github.NewClient(github.Token())
+	release, err := client.CreateRelease(p.ctx, repo, github.Release{
+		TagName:    pub.Tag,
+		Name:       pub.Version,
+		Body:       pub.Notes,
+		Draft:      pub.Draft || len(p.assets) > 0,
+		Prerelease: pub.Prerelease,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(p.assets) == 0 {
+		return release.URL, nil
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := client.DeleteRelease(p.ctx, release); deleteErr != nil {
+			err = fmt.Errorf("%w; the draft release %s is left over: %v", err, release.URL, deleteErr)
+		}
+	}()
+
+	if err := p.uploadAssets(pub, client, release); err != nil {
+		return "", err
+	}
+	if pub.Draft {
+		return release.URL, nil
+	}
+	published, err := client.PublishDraft(p.ctx, release)
+	if err != nil {
+		return "", err
+	}
+	return published.URL, nil
+}
+
+// uploadAssets uploads the files matching the asset patterns, reporting
+// each one through the publication's events. Every file is attempted even
+// if an earlier one fails.
+func (p *githubPublisher) uploadAssets(pub flow.Publication, client *github.Client, release *github.CreatedRelease) error {
+	var failed int
+	for _, pattern := range p.assets {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			pub.Warn("    Warning: no files match %s", pattern)
+		}
+		for _, path := range paths {
+			pub.Step("upload-asset", map[string]string{"tag": pub.Tag, "path": path},
+				"    Uploading: %s", path)
+			if err := client.UploadAsset(p.ctx, release, path); err != nil {
+				pub.Warn("    Warning: failed to upload %s: %v", path, err)
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d asset(s) failed to upload", failed)
+	}
+	return nil
+}
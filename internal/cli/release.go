@@ -1,10 +1,18 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 
-	"github.com/kloudlabs-io/mkrel/internal/config"
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
 	"github.com/kloudlabs-io/mkrel/internal/flow"
+	"github.com/kloudlabs-io/mkrel/internal/github"
+	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
 // releaseCmd is a parent command - it groups related subcommands.
@@ -25,87 +33,386 @@ var releaseStartCmd = &cobra.Command{
 
 This will:
   1. Verify no release is already in progress
-  2. Calculate the next version (CalVer date or SemVer minor bump)
-  3. Create release/<version> branch from develop`,
+  2. Verify develop has changes since the last release (or --allow-empty)
+  3. Calculate the next version (CalVer date or SemVer minor bump)
+  4. Create release/<version> branch from develop
+
+With --push, the release candidate version (SemVer X.Y.Z-rc.0) is also
+tagged on the new branch and the tag pushed, so CI can build it.
+
+With --interactive, the current and next version are shown and the
+branch is only created once confirmed; typing a version starts that one
+instead. The question is skipped with --yes or when stdin isn't a
+terminal.`,
 
 	RunE: runReleaseStart,
 }
 
 // releaseFinishCmd finishes the current release.
 var releaseFinishCmd = &cobra.Command{
-	Use:   "finish",
+	Use:   "finish [version]",
 	Short: "Finish the current release",
 	Long: `Finish the current release branch.
 
+If several release branches exist, pass the version to finish
+(e.g., "mkrel release finish 1.3.0-rc.0").
+
 This will:
   1. Finalize the version (remove RC suffix if any)
   2. Merge release branch to main
   3. Tag the release
   4. Merge back to develop
-  5. Push everything to remote
-  6. Delete the local release branch`,
+  5. Push everything to remote, force-updating any moving_tags
+  6. Delete the local release branch
+  7. With prune_features, delete local feature branches merged into develop
+
+To recover a botched release, --only runs just the listed steps (e.g.,
+"--only tag,push"). Skipped steps can leave branches, tags and the remote
+inconsistent. If only the merge back into develop is missing (main was
+already merged and tagged), --sync-develop-only merges main into develop
+and pushes develop, without touching main or tags.`,
 
+	Args: cobra.MaximumNArgs(1),
 	RunE: runReleaseFinish,
 }
 
+// releaseRenameCmd changes the version of the current release.
+var releaseRenameCmd = &cobra.Command{
+	Use:   "rename <version>",
+	Short: "Change the version of the current release",
+	Long: `Rename the current release branch to a new version
+(e.g., "mkrel release rename 1.4.0-rc.0" renames release/1.3.0-rc.0 to
+release/1.4.0-rc.0).
+
+Use this when the computed version was wrong, instead of deleting the
+branch and starting over. The version must be valid for the configured
+scheme and not already released.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: runReleaseRename,
+}
+
+// releasePRCmd opens a pull request for the current release.
+var releasePRCmd = &cobra.Command{
+	Use:   "pr [version]",
+	Short: "Open a pull request for the current release",
+	Long: `Push the current release branch and open a pull request merging it into
+main, with the release notes as its description. Use this instead of
+"release finish" when main only accepts changes through pull requests.
+
+The pull request is opened on GitHub with the token in GITHUB_TOKEN or
+GH_TOKEN. Without a token, or for other hosts, the branch is still pushed
+and the pull request can be opened by hand.
+
+Nothing is merged or tagged. Once the pull request is merged, pull main and
+tag, push and publish the release with the command printed at the end, e.g.
+"mkrel release finish 1.2.0-rc.0 --only tag,push,publish,cleanup".`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReleasePR,
+}
+
+// releaseChannelCmd moves the current release to a prerelease channel.
+var releaseChannelCmd = &cobra.Command{
+	Use:   "channel <alpha|beta|rc>",
+	Short: "Move the current release to a prerelease channel",
+	Long: `Move the current release to a prerelease channel, keeping its version
+(e.g., "mkrel release channel beta" renames release/1.3.0-alpha.2 to
+release/1.3.0-beta.0). Choosing the current channel again increments the
+prerelease number (1.3.0-beta.0 -> 1.3.0-beta.1).
+
+Channels go alpha -> beta -> rc. Going back to an earlier channel fails
+unless --force is given. SemVer only.`,
+
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: version.Channels,
+	RunE:      runReleaseChannel,
+}
+
+// releaseListCmd lists the release branches in progress.
+var releaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List releases in progress",
+	Long: `List the release branches that haven't been finished, with their version.
+"mkrel list" lists finished releases instead.
+
+With --json, prints an array of {branch, version} objects; version is left
+out for a branch whose name isn't a valid version.`,
+
+	Args: cobra.NoArgs,
+	RunE: runReleaseList,
+}
+
+// releaseNoteCmd prints the release notes of an existing tag.
+var releaseNoteCmd = &cobra.Command{
+	Use:   "note <tag>",
+	Short: "Print the release notes of an existing release",
+	Long: `Print the release notes for a tag, generated from the commits since
+the previous release (e.g., "mkrel release note v1.3.0").
+
+--format selects markdown (default, as in changelog_in_tag), plain text,
+or json.
+
+With --stored, the message stored in the tag (e.g., the notes written with
+changelog_in_tag) is printed instead. Lightweight tags have no message, so
+their notes are generated.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: runReleaseNote,
+}
+
 func init() {
 	rootCmd.AddCommand(releaseCmd)
 	releaseCmd.AddCommand(releaseStartCmd)
 	releaseCmd.AddCommand(releaseFinishCmd)
+	releaseCmd.AddCommand(releaseRenameCmd)
+	releaseCmd.AddCommand(releaseNoteCmd)
+	releaseCmd.AddCommand(releasePRCmd)
+	releaseCmd.AddCommand(releaseChannelCmd)
+	releaseCmd.AddCommand(releaseListCmd)
+
+	releaseStartCmd.Flags().Bool("push", false, "tag and push the release candidate (SemVer only)")
+	releaseStartCmd.Flags().Bool("resume", false, "check out the release in progress, if any, instead of failing")
+	releaseStartCmd.Flags().Bool("allow-empty", false, "start even if nothing changed since the last release")
+	releaseStartCmd.Flags().BoolP("interactive", "i", false, "confirm the computed version, or type another, before starting")
+	releaseStartCmd.Flags().BoolP("yes", "y", false, "don't ask with --interactive (e.g., in scripts)")
+	releaseFinishCmd.Flags().Bool("force", false, "finish even if the release branch isn't based on develop")
+	releaseFinishCmd.Flags().Bool("set-upstream", false, "push main and develop with -u if they have no upstream")
+	releaseFinishCmd.Flags().Bool("draft", false, "publish the GitHub release as a draft (see github_release)")
+	releaseFinishCmd.Flags().Bool("prerelease", false, "mark the GitHub release as a prerelease (see github_release)")
+	releaseFinishCmd.Flags().StringP("message", "m", "", "tag annotation (overrides the default and changelog_in_tag)")
+	releaseFinishCmd.Flags().Bool("force-delete", false, "delete the branch even if git doesn't consider it merged (git branch -D)")
+	releaseFinishCmd.Flags().Bool("auto-hotfix", false, "if the version is already tagged (e.g., a second CalVer release today), finish as the next hotfix")
+	releaseFinishCmd.Flags().Bool("retag", false, "if the version is already tagged, replace the tag and force-push it")
+	releaseFinishCmd.Flags().StringSlice("only", nil, "run only these steps, for recovery: "+strings.Join(flow.FinishSteps, ", "))
+	releaseFinishCmd.Flags().BoolP("yes", "y", false, "push main without asking (see confirm_main_push)")
+	releaseFinishCmd.Flags().Bool("sync-develop-only", false, "only merge main into develop and push develop, for recovery when main is already released")
+
+	releaseChannelCmd.Flags().Bool("force", false, "allow going back to an earlier channel")
+
+	releaseListCmd.Flags().Bool("json", false, "print releases in progress as JSON")
 
+	releaseNoteCmd.Flags().String("format", "markdown", "output format: markdown, plain, or json")
+	releaseNoteCmd.Flags().Bool("stored", false, "print the message stored in the tag instead of generating notes")
+
+	releaseCmd.PersistentFlags().Bool("strict", false, "fail if lint_commits finds commits that aren't conventional")
+
+	releaseCmd.PersistentFlags().Bool("force-unlock", false, "remove a stale lock left by an interrupted mkrel run")
 }
 
 // runReleaseStart executes the release start command.
 func runReleaseStart(cmd *cobra.Command, args []string) error {
-	// Get flags
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	configPath, _ := cmd.Flags().GetString("config")
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	push, _ := cmd.Flags().GetBool("push")
+	strict, _ := cmd.Flags().GetBool("strict")
+	resume, _ := cmd.Flags().GetBool("resume")
+	allowEmpty, _ := cmd.Flags().GetBool("allow-empty")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	yes, _ := cmd.Flags().GetBool("yes")
 
-	// Load config (uses defaults if no config file)
-	cfg, err := config.Load(configPath)
+	opts := flow.ReleaseStartOptions{Push: push, Strict: strict, Resume: resume, AllowEmpty: allowEmpty}
+	if interactive && !yes && isTerminal(os.Stdin) {
+		opts.Confirm = func(current, next string) (string, bool) { return confirmVersion(cmd, current, next) }
+	}
+	return f.ReleaseStart(opts)
+}
+
+// runReleaseFinish executes the release finish command.
+func runReleaseFinish(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
 	if err != nil {
 		return err
 	}
 
-	// Create flow with config
-	f, err := flow.New(flow.Options{
-		Scheme:     cfg.Scheme,
-		Remote:     cfg.Remote,
-		MainBranch: cfg.Branches.Main,
-		DevBranch:  cfg.Branches.Develop,
-		DryRun:     dryRun,
-		Verbose:    verbose,
-	})
+	force, _ := cmd.Flags().GetBool("force")
+	strict, _ := cmd.Flags().GetBool("strict")
+	setUpstream, _ := cmd.Flags().GetBool("set-upstream")
+	draft, _ := cmd.Flags().GetBool("draft")
+	prerelease, _ := cmd.Flags().GetBool("prerelease")
+	message, _ := cmd.Flags().GetString("message")
+	forceDelete, _ := cmd.Flags().GetBool("force-delete")
+	autoHotfix, _ := cmd.Flags().GetBool("auto-hotfix")
+	retag, _ := cmd.Flags().GetBool("retag")
+	only, _ := cmd.Flags().GetStringSlice("only")
+	syncDevelopOnly, _ := cmd.Flags().GetBool("sync-develop-only")
+
+	opts := flow.ReleaseFinishOptions{
+		Force:       force,
+		Strict:      strict,
+		SetUpstream: setUpstream,
+		Draft:       draft,
+		Prerelease:  prerelease,
+		Message:     message,
+		ForceDelete: forceDelete,
+		AutoHotfix:  autoHotfix,
+		Retag:       retag,
+		Only:        only,
+
+		SyncDevelopOnly: syncDevelopOnly,
+	}
+	if len(args) > 0 {
+		opts.Version = args[0]
+	}
+
+	return f.ReleaseFinish(opts)
+}
+
+// runReleaseList executes the release list command.
+func runReleaseList(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
 	if err != nil {
 		return err
 	}
 
-	return f.ReleaseStart()
+	releases, err := f.ReleasesInProgress()
+	if err != nil {
+		return err
+	}
+	return printInProgress(cmd, "releases", releases)
 }
 
-// runReleaseFinish executes the release finish command.
-func runReleaseFinish(cmd *cobra.Command, args []string) error {
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	configPath, _ := cmd.Flags().GetString("config")
+// runReleasePR executes the release pr command.
+func runReleasePR(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
 
-	cfg, err := config.Load(configPath)
+	var ver string
+	if len(args) > 0 {
+		ver = args[0]
+	}
+	pr, err := f.ReleasePR(ver)
 	if err != nil {
 		return err
 	}
 
-	f, err := flow.New(flow.Options{
-		Scheme:     cfg.Scheme,
-		Remote:     cfg.Remote,
-		MainBranch: cfg.Branches.Main,
-		DevBranch:  cfg.Branches.Develop,
-		DryRun:     dryRun,
-		Verbose:    verbose,
+	repo, err := github.ParseRemote(pr.RemoteURL)
+	if err != nil {
+		fmt.Printf("Pushed %s; open a pull request into %s on your Git host (%v)\n", pr.Head, pr.Base, err)
+		printPRFollowUp(pr)
+		return nil
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		fmt.Printf("Would open pull request on %s: %s (%s -> %s)\n", repo, pr.Title, pr.Head, pr.Base)
+		return nil
+	}
+	token := github.Token()
+	if token == "" {
+		fmt.Printf("GITHUB_TOKEN is not set; open the pull request at %s\n", repo.CompareURL(pr.Base, pr.Head))
+		printPRFollowUp(pr)
+		return nil
+	}
+
+	url, err := github.NewClient(token).CreatePullRequest(cmd.Context(), repo, github.PullRequest{
+		Title: pr.Title,
+		Head:  pr.Head,
+		Base:  pr.Base,
+		Body:  pr.Body,
 	})
 	if err != nil {
 		return err
 	}
+	fmt.Printf("Opened pull request: %s\n", url)
+	printPRFollowUp(pr)
+	return nil
+}
+
+// printPRFollowUp prints how to tag and publish the release once its
+// pull request is merged.
+func printPRFollowUp(pr *flow.PullRequest) {
+	fmt.Println("")
+	fmt.Println("Once it is merged, tag and publish the release from the updated main:")
+	fmt.Printf("  git switch %s && git pull\n", pr.Base)
+	fmt.Printf("  mkrel release finish %s --only %s\n", pr.Version, strings.Join(pr.FinishSteps, ","))
+}
+
+// runReleaseRename executes the release rename command.
+func runReleaseRename(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
 
-	return f.ReleaseFinish()
+	return f.ReleaseRename(args[0])
+}
+
+// runReleaseChannel executes the release channel command.
+func runReleaseChannel(cmd *cobra.Command, args []string) error {
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	return f.ReleaseChannel(args[0], force)
+}
+
+// notesJSON is the --format json representation of release notes.
+type notesJSON struct {
+	Version  string              `json:"version"`
+	Tag      string              `json:"tag"`
+	Previous string              `json:"previous,omitempty"`
+	Since    string              `json:"since"`
+	Date     string              `json:"date"`
+	Sections []changelog.Section `json:"sections"`
+}
+
+// runReleaseNote executes the release note command.
+func runReleaseNote(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "markdown" && format != "plain" && format != "json" {
+		return fmt.Errorf("unknown format: %s (use markdown, plain, or json)", format)
+	}
+
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	if stored, _ := cmd.Flags().GetBool("stored"); stored {
+		message, err := f.TagMessage(args[0])
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			return printResult(cmd, message)
+		}
+		fmt.Fprintf(os.Stderr, "%s is a lightweight tag with no message; generating notes\n", args[0])
+	}
+
+	notes, err := f.TagNotes(args[0])
+	if err != nil {
+		return err
+	}
+
+	var text string
+	switch format {
+	case "markdown":
+		text = changelog.Render(notes.Version, notes.Date, notes.Sections)
+	case "plain":
+		text = changelog.RenderPlain(notes.Version, notes.Date, notes.Sections)
+	case "json":
+		sections := notes.Sections
+		if sections == nil {
+			sections = []changelog.Section{}
+		}
+		data, err := json.MarshalIndent(notesJSON{
+			Version:  notes.Version,
+			Tag:      notes.Tag,
+			Previous: notes.Previous,
+			Since:    notes.Since,
+			Date:     notes.Date.Format(time.RFC3339),
+			Sections: sections,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	return printResult(cmd, strings.TrimSuffix(text, "\n"))
 }
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// remoteCmd is a parent command - it groups remote subcommands.
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manage the git remote releases are pushed to",
+}
+
+// remoteAddCmd adds a git remote.
+var remoteAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a git remote",
+	Long: `Add a git remote, for a repository created locally that has nowhere
+to push releases yet (e.g., "mkrel remote add origin git@github.com:org/repo.git").
+
+Releases are pushed to the remote named by the "remote" setting
+(default: origin).`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: runRemoteAdd,
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteAddCmd)
+}
+
+// runRemoteAdd executes the remote add command. It doesn't need a Flow,
+// so it works in a repository without commits yet.
+func runRemoteAdd(cmd *cobra.Command, args []string) error {
+	name, url := args[0], args[1]
+	repo, err := openRepository(cmd)
+	if err != nil {
+		return err
+	}
+	if existing, err := repo.RemoteURL(name); err == nil {
+		return fmt.Errorf("remote %s already exists (%s)", name, existing)
+	}
+
+	if err := repo.AddRemote(name, url); err != nil {
+		return fmt.Errorf("failed to add remote: %w", err)
+	}
+	fmt.Printf("==> Added remote %s: %s\n", name, url)
+	return nil
+}
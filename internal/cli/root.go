@@ -2,7 +2,17 @@
 package cli
 
 import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
 	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/config"
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+	"github.com/kloudlabs-io/mkrel/internal/git"
+	"github.com/kloudlabs-io/mkrel/internal/term"
 )
 
 // Build-time variables set by GoReleaser via -ldflags.
@@ -24,15 +34,177 @@ It handles the complete release lifecycle:
   - Merging to main and develop
   - Tagging and pushing to remote`,
 	SilenceUsage: true,
+
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setupColor(cmd)
+		startUpdateCheck(cmd)
+	},
 }
 
 // Execute runs the root command.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	printUpdateNotice()
+	return err
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "verbose output (-vv also prints git output)")
+	rootCmd.PersistentFlags().Bool("debug", false, "print git commands and their output (same as -vv)")
 	rootCmd.PersistentFlags().Bool("dry-run", false, "show what would be done without making changes")
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file (default: .mkrel.yaml)")
+	rootCmd.PersistentFlags().String("output", "", "write the result (version or release notes) to a file")
+	rootCmd.PersistentFlags().Bool("check-update", false, "notify if a newer mkrel release exists (same as check_updates)")
+	rootCmd.PersistentFlags().String("git-dir", "", "git directory, for a work tree whose .git is elsewhere (default: $GIT_DIR)")
+	rootCmd.PersistentFlags().Bool("no-color", false, "strip colors from output, including git's (same as NO_COLOR)")
+	rootCmd.PersistentFlags().String("log", "", "write a structured log of git commands and steps to stderr: text or json")
+	rootCmd.PersistentFlags().Duration("timeout", 60*time.Second, "stop git commands that run longer, e.g., a hung fetch or push (0 = no limit)")
+}
+
+// openRepository opens the git repository using the command's flags,
+// for setup commands that must work before a Flow can be created (e.g.,
+// in a repository without commits or a main branch).
+func openRepository(cmd *cobra.Command) (*git.Repository, error) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	verbosity, _ := cmd.Flags().GetCount("verbose")
+	gitDir, _ := cmd.Flags().GetString("git-dir")
+	return git.NewRepositoryWithGitDir("", gitDir, dryRun, verbosity > 0)
+}
+
+// newFlow loads configuration and creates a Flow using the command's flags.
+func newFlow(cmd *cobra.Command) (*flow.Flow, error) {
+	return newFlowTo(cmd, cmd.OutOrStdout())
+}
+
+// newQueryFlow is like newFlow, but reports progress and warnings on
+// stderr, keeping stdout for the result of commands like current.
+func newQueryFlow(cmd *cobra.Command) (*flow.Flow, error) {
+	return newFlowTo(cmd, cmd.ErrOrStderr())
+}
+
+// newFlowTo creates a flow that reports its progress to out.
+func newFlowTo(cmd *cobra.Command, out io.Writer) (*flow.Flow, error) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	verbosity, _ := cmd.Flags().GetCount("verbose")
+	debug, _ := cmd.Flags().GetBool("debug")
+	forceUnlock, _ := cmd.Flags().GetBool("force-unlock")
+	configPath, _ := cmd.Flags().GetString("config")
+	output, _ := cmd.Flags().GetString("output")
+	gitDir, _ := cmd.Flags().GetString("git-dir")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	logger, err := newLogger(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load config (uses defaults if no config file)
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var versionFile string
+	if cfg.VersionSource == config.VersionSourceFile {
+		versionFile = cfg.VersionFilePath
+	}
+
+	var confirmMainPush func(string) bool
+	// Only release and hotfix finish push main; other commands' --yes
+	// answers their own questions (e.g., undo's)
+	var yes bool
+	if cmd.Name() == "finish" {
+		yes, _ = cmd.Flags().GetBool("yes")
+	}
+	if cfg.ConfirmMainPush && !yes {
+		confirmMainPush = mainPushConfirmation(cmd)
+	}
+
+	var publisher flow.ReleasePublisher
+	if cfg.GitHubRelease {
+		publisher = &githubPublisher{ctx: cmd.Context(), assets: cfg.ReleaseAssets}
+	}
+
+	return flow.New(flow.Options{
+		GitDir:             gitDir,
+		Scheme:             cfg.Scheme,
+		BranchSchemes:      cfg.BranchSchemes,
+		Remote:             cfg.Remote,
+		Namespace:          cfg.Namespace,
+		MainBranch:         cfg.Branches.Main,
+		DevBranch:          cfg.Branches.Develop,
+		DevelopOptional:    !cfg.RequireDevelop,
+		AutoCreateDevelop:  cfg.AutoCreateDevelop,
+		DevelopCandidates:  cfg.DevelopCandidates,
+		DryRun:             dryRun,
+		Verbose:            verbosity > 0,
+		Debug:              debug || verbosity > 1,
+		ForceUnlock:        forceUnlock,
+		Output:             out,
+		ChangelogInTag:     cfg.ChangelogInTag,
+		ChangelogStyle:     cfg.ChangelogStyle,
+		ChangelogExclude:   cfg.ChangelogExclude,
+		ValidatePush:       cfg.ValidatePush,
+		LintCommits:        cfg.LintCommits,
+		VerifySignatures:   cfg.VerifySignatures,
+		FinalBranchNames:   cfg.ReleaseBranchVersion == config.ReleaseBranchFinal,
+		PruneFeatures:      cfg.PruneFeatures,
+		MovingTags:         cfg.MovingTags,
+		VersionFile:        versionFile,
+		ChangelogFile:      cfg.ChangelogFile,
+		NotesFile:          output,
+		Publisher:          publisher,
+		DraftReleases:      cfg.GitHubReleaseDraft,
+		Messages:           cfg.Messages,
+		HotfixSkipDevelop:  !cfg.HotfixMergeDevelop,
+		HotfixNeedsRelease: !cfg.HotfixWithoutRelease,
+		ReleaseSkipDevelop: !cfg.ReleaseMergeDevelop,
+		MergeMessage:       cfg.MergeMessageTemplate,
+		CacheReads:         true,
+		Timeout:            timeout,
+		Logger:             logger,
+		IgnoreDirty:        cfg.IgnoreDirty,
+		AutoUnshallow:      cfg.AutoUnshallow,
+		ConfirmMainPush:    confirmMainPush,
+	})
+}
+
+// newLogger returns the structured logger selected with --log, or nil
+// when it isn't set.
+func newLogger(cmd *cobra.Command) (*slog.Logger, error) {
+	format, _ := cmd.Flags().GetString("log")
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch format {
+	case "":
+		return nil, nil
+	case "text":
+		return slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(cmd.ErrOrStderr(), opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid --log format %q (valid: text, json)", format)
+	}
+}
+
+// printResult prints a command's result, or writes it to the --output
+// file if one was given.
+func printResult(cmd *cobra.Command, result string) error {
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		return flow.WriteOutput(output, result)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), result)
+	return nil
+}
+
+// setupColor strips ANSI colors from everything mkrel prints, including
+// error messages and git output passed through, unless colors are
+// enabled (see term.ColorEnabled).
+func setupColor(cmd *cobra.Command) {
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	if term.ColorEnabled(noColor) {
+		return
+	}
+	root := cmd.Root()
+	root.SetOut(term.Plain(root.OutOrStdout()))
+	root.SetErr(term.Plain(root.ErrOrStderr()))
 }
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSetupColor(t *testing.T) {
+	colored := "\x1b[1;31merror:\x1b[m The branch 'release/1.2.0' is not fully merged."
+
+	tests := []struct {
+		name    string
+		noColor string // NO_COLOR
+		args    []string
+		want    string
+	}{
+		{
+			name: "colors",
+			want: colored,
+		},
+		{
+			name:    "NO_COLOR",
+			noColor: "1",
+			want:    "error: The branch 'release/1.2.0' is not fully merged.",
+		},
+		{
+			name: "--no-color",
+			args: []string{"--no-color"},
+			want: "error: The branch 'release/1.2.0' is not fully merged.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TERM", "xterm-256color")
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			// Prints git output passed through, then fails with it, as a
+			// failed merge does
+			cmd := &cobra.Command{
+				Use:              "mkrel",
+				SilenceUsage:     true,
+				PersistentPreRun: func(cmd *cobra.Command, args []string) { setupColor(cmd) },
+				RunE: func(cmd *cobra.Command, args []string) error {
+					fmt.Fprintln(cmd.OutOrStdout(), colored)
+					return errors.New(colored)
+				},
+			}
+			cmd.Flags().Bool("no-color", false, "")
+			var out, errOut bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&errOut)
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err == nil {
+				t.Fatal("Execute() error = nil, want the command's error")
+			}
+			if got := strings.TrimSuffix(out.String(), "\n"); got != tt.want {
+				t.Errorf("stdout = %q, want %q", got, tt.want)
+			}
+			if got := strings.TrimSuffix(errOut.String(), "\n"); got != "Error: "+tt.want {
+				t.Errorf("stderr = %q, want %q", got, "Error: "+tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFlow_DetectsMain(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// A repository whose main branch is "trunk", as the remote's HEAD says
+	remote := t.TempDir()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"-C", remote, "init", "-q", "--bare", "-b", "trunk"},
+		{"-C", dir, "init", "-q", "-b", "trunk"},
+		{"-C", dir, "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"-C", dir, "branch", "develop"},
+		{"-C", dir, "remote", "add", "origin", remote},
+		{"-C", dir, "push", "-q", "origin", "trunk", "develop"},
+		{"-C", dir, "remote", "set-head", "origin", "trunk"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	t.Chdir(dir)
+
+	f, err := newFlow(&cobra.Command{})
+	if err != nil {
+		t.Fatalf("newFlow() error = %v", err)
+	}
+	plan, err := f.PlanHotfix()
+	if err != nil {
+		t.Fatalf("PlanHotfix() error = %v", err)
+	}
+	if plan.Base != "trunk" {
+		t.Errorf("hotfix base = %q, want the detected main branch trunk", plan.Base)
+	}
+}
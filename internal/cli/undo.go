@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/flow"
+)
+
+// undoCmd reverts the last finish.
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last release or hotfix finish locally",
+	Long: `Revert the last "release finish" or "hotfix finish".
+
+Before merging, finish records the branches it is about to change in
+.git/mkrel-undo.json. Undo resets those branches to the recorded commits,
+recreates the release or hotfix branch, and deletes the new tag.
+
+Undo only changes local refs. If the finish already pushed, the remote
+branches and tag are unchanged and must be fixed by hand.
+
+The checked-out branch is reset with "git reset --hard", so undo lists
+the resets and asks for confirmation first; pass --yes to skip the
+question (required when stdin isn't a terminal).`,
+
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().Bool("force-unlock", false, "remove a stale lock left by an interrupted mkrel run")
+	undoCmd.Flags().BoolP("yes", "y", false, "undo without asking for confirmation")
+}
+
+// runUndo executes the undo command.
+func runUndo(cmd *cobra.Command, args []string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if !yes && !dryRun && !isTerminal(os.Stdin) {
+		return fmt.Errorf("undo resets branches and needs confirmation; rerun with --yes")
+	}
+
+	f, err := newFlow(cmd)
+	if err != nil {
+		return err
+	}
+
+	var opts flow.UndoOptions
+	if !yes {
+		opts.Confirm = func(summary string) bool { return confirm(cmd, summary) }
+	}
+	return f.Undo(opts)
+}
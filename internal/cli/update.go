@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/config"
+	"github.com/kloudlabs-io/mkrel/internal/update"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// updateCmd replaces the running binary with the latest release.
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update mkrel to the latest release",
+	Long: `Check GitHub for a newer mkrel release and install it over the running
+binary. The download is verified against the release checksums.
+
+Use --check to only report whether an update is available.`,
+
+	Args: cobra.NoArgs,
+	RunE: runUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().Bool("check", false, "only report whether a newer release is available")
+}
+
+// runUpdate executes the update command.
+func runUpdate(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+
+	if !version.IsValid(Version, version.SchemeSemVer) {
+		return fmt.Errorf("mkrel %s is a development build and can't be compared with releases", Version)
+	}
+
+	release, err := update.Latest(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	newer, err := version.Compare(release.Version(), Version, version.SchemeSemVer)
+	if err != nil {
+		return err
+	}
+	if newer <= 0 {
+		fmt.Printf("mkrel %s is up to date\n", Version)
+		return nil
+	}
+	if check {
+		fmt.Printf("mkrel %s is available (current: %s)\n", release.Version(), Version)
+		fmt.Println("Run \"mkrel update\" to install it")
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the mkrel binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to locate the mkrel binary: %w", err)
+	}
+
+	fmt.Printf("Updating mkrel %s -> %s\n", Version, release.Version())
+	if err := update.Install(release, exe); err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s\n", exe)
+	return nil
+}
+
+// updateCheckTimeout bounds the background update check, so a slow
+// network never holds up a command.
+const updateCheckTimeout = 2 * time.Second
+
+// updateNotice receives the result of the background update check:
+// a notice if a newer release exists, otherwise empty string.
+var updateNotice <-chan string
+
+// startUpdateCheck checks for a newer release in the background when
+// check_updates (or --check-update) is set. It does nothing in CI, when
+// stderr isn't a terminal, or for development builds.
+func startUpdateCheck(cmd *cobra.Command) {
+	if cmd == updateCmd || os.Getenv("CI") != "" || !isTerminal(os.Stderr) {
+		return
+	}
+	if !version.IsValid(Version, version.SchemeSemVer) {
+		return
+	}
+
+	enabled, _ := cmd.Flags().GetBool("check-update")
+	if !enabled {
+		configPath, _ := cmd.Flags().GetString("config")
+		cfg, err := config.Load(configPath)
+		enabled = err == nil && cfg.CheckUpdates
+	}
+	if !enabled {
+		return
+	}
+
+	notice := make(chan string, 1)
+	updateNotice = notice
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+
+		latest, err := update.LatestVersion(ctx, update.StatePath())
+		if err != nil {
+			notice <- ""
+			return
+		}
+		if newer, err := version.Compare(latest, Version, version.SchemeSemVer); err != nil || newer <= 0 {
+			notice <- ""
+			return
+		}
+		notice <- fmt.Sprintf("A new release of mkrel is available: %s -> %s (run \"mkrel update\")", Version, latest)
+	}()
+}
+
+// printUpdateNotice prints the update check's notice, if any. A check
+// still in flight is abandoned rather than waited for, so it never delays
+// the exit.
+func printUpdateNotice() {
+	if updateNotice == nil {
+		return
+	}
+	select {
+	case msg := <-updateNotice:
+		if msg != "" {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+	default:
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// validateCmd checks a version against the scheme.
+var validateCmd = &cobra.Command{
+	Use:   "validate <version>",
+	Short: "Check that a version is valid for the scheme",
+	Long: `Check that a version is valid for the configured scheme (or --scheme),
+e.g., to gate versions entered by hand in CI. Exits non-zero with the reason
+if it isn't. No repository is needed.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().String("scheme", "", "versioning scheme: semver or calver (default: from config)")
+}
+
+// runValidate executes the validate command.
+func runValidate(cmd *cobra.Command, args []string) error {
+	scheme, err := flagScheme(cmd)
+	if err != nil {
+		return err
+	}
+	if err := version.Validate(args[0], scheme); err != nil {
+		return err
+	}
+	fmt.Printf("%s is a valid %s version\n", args[0], scheme)
+	return nil
+}
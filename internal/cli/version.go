@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"runtime"
 
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,12 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("mkrel %s\n", Version)
 		fmt.Printf("  commit: %s\n", Commit)
 		fmt.Printf("  built:  %s\n", Date)
+
+		// The Go toolchain and platform help when reporting bugs
+		if full, _ := cmd.Flags().GetBool("full"); full {
+			fmt.Printf("  go:     %s\n", runtime.Version())
+			fmt.Printf("  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		}
 	},
 }
 
@@ -24,4 +31,5 @@ var versionCmd = &cobra.Command{
 // Each file can have its own init() - they all run on package load.
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().Bool("full", false, "also print the Go version and platform")
 }
@@ -4,18 +4,38 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
 	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
+// Values for ReleaseBranchVersion.
+const (
+	ReleaseBranchPrerelease = "prerelease" // release/1.3.0-rc.0
+	ReleaseBranchFinal      = "final"      // release/1.3.0
+)
+
+// Values for VersionSource.
+const (
+	VersionSourceTags = "tags" // Latest version tag
+	VersionSourceFile = "file" // Contents of VersionFilePath
+)
+
 // Config holds all configuration for mkrel.
 type Config struct {
 	// Scheme is the versioning scheme: "calver" or "semver"
 	Scheme version.Scheme `mapstructure:"scheme"`
 
+	// BranchSchemes overrides Scheme for specific target branches
+	// (e.g., {lts: semver}). Branch names are matched in lowercase.
+	BranchSchemes map[string]version.Scheme `mapstructure:"branch_schemes"`
+
 	// CalVerFormat is the CalVer format (default: "YYYY.MM.DD")
 	CalVerFormat string `mapstructure:"calver_format"`
 
@@ -25,13 +45,145 @@ type Config struct {
 	// Remote is the git remote name (default: "origin")
 	Remote string `mapstructure:"remote"`
 
+	// Namespace scopes mkrel's branches and tags for repositories shared
+	// by several tools: "mytool" gives branches like "mytool/release/1.2.0"
+	// and tags like "mytool-1.2.0" (optional)
+	Namespace string `mapstructure:"namespace"`
+
+	// RequireDevelop fails when no develop branch exists (default: true).
+	// When false, releases run main-only: they start from and merge into
+	// main, and nothing is merged back.
+	RequireDevelop bool `mapstructure:"require_develop"`
+
+	// AutoCreateDevelop creates a missing develop branch from main and
+	// pushes it on "mkrel init" or the first "release start" (default: false)
+	AutoCreateDevelop bool `mapstructure:"auto_create_develop"`
+
+	// DevelopCandidates lists the names tried, in order, to find the
+	// develop branch when branches.develop isn't set (default:
+	// develop, development, dev)
+	DevelopCandidates []string `mapstructure:"develop_candidates"`
+
+	// ChangelogInTag uses the generated release notes as the annotation
+	// of release and hotfix tags (default: false)
+	ChangelogInTag bool `mapstructure:"changelog_in_tag"`
+
+	// ChangelogStyle selects how commits are grouped in release notes:
+	// "conventional" (feat:, fix:, ...) or "gitmoji" (✨, 🐛, ...)
+	// (default: "conventional")
+	ChangelogStyle changelog.Style `mapstructure:"changelog_style"`
+
+	// ChangelogExclude lists regular expressions; commits whose subject
+	// matches one are left out of release notes. Set it to [] to keep
+	// merge commits (default: ["^Merge "])
+	ChangelogExclude []string `mapstructure:"changelog_exclude"`
+
+	// ChangelogFile is a Markdown file, relative to the repository root,
+	// that release and hotfix finish add the release notes to, above the
+	// previous entries (optional)
+	ChangelogFile string `mapstructure:"changelog_file"`
+
+	// ValidatePush runs "git push --dry-run" before a finish merges and
+	// tags, so a rejected push fails early (default: false)
+	ValidatePush bool `mapstructure:"validate_push"`
+
+	// LintCommits checks that commits since the last release follow
+	// Conventional Commits on release start and finish (default: false)
+	LintCommits bool `mapstructure:"lint_commits"`
+
+	// VerifySignatures fails release and hotfix finish when a commit since
+	// the last release doesn't have a good signature (default: false)
+	VerifySignatures bool `mapstructure:"verify_signatures"`
+
+	// HotfixMergeDevelop merges finished hotfixes back into develop. When
+	// false, hotfixes only land on main and develop must get the fix some
+	// other way, e.g., a cherry-pick (default: true)
+	HotfixMergeDevelop bool `mapstructure:"hotfix_merge_develop"`
+
+	// HotfixWithoutRelease lets "hotfix start" run before the first
+	// release, versioning the hotfix as a release (today's date for CalVer,
+	// 0.0.1 for SemVer). When false, it fails instead (default: true)
+	HotfixWithoutRelease bool `mapstructure:"hotfix_without_release"`
+
+	// ReleaseMergeDevelop merges main back into develop on "release
+	// finish". When false, only main is merged, tagged and pushed, and
+	// develop is reconciled separately (default: true)
+	ReleaseMergeDevelop bool `mapstructure:"release_merge_develop"`
+
+	// MergeMessageTemplate is the message of the merge commits release
+	// and hotfix finish create, with {{source}}, {{target}} and
+	// {{version}} placeholders (default: empty, git's own message)
+	MergeMessageTemplate string `mapstructure:"merge_message_template"`
+
+	// PruneFeatures deletes local feature/* branches that are fully merged
+	// into develop on "release finish" (default: false)
+	PruneFeatures bool `mapstructure:"prune_features"`
+
+	// MovingTags are tags like "latest" or "stable" that "release finish"
+	// force-updates to the new release and force-pushes (optional)
+	MovingTags []string `mapstructure:"moving_tags"`
+
+	// ReleaseBranchVersion selects the version in release branch names:
+	// "prerelease" includes the SemVer RC suffix, "final" leaves it out
+	// (default: "prerelease")
+	ReleaseBranchVersion string `mapstructure:"release_branch_version"`
+
+	// CheckUpdates prints a notice when a newer mkrel release exists,
+	// checking GitHub at most once a day. Skipped in CI and when output
+	// isn't a terminal (default: false)
+	CheckUpdates bool `mapstructure:"check_updates"`
+
+	// VersionSource selects where the current version is read from:
+	// "tags" (the latest version tag) or "file" (VersionFilePath, which
+	// finishes then update and commit) (default: "tags")
+	VersionSource string `mapstructure:"version_source"`
+
+	// VersionFilePath is the file holding the version when VersionSource
+	// is "file", relative to the repository root (default: "VERSION")
+	VersionFilePath string `mapstructure:"version_file_path"`
+
+	// GitHubRelease publishes each finished release (and release candidate
+	// pushed by "release start --push") as a GitHub release with its
+	// release notes. Needs GITHUB_TOKEN or GH_TOKEN (default: false)
+	GitHubRelease bool `mapstructure:"github_release"`
+
+	// GitHubReleaseDraft creates GitHub releases as drafts, to review
+	// and publish by hand (default: false)
+	GitHubReleaseDraft bool `mapstructure:"github_release_draft"`
+
+	// ReleaseAssets lists glob patterns (e.g., "dist/*") of files to
+	// attach to each GitHub release; needs GitHubRelease (optional)
+	ReleaseAssets []string `mapstructure:"release_assets"`
+
 	// VersionFiles lists files to update with version (optional)
 	VersionFiles []VersionFile `mapstructure:"version_files"`
+
+	// Messages overrides the message printed when an operation completes,
+	// keyed by operation (e.g., release-finish), as Go templates over its
+	// fields: "Shipped {{.version}}". An empty message suppresses it.
+	Messages map[string]string `mapstructure:"messages"`
+
+	// IgnoreDirty lists path patterns of untracked files that don't block
+	// release and hotfix commands, such as generated files: "dist/**"
+	// matches a directory, "*.log" a file name anywhere. Changes to
+	// tracked files always block (optional)
+	IgnoreDirty []string `mapstructure:"ignore_dirty"`
+
+	// AutoUnshallow runs "git fetch --unshallow --tags" before computing
+	// versions in a shallow clone, such as a CI checkout, instead of only
+	// warning. Off by default, since the fetch can take long (default: false)
+	AutoUnshallow bool `mapstructure:"auto_unshallow"`
+
+	// ConfirmMainPush asks for confirmation before release and hotfix
+	// finish push the main branch and tag, as a guardrail for production
+	// releases. Pushes that leave main alone don't ask; --yes skips the
+	// question (default: false)
+	ConfirmMainPush bool `mapstructure:"confirm_main_push"`
 }
 
 // BranchConfig holds branch naming configuration.
 type BranchConfig struct {
-	Main    string `mapstructure:"main"`    // Production branch (default: "main")
+	Main    string `mapstructure:"main"`    // Production branch (default: detected, see flow.New)
 	Develop string `mapstructure:"develop"` // Development branch (default: "develop")
 }
 
@@ -50,20 +202,77 @@ func Default() *Config {
 			Main:    "main",
 			Develop: "develop",
 		},
-		Remote:       "origin",
-		VersionFiles: []VersionFile{},
+		Remote:               "origin",
+		RequireDevelop:       true,
+		HotfixMergeDevelop:   true,
+		HotfixWithoutRelease: true,
+		ReleaseMergeDevelop:  true,
+		ChangelogStyle:       changelog.StyleConventional,
+		ChangelogExclude:     slices.Clone(changelog.DefaultExclude),
+		ReleaseBranchVersion: ReleaseBranchPrerelease,
+		VersionSource:        VersionSourceTags,
+		VersionFilePath:      "VERSION",
+		VersionFiles:         []VersionFile{},
 	}
 }
 
+// UserConfigPath returns the path of the user-level config file:
+// $XDG_CONFIG_HOME/mkrel/config.yaml, or ~/.config/mkrel/config.yaml.
+// Returns empty string if the home directory is unknown.
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "mkrel", "config.yaml")
+}
+
 // Load reads configuration from file and environment.
 // It looks for .mkrel.yaml in the current directory.
+//
+// Settings are layered, each overriding the ones below it:
+// environment variables (MKREL_<KEY>, e.g. MKREL_REMOTE or
+// MKREL_BRANCHES_MAIN), the repository config, the user config
+// (see UserConfigPath), and the defaults.
 func Load(configPath string) (*Config, error) {
+	return load(configPath, true)
+}
+
+// LoadFile reads only the config file at path over the defaults, without
+// the user config or environment, so it can be edited and saved back.
+func LoadFile(path string) (*Config, error) {
+	return load(path, false)
+}
+
+// load reads the repository config, over the user config and
+// environment if layered is set.
+func load(configPath string, layered bool) (*Config, error) {
 	// Start with defaults
 	cfg := Default()
 
 	// Set up Viper
 	v := viper.New()
 
+	if layered {
+		v.SetEnvPrefix("MKREL")
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		v.AutomaticEnv()
+
+		// The user config goes beneath the repository config
+		if userPath := UserConfigPath(); userPath != "" {
+			if _, err := os.Stat(userPath); err == nil {
+				v.SetConfigFile(userPath)
+				if err := v.ReadInConfig(); err != nil {
+					return nil, fmt.Errorf("failed to read user config: %w", err)
+				}
+			}
+		}
+	}
+
 	// Set config file name and type
 	if configPath != "" {
 		// Explicit config file path
@@ -81,9 +290,36 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("branches.main", cfg.Branches.Main)
 	v.SetDefault("branches.develop", cfg.Branches.Develop)
 	v.SetDefault("remote", cfg.Remote)
+	v.SetDefault("namespace", cfg.Namespace)
+	v.SetDefault("require_develop", cfg.RequireDevelop)
+	v.SetDefault("auto_create_develop", cfg.AutoCreateDevelop)
+	v.SetDefault("changelog_in_tag", cfg.ChangelogInTag)
+	v.SetDefault("changelog_style", string(cfg.ChangelogStyle))
+	v.SetDefault("changelog_exclude", cfg.ChangelogExclude)
+	v.SetDefault("changelog_file", cfg.ChangelogFile)
+	v.SetDefault("develop_candidates", cfg.DevelopCandidates)
+	v.SetDefault("moving_tags", cfg.MovingTags)
+	v.SetDefault("release_assets", cfg.ReleaseAssets)
+	v.SetDefault("ignore_dirty", cfg.IgnoreDirty)
+	v.SetDefault("validate_push", cfg.ValidatePush)
+	v.SetDefault("lint_commits", cfg.LintCommits)
+	v.SetDefault("verify_signatures", cfg.VerifySignatures)
+	v.SetDefault("hotfix_merge_develop", cfg.HotfixMergeDevelop)
+	v.SetDefault("hotfix_without_release", cfg.HotfixWithoutRelease)
+	v.SetDefault("auto_unshallow", cfg.AutoUnshallow)
+	v.SetDefault("confirm_main_push", cfg.ConfirmMainPush)
+	v.SetDefault("release_merge_develop", cfg.ReleaseMergeDevelop)
+	v.SetDefault("merge_message_template", cfg.MergeMessageTemplate)
+	v.SetDefault("prune_features", cfg.PruneFeatures)
+	v.SetDefault("release_branch_version", cfg.ReleaseBranchVersion)
+	v.SetDefault("check_updates", cfg.CheckUpdates)
+	v.SetDefault("version_source", cfg.VersionSource)
+	v.SetDefault("version_file_path", cfg.VersionFilePath)
+	v.SetDefault("github_release", cfg.GitHubRelease)
+	v.SetDefault("github_release_draft", cfg.GitHubReleaseDraft)
 
-	// Try to read config file
-	if err := v.ReadInConfig(); err != nil {
+	// Try to read config file, merging it over the user config
+	if err := v.MergeInConfig(); err != nil {
 		// Config file not found is OK - use defaults
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			// Other errors are real problems
@@ -105,24 +341,170 @@ func Load(configPath string) (*Config, error) {
 		cfg.Scheme = scheme
 	}
 
+	// Validate per-branch schemes the same way
+	for branch, s := range cfg.BranchSchemes {
+		scheme, err := version.ParseScheme(string(s))
+		if err != nil {
+			return nil, fmt.Errorf("branch_schemes.%s: %w", branch, err)
+		}
+		cfg.BranchSchemes[branch] = scheme
+	}
+
+	style, err := changelog.ParseStyle(string(cfg.ChangelogStyle))
+	if err != nil {
+		return nil, fmt.Errorf("changelog_style: %w", err)
+	}
+	cfg.ChangelogStyle = style
+
+	if _, err := changelog.CompileExclude(cfg.ChangelogExclude); err != nil {
+		return nil, fmt.Errorf("changelog_exclude: %w", err)
+	}
+
+	// Candidates only apply when no develop branch was named explicitly
+	if len(cfg.DevelopCandidates) > 0 && !v.InConfig("branches.develop") && os.Getenv("MKREL_BRANCHES_DEVELOP") == "" {
+		cfg.Branches.Develop = ""
+	}
+	// Without a main branch named explicitly, it is detected (e.g., "trunk"
+	// from the remote's HEAD)
+	fromEnv := layered && os.Getenv("MKREL_BRANCHES_MAIN") != ""
+	if !v.InConfig("branches.main") && !fromEnv {
+		cfg.Branches.Main = ""
+	}
+
+	switch cfg.ReleaseBranchVersion {
+	case ReleaseBranchPrerelease, ReleaseBranchFinal:
+	default:
+		return nil, fmt.Errorf("release_branch_version: invalid value %q (use %q or %q)",
+			cfg.ReleaseBranchVersion, ReleaseBranchFinal, ReleaseBranchPrerelease)
+	}
+
+	for _, pattern := range cfg.ReleaseAssets {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("release_assets: invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range cfg.IgnoreDirty {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return nil, fmt.Errorf("ignore_dirty: invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	switch cfg.VersionSource {
+	case VersionSourceTags, VersionSourceFile:
+	default:
+		return nil, fmt.Errorf("version_source: invalid value %q (use %q or %q)",
+			cfg.VersionSource, VersionSourceTags, VersionSourceFile)
+	}
+
 	return cfg, nil
 }
 
 // Save writes the configuration to a file.
 func (c *Config) Save(path string) error {
+	return c.settings().WriteConfigAs(path)
+}
+
+// SaveKeys writes only the given keys (as named for Set) and the keys
+// already in the file at path, with their values in c, so that changing
+// one setting doesn't copy every default into the file. Comments in the
+// file are not preserved.
+func (c *Config) SaveKeys(path string, keys ...string) error {
+	if _, err := os.Stat(path); err == nil {
+		existing := viper.New()
+		existing.SetConfigFile(path)
+		if err := existing.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		keys = append(keys, existing.AllKeys()...)
+	}
+
+	all := c.settings()
+	v := viper.New()
+	for _, key := range keys {
+		// Map settings (e.g., branch_schemes) are written whole
+		if section, _, ok := strings.Cut(key, "."); ok && !all.IsSet(key) {
+			key = section
+		}
+		// Unset optional values (e.g., an empty namespace) are left out
+		if all.IsSet(key) {
+			v.Set(key, all.Get(key))
+		}
+	}
+	return v.WriteConfigAs(path)
+}
+
+// settings returns every setting of the configuration, keyed as in
+// .mkrel.yaml. Optional settings are left out when unset.
+func (c *Config) settings() *viper.Viper {
 	v := viper.New()
 
 	v.Set("scheme", string(c.Scheme))
 	v.Set("calver_format", c.CalVerFormat)
+	if len(c.BranchSchemes) > 0 {
+		v.Set("branch_schemes", c.BranchSchemes)
+	}
 	v.Set("branches.main", c.Branches.Main)
 	v.Set("branches.develop", c.Branches.Develop)
 	v.Set("remote", c.Remote)
+	if c.Namespace != "" {
+		v.Set("namespace", c.Namespace)
+	}
+	v.Set("require_develop", c.RequireDevelop)
+	v.Set("auto_create_develop", c.AutoCreateDevelop)
+	if len(c.DevelopCandidates) > 0 {
+		v.Set("develop_candidates", c.DevelopCandidates)
+	}
+	v.Set("changelog_in_tag", c.ChangelogInTag)
+	if c.ChangelogStyle != "" {
+		v.Set("changelog_style", string(c.ChangelogStyle))
+	}
+	v.Set("changelog_exclude", c.ChangelogExclude)
+	if c.ChangelogFile != "" {
+		v.Set("changelog_file", c.ChangelogFile)
+	}
+	v.Set("validate_push", c.ValidatePush)
+	v.Set("lint_commits", c.LintCommits)
+	v.Set("verify_signatures", c.VerifySignatures)
+	v.Set("hotfix_merge_develop", c.HotfixMergeDevelop)
+	v.Set("hotfix_without_release", c.HotfixWithoutRelease)
+	v.Set("auto_unshallow", c.AutoUnshallow)
+	v.Set("confirm_main_push", c.ConfirmMainPush)
+	v.Set("release_merge_develop", c.ReleaseMergeDevelop)
+	if c.MergeMessageTemplate != "" {
+		v.Set("merge_message_template", c.MergeMessageTemplate)
+	}
+	v.Set("prune_features", c.PruneFeatures)
+	if len(c.MovingTags) > 0 {
+		v.Set("moving_tags", c.MovingTags)
+	}
+	if c.ReleaseBranchVersion != "" {
+		v.Set("release_branch_version", c.ReleaseBranchVersion)
+	}
+	v.Set("check_updates", c.CheckUpdates)
+	if c.VersionSource != "" {
+		v.Set("version_source", c.VersionSource)
+	}
+	if c.VersionFilePath != "" {
+		v.Set("version_file_path", c.VersionFilePath)
+	}
+	v.Set("github_release", c.GitHubRelease)
+	v.Set("github_release_draft", c.GitHubReleaseDraft)
+	if len(c.ReleaseAssets) > 0 {
+		v.Set("release_assets", c.ReleaseAssets)
+	}
 
 	if len(c.VersionFiles) > 0 {
 		v.Set("version_files", c.VersionFiles)
 	}
+	if len(c.Messages) > 0 {
+		v.Set("messages", c.Messages)
+	}
+	if len(c.IgnoreDirty) > 0 {
+		v.Set("ignore_dirty", c.IgnoreDirty)
+	}
 
-	return v.WriteConfigAs(path)
+	return v
 }
 
 // Exists checks if a config file exists in the current directory.
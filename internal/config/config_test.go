@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/kloudlabs-io/mkrel/internal/version"
@@ -45,6 +46,15 @@ func TestDefault(t *testing.T) {
 	if cfg.Remote != "origin" {
 		t.Errorf("Default().Remote = %v, want %v", cfg.Remote, "origin")
 	}
+	if !cfg.RequireDevelop {
+		t.Error("Default().RequireDevelop = false, want true")
+	}
+	if !cfg.HotfixMergeDevelop {
+		t.Error("Default().HotfixMergeDevelop = false, want true")
+	}
+	if !cfg.HotfixWithoutRelease {
+		t.Error("Default().HotfixWithoutRelease = false, want true")
+	}
 }
 
 func TestLoad_NoConfigFile(t *testing.T) {
@@ -120,15 +130,107 @@ scheme: semver
 		t.Errorf("Load().Scheme = %v, want %v", cfg.Scheme, version.SchemeSemVer)
 	}
 
-	// Default values should be preserved
-	if cfg.Branches.Main != "main" {
-		t.Errorf("Load().Branches.Main = %v, want %v", cfg.Branches.Main, "main")
+	// Default values should be preserved; main is detected when unset
+	if cfg.Branches.Main != "" {
+		t.Errorf("Load().Branches.Main = %v, want it empty to detect it", cfg.Branches.Main)
 	}
 	if cfg.Remote != "origin" {
 		t.Errorf("Load().Remote = %v, want %v", cfg.Remote, "origin")
 	}
 }
 
+func TestLoad_RequireDevelop(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".mkrel.yaml")
+
+	configContent := `
+require_develop: false
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.RequireDevelop {
+		t.Error("Load().RequireDevelop = true, want false")
+	}
+}
+
+func TestLoad_DevelopCandidates(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".mkrel.yaml")
+
+	if err := os.WriteFile(configPath, []byte("develop_candidates: [next, integration]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"next", "integration"}; !reflect.DeepEqual(cfg.DevelopCandidates, want) || cfg.Branches.Develop != "" {
+		t.Errorf("Load() = candidates %q, develop %q; want %q and no develop branch", cfg.DevelopCandidates, cfg.Branches.Develop, want)
+	}
+
+	// An explicit develop branch wins over the candidates
+	if err := os.WriteFile(configPath, []byte("develop_candidates: [next]\nbranches:\n  develop: staging\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Branches.Develop != "staging" {
+		t.Errorf("Load().Branches.Develop = %q, want %q", cfg.Branches.Develop, "staging")
+	}
+}
+
+func TestLoad_BranchSchemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]version.Scheme
+		wantErr bool
+	}{
+		{
+			name:    "override",
+			content: "scheme: calver\nbranch_schemes:\n  lts: SemVer\n",
+			want:    map[string]version.Scheme{"lts": version.SchemeSemVer},
+		},
+		{
+			name:    "invalid",
+			content: "branch_schemes:\n  lts: invalid\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Load() expected error for invalid branch scheme")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.BranchSchemes, tt.want) {
+				t.Errorf("Load().BranchSchemes = %v, want %v", cfg.BranchSchemes, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad_InvalidScheme(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, ".mkrel.yaml")
@@ -146,6 +248,119 @@ scheme: invalid
 	}
 }
 
+func TestLoad_ReleaseBranchVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"final", false},
+		{"prerelease", false},
+		{"rc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+			if err := os.WriteFile(configPath, []byte("release_branch_version: "+tt.value+"\n"), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Load() expected error for release_branch_version %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.ReleaseBranchVersion != tt.value {
+				t.Errorf("Load().ReleaseBranchVersion = %q, want %q", cfg.ReleaseBranchVersion, tt.value)
+			}
+		})
+	}
+}
+
+func TestLoad_ChangelogExclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr bool
+	}{
+		{"default", "scheme: semver\n", []string{"^Merge "}, false},
+		{"custom", "changelog_exclude: [\"^Merge \", \"^chore\"]\n", []string{"^Merge ", "^chore"}, false},
+		{"keep merges", "changelog_exclude: []\n", []string{}, false},
+		{"invalid", "changelog_exclude: [\"(\"]\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Load() expected error for an invalid pattern")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(cfg.ChangelogExclude) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(cfg.ChangelogExclude, tt.want)) {
+				t.Errorf("Load().ChangelogExclude = %q, want %q", cfg.ChangelogExclude, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ReleaseAssets(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+	if err := os.WriteFile(configPath, []byte("github_release: true\nrelease_assets: [\"dist/*.tar.gz\", \"dist/checksums.txt\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"dist/*.tar.gz", "dist/checksums.txt"}; !cfg.GitHubRelease || !reflect.DeepEqual(cfg.ReleaseAssets, want) {
+		t.Errorf("Load() = github_release %v, release_assets %q, want true, %q", cfg.GitHubRelease, cfg.ReleaseAssets, want)
+	}
+
+	if err := os.WriteFile(configPath, []byte("release_assets: [\"dist/[\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() expected error for an invalid pattern")
+	}
+}
+
+func TestLoad_IgnoreDirty(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+	if err := os.WriteFile(configPath, []byte("ignore_dirty: [\"dist/**\", \"*.log\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"dist/**", "*.log"}; !reflect.DeepEqual(cfg.IgnoreDirty, want) {
+		t.Errorf("Load().IgnoreDirty = %q, want %q", cfg.IgnoreDirty, want)
+	}
+
+	if err := os.WriteFile(configPath, []byte("ignore_dirty: [\"build/[\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() expected error for an invalid pattern")
+	}
+}
+
 func TestLoad_InvalidYAML(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, ".mkrel.yaml")
@@ -262,3 +477,81 @@ func TestConfig_Save(t *testing.T) {
 		t.Errorf("Loaded.Branches.Main = %v, want %v", loaded.Branches.Main, cfg.Branches.Main)
 	}
 }
+
+func TestLoad_UserConfig(t *testing.T) {
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	if err := os.MkdirAll(filepath.Join(userDir, "mkrel"), 0755); err != nil {
+		t.Fatalf("Failed to create user config dir: %v", err)
+	}
+	userConfig := "scheme: semver\nremote: upstream\nbranches:\n  main: trunk\n"
+	if err := os.WriteFile(filepath.Join(userDir, "mkrel", "config.yaml"), []byte(userConfig), 0644); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+
+	repoDir := t.TempDir()
+	chdir(t, repoDir)
+
+	// User config alone overrides the defaults
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Scheme != version.SchemeSemVer || cfg.Remote != "upstream" || cfg.Branches.Main != "trunk" {
+		t.Errorf("Load() = %+v, want the user config values", cfg)
+	}
+
+	// The repository config overrides the user config, key by key
+	if err := os.WriteFile(".mkrel.yaml", []byte("remote: origin\nbranches:\n  develop: dev\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Remote != "origin" || cfg.Branches.Develop != "dev" {
+		t.Errorf("Load() = %+v, want repository values for remote and develop", cfg)
+	}
+	if cfg.Scheme != version.SchemeSemVer || cfg.Branches.Main != "trunk" {
+		t.Errorf("Load() = %+v, want user values for scheme and main", cfg)
+	}
+
+	// The environment overrides both
+	t.Setenv("MKREL_REMOTE", "fork")
+	t.Setenv("MKREL_BRANCHES_MAIN", "production")
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Remote != "fork" || cfg.Branches.Main != "production" {
+		t.Errorf("Load() = %+v, want environment values for remote and main", cfg)
+	}
+
+	// Settings without a built-in default are read from the environment
+	// too, lists separated by commas
+	t.Setenv("MKREL_CHANGELOG_FILE", "CHANGES.md")
+	t.Setenv("MKREL_RELEASE_ASSETS", "dist/*.tar.gz,dist/checksums.txt")
+	t.Setenv("MKREL_MOVING_TAGS", "latest")
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ChangelogFile != "CHANGES.md" {
+		t.Errorf("Load().ChangelogFile = %q, want CHANGES.md", cfg.ChangelogFile)
+	}
+	if want := []string{"dist/*.tar.gz", "dist/checksums.txt"}; !reflect.DeepEqual(cfg.ReleaseAssets, want) {
+		t.Errorf("Load().ReleaseAssets = %q, want %q", cfg.ReleaseAssets, want)
+	}
+	if want := []string{"latest"}; !reflect.DeepEqual(cfg.MovingTags, want) {
+		t.Errorf("Load().MovingTags = %q, want %q", cfg.MovingTags, want)
+	}
+
+	// LoadFile reads the repository config alone
+	cfg, err = LoadFile(".mkrel.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.Scheme != version.SchemeCalVer || cfg.Remote != "origin" || cfg.Branches.Main != "" {
+		t.Errorf("LoadFile() = %+v, want only the repository config over defaults", cfg)
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// branchSchemesKey prefixes per-branch scheme keys (e.g., "branch_schemes.lts").
+const branchSchemesKey = "branch_schemes."
+
+// field reads and writes one config value as a string.
+type field struct {
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+// stringField is a field holding a plain string.
+func stringField(ptr func(c *Config) *string) field {
+	return field{
+		get: func(c *Config) string { return *ptr(c) },
+		set: func(c *Config, value string) error { *ptr(c) = value; return nil },
+	}
+}
+
+// boolField is a field holding a boolean ("true"/"false").
+func boolField(ptr func(c *Config) *bool) field {
+	return field{
+		get: func(c *Config) string { return strconv.FormatBool(*ptr(c)) },
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean: %s (use true or false)", value)
+			}
+			*ptr(c) = b
+			return nil
+		},
+	}
+}
+
+// fields maps the keys accepted by Get and Set, as named in .mkrel.yaml.
+var fields = map[string]field{
+	"scheme": {
+		get: func(c *Config) string { return string(c.Scheme) },
+		set: func(c *Config, value string) error {
+			scheme, err := version.ParseScheme(value)
+			c.Scheme = scheme
+			return err
+		},
+	},
+	"calver_format":       stringField(func(c *Config) *string { return &c.CalVerFormat }),
+	"branches.main":       stringField(func(c *Config) *string { return &c.Branches.Main }),
+	"branches.develop":    stringField(func(c *Config) *string { return &c.Branches.Develop }),
+	"remote":              stringField(func(c *Config) *string { return &c.Remote }),
+	"namespace":           stringField(func(c *Config) *string { return &c.Namespace }),
+	"require_develop":     boolField(func(c *Config) *bool { return &c.RequireDevelop }),
+	"auto_create_develop": boolField(func(c *Config) *bool { return &c.AutoCreateDevelop }),
+	"changelog_in_tag":    boolField(func(c *Config) *bool { return &c.ChangelogInTag }),
+	"changelog_style": {
+		get: func(c *Config) string { return string(c.ChangelogStyle) },
+		set: func(c *Config, value string) error {
+			style, err := changelog.ParseStyle(value)
+			c.ChangelogStyle = style
+			return err
+		},
+	},
+	"validate_push":  boolField(func(c *Config) *bool { return &c.ValidatePush }),
+	"lint_commits":   boolField(func(c *Config) *bool { return &c.LintCommits }),
+	"prune_features": boolField(func(c *Config) *bool { return &c.PruneFeatures }),
+	"check_updates":  boolField(func(c *Config) *bool { return &c.CheckUpdates }),
+	"version_source": {
+		get: func(c *Config) string { return c.VersionSource },
+		set: func(c *Config, value string) error {
+			if value != VersionSourceTags && value != VersionSourceFile {
+				return fmt.Errorf("invalid value %q (use %q or %q)", value, VersionSourceTags, VersionSourceFile)
+			}
+			c.VersionSource = value
+			return nil
+		},
+	},
+	"version_file_path": stringField(func(c *Config) *string { return &c.VersionFilePath }),
+	"release_branch_version": {
+		get: func(c *Config) string { return c.ReleaseBranchVersion },
+		set: func(c *Config, value string) error {
+			if value != ReleaseBranchPrerelease && value != ReleaseBranchFinal {
+				return fmt.Errorf("invalid value %q (use %q or %q)", value, ReleaseBranchFinal, ReleaseBranchPrerelease)
+			}
+			c.ReleaseBranchVersion = value
+			return nil
+		},
+	},
+	"github_release":         boolField(func(c *Config) *bool { return &c.GitHubRelease }),
+	"github_release_draft":   boolField(func(c *Config) *bool { return &c.GitHubReleaseDraft }),
+	"verify_signatures":      boolField(func(c *Config) *bool { return &c.VerifySignatures }),
+	"hotfix_merge_develop":   boolField(func(c *Config) *bool { return &c.HotfixMergeDevelop }),
+	"release_merge_develop":  boolField(func(c *Config) *bool { return &c.ReleaseMergeDevelop }),
+	"merge_message_template": stringField(func(c *Config) *string { return &c.MergeMessageTemplate }),
+	"changelog_file":         stringField(func(c *Config) *string { return &c.ChangelogFile }),
+	"hotfix_without_release": boolField(func(c *Config) *bool { return &c.HotfixWithoutRelease }),
+	"auto_unshallow":         boolField(func(c *Config) *bool { return &c.AutoUnshallow }),
+	"confirm_main_push":      boolField(func(c *Config) *bool { return &c.ConfirmMainPush }),
+}
+
+// Keys returns the keys accepted by Get and Set, sorted. Per-branch
+// schemes are set with "branch_schemes.<branch>".
+func Keys() []string {
+	keys := make([]string, 0, len(fields)+1)
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	keys = append(keys, branchSchemesKey+"<branch>")
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the value of a key, using dotted names for nested keys
+// (e.g., "branches.main").
+func (c *Config) Get(key string) (string, error) {
+	if branch, ok := strings.CutPrefix(key, branchSchemesKey); ok {
+		return string(c.BranchSchemes[strings.ToLower(branch)]), nil
+	}
+
+	f, ok := fields[key]
+	if !ok {
+		return "", unknownKey(key)
+	}
+	return f.get(c), nil
+}
+
+// Set validates and sets the value of a key, using the same names as Get.
+// The config is unchanged if the value is invalid.
+func (c *Config) Set(key, value string) error {
+	if branch, ok := strings.CutPrefix(key, branchSchemesKey); ok {
+		if branch == "" || strings.Contains(branch, ".") {
+			return fmt.Errorf("%s: invalid branch name %q", key, branch)
+		}
+		scheme, err := version.ParseScheme(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if c.BranchSchemes == nil {
+			c.BranchSchemes = make(map[string]version.Scheme)
+		}
+		// Viper lowercases keys when reading the file
+		c.BranchSchemes[strings.ToLower(branch)] = scheme
+		return nil
+	}
+
+	f, ok := fields[key]
+	if !ok {
+		return unknownKey(key)
+	}
+
+	updated := *c
+	if err := f.set(&updated, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*c = updated
+	return nil
+}
+
+// unknownKey returns the error for a key Get and Set don't know.
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown config key: %s (known keys: %s)", key, strings.Join(Keys(), ", "))
+}
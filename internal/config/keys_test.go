@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestConfig_SetGet(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+
+	values := map[string]string{
+		"scheme":                 "semver",
+		"branches.main":          "production",
+		"remote":                 "upstream",
+		"lint_commits":           "true",
+		"changelog_style":        "gitmoji",
+		"release_branch_version": "final",
+		"branch_schemes.lts":     "calver",
+	}
+
+	cfg := Default()
+	for key, value := range values {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatalf("Set(%q, %q) error = %v", key, value, err)
+		}
+	}
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for key, want := range values {
+		got, err := loaded.Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", key, err)
+		}
+		if got != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestConfig_SetInvalid(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"scheme", "invalid"},
+		{"lint_commits", "maybe"},
+		{"changelog_style", "emoji"},
+		{"release_branch_version", "rc"},
+		{"branch_schemes.lts", "invalid"},
+		{"unknown", "value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			cfg := Default()
+			if err := cfg.Set(tt.key, tt.value); err == nil {
+				t.Errorf("Set(%q, %q) expected error", tt.key, tt.value)
+			}
+			if cfg.Scheme != version.SchemeCalVer || cfg.LintCommits || len(cfg.BranchSchemes) != 0 {
+				t.Errorf("Set(%q, %q) changed the config on error: %+v", tt.key, tt.value, cfg)
+			}
+		})
+	}
+}
+
+func TestConfig_SaveKeys(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+	if err := os.WriteFile(configPath, []byte("remote: upstream\nbranch_schemes:\n  lts: semver\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if err := cfg.Set("lint_commits", "true"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cfg.SaveKeys(configPath, "lint_commits"); err != nil {
+		t.Fatalf("SaveKeys() error = %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := viper.New()
+	saved.SetConfigType("yaml")
+	if err := saved.ReadConfig(bytes.NewReader(data)); err != nil {
+		t.Fatalf("reading saved config: %v", err)
+	}
+	want := []string{"branch_schemes.lts", "lint_commits", "remote"}
+	got := saved.AllKeys()
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("saved keys = %v, want %v:\n%s", got, want, data)
+	}
+	if saved.GetString("remote") != "upstream" || !saved.GetBool("lint_commits") {
+		t.Errorf("saved config lost a value:\n%s", data)
+	}
+}
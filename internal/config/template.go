@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+// commentedTemplate is the annotated .mkrel.yaml written by SaveWithComments.
+// Optional settings that are unset appear as commented-out examples.
+var commentedTemplate = template.Must(template.New(".mkrel.yaml").Funcs(template.FuncMap{
+	"list": yamlList,
+}).Parse(`# mkrel configuration. Every setting is optional; the values below are
+# the defaults unless noted. "mkrel config get <key>" shows a value in effect.
+
+# Versioning scheme: calver or semver
+scheme: {{.Scheme}}
+
+# Per-branch scheme overrides, keyed by the branch a version lands on.
+# Releases land on main; hotfixes on their --base branch. Branch names are
+# matched in lowercase and can't contain dots.
+{{- if .BranchSchemes}}
+branch_schemes:
+{{- range $branch, $scheme := .BranchSchemes}}
+  {{$branch}}: {{$scheme}}
+{{- end}}
+{{- else}}
+# branch_schemes:
+#   lts: semver
+{{- end}}
+
+# CalVer format
+calver_format: {{.CalVerFormat}}
+
+# Branch names. When main is empty, it is the remote's default branch
+# (origin/HEAD), then init.defaultBranch, then main or master. When develop
+# is empty, develop_candidates are tried.
+branches:
+  main: {{.Branches.Main}}
+  develop: {{.Branches.Develop}}
+
+# Git remote
+remote: {{.Remote}}
+
+# Prefix for branches and tags in repositories shared by several tools:
+# "mytool" gives mytool/release/1.2.0 and mytool-1.2.0
+{{- if .Namespace}}
+namespace: {{.Namespace}}
+{{- else}}
+# namespace: mytool
+{{- end}}
+
+# Fail if no develop branch exists. When false, releases run on main only.
+require_develop: {{.RequireDevelop}}
+
+# Create a missing develop branch from main on "mkrel init" or the first
+# "release start"
+auto_create_develop: {{.AutoCreateDevelop}}
+
+# Names tried, in order, to find the develop branch when branches.develop
+# is empty (default: develop, development, dev)
+{{- if .DevelopCandidates}}
+develop_candidates: {{list .DevelopCandidates}}
+{{- else}}
+# develop_candidates: [next, integration]
+{{- end}}
+
+# Changelog: release notes are generated from the commits since the
+# previous release.
+#
+# Use the release notes as the tag annotation
+changelog_in_tag: {{.ChangelogInTag}}
+# How commits are grouped: conventional (feat:, fix:) or gitmoji
+changelog_style: {{.ChangelogStyle}}
+# Regular expressions for commit subjects to leave out of release notes
+changelog_exclude: {{list .ChangelogExclude}}
+# Markdown file release and hotfix finish add the release notes to
+{{- if .ChangelogFile}}
+changelog_file: {{.ChangelogFile}}
+{{- else}}
+# changelog_file: CHANGELOG.md
+{{- end}}
+
+# Run "git push --dry-run" before a finish merges and tags
+validate_push: {{.ValidatePush}}
+
+# Check commits since the last release follow the changelog style
+lint_commits: {{.LintCommits}}
+
+# Fail release and hotfix finish if a commit since the last release isn't
+# signed with a good signature
+verify_signatures: {{.VerifySignatures}}
+
+# Message of the merge commits release and hotfix finish create;
+# {{"{{source}}"}}, {{"{{target}}"}} and {{"{{version}}"}} are filled in (default: git's own)
+{{- if .MergeMessageTemplate}}
+merge_message_template: {{printf "%q" .MergeMessageTemplate}}
+{{- else}}
+# merge_message_template: "Merge {{"{{source}} into {{target}}"}}"
+{{- end}}
+
+# Merge main back into develop on "release finish". When false, only main
+# is merged, tagged and pushed; reconcile develop yourself
+release_merge_develop: {{.ReleaseMergeDevelop}}
+
+# Merge finished hotfixes back into develop. When false, hotfixes only land
+# on main; cherry-pick them onto develop, or it will lack the fix
+hotfix_merge_develop: {{.HotfixMergeDevelop}}
+
+# Allow "hotfix start" before the first release, versioned like a release
+# (today's date for CalVer, 0.0.1 for SemVer). When false, it fails instead
+hotfix_without_release: {{.HotfixWithoutRelease}}
+
+# Untracked files that don't block release and hotfix commands, e.g.,
+# generated files: "dist/**" is a directory, "*.log" a file name anywhere.
+# Changes to tracked files always block
+{{- if .IgnoreDirty}}
+ignore_dirty: {{list .IgnoreDirty}}
+{{- else}}
+# ignore_dirty: ["dist/**", "*.log"]
+{{- end}}
+
+# Delete local feature/* branches merged into develop on "release finish"
+prune_features: {{.PruneFeatures}}
+
+# Tags force-moved to every release on "release finish"
+{{- if .MovingTags}}
+moving_tags: {{list .MovingTags}}
+{{- else}}
+# moving_tags: [latest]
+{{- end}}
+
+# Version in release branch names: prerelease (release/1.3.0-rc.0) or
+# final (release/1.3.0)
+release_branch_version: {{.ReleaseBranchVersion}}
+
+# Where the current version comes from: tags, or file (version_file_path)
+version_source: {{.VersionSource}}
+version_file_path: {{.VersionFilePath}}
+
+# Files to update with the new version; {{"{{version}}"}} marks where the
+# version goes in the pattern
+{{- if .VersionFiles}}
+version_files:
+{{- range .VersionFiles}}
+  - path: {{.Path}}
+    pattern: {{printf "%q" .Pattern}}
+{{- end}}
+{{- else}}
+# version_files:
+#   - path: package.json
+#     pattern: '"version": "{{"{{version}}"}}"'
+{{- end}}
+
+# Publish finished releases as GitHub releases (needs GITHUB_TOKEN or GH_TOKEN)
+github_release: {{.GitHubRelease}}
+
+# Create GitHub releases as drafts
+github_release_draft: {{.GitHubReleaseDraft}}
+
+# Files to attach to each GitHub release, as glob patterns
+{{- if .ReleaseAssets}}
+release_assets: {{list .ReleaseAssets}}
+{{- else}}
+# release_assets: ["dist/*.tar.gz", "dist/checksums.txt"]
+{{- end}}
+
+# Print a notice when a newer mkrel release exists
+check_updates: {{.CheckUpdates}}
+
+# In a shallow clone (e.g., a CI checkout), fetch the full history and tags
+# before computing versions instead of only warning
+auto_unshallow: {{.AutoUnshallow}}
+
+# Ask before release and hotfix finish push main and the tag (--yes skips)
+confirm_main_push: {{.ConfirmMainPush}}
+
+# Completion messages, by operation, as Go templates over the operation's
+# fields (version, tag, commit, branch); "short" abbreviates a commit. An
+# empty message prints nothing.
+{{- if .Messages}}
+messages:
+{{- range $name, $text := .Messages}}
+  {{$name}}: {{printf "%q" $text}}
+{{- end}}
+{{- else}}
+# messages:
+#   release-finish: "Shipped {{"{{.version}} ({{short .commit}})"}}"
+{{- end}}
+`))
+
+// yamlList formats values as a YAML flow sequence (["a", "b"]).
+func yamlList(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	// A JSON array of strings is valid YAML
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+// SaveWithComments writes the configuration to a file like Save, with a
+// comment explaining each setting and commented-out examples for unset
+// optional ones.
+func (c *Config) SaveWithComments(path string) error {
+	var buf bytes.Buffer
+	if err := commentedTemplate.Execute(&buf, c); err != nil {
+		return fmt.Errorf("failed to render config: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestConfig_SaveWithComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+
+	cfg := Default()
+	cfg.Scheme = version.SchemeSemVer
+	if err := cfg.SaveWithComments(configPath); err != nil {
+		t.Fatalf("SaveWithComments() error = %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# Versioning scheme: calver or semver",
+		"# Branch names. When main is empty, it is the remote's default branch",
+		"# Changelog: release notes are generated from the commits since the",
+		"# version_files:",
+		`#     pattern: '"version": "{{version}}"'`,
+		"# moving_tags: [latest]",
+	} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("config is missing %q:\n%s", line, data)
+		}
+	}
+
+	loaded, err := LoadFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("LoadFile() = %+v, want %+v", loaded, cfg)
+	}
+}
+
+func TestConfig_SaveWithComments_Values(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mkrel.yaml")
+
+	cfg := Default()
+	cfg.BranchSchemes = map[string]version.Scheme{"lts": version.SchemeSemVer}
+	cfg.Namespace = "mytool"
+	cfg.MovingTags = []string{"latest", "stable"}
+	cfg.ChangelogExclude = []string{}
+	cfg.VersionFiles = []VersionFile{{Path: "package.json", Pattern: `"version": "{{version}}"`}}
+	cfg.Messages = map[string]string{"release-finish": "Shipped {{.version}}", "undo": ""}
+	cfg.MergeMessageTemplate = "Merge {{source}} into {{target}} ({{version}})"
+	cfg.IgnoreDirty = []string{"dist/**", "*.log"}
+	cfg.AutoUnshallow = true
+	cfg.ConfirmMainPush = true
+	cfg.ChangelogFile = "CHANGELOG.md"
+	if err := cfg.SaveWithComments(configPath); err != nil {
+		t.Fatalf("SaveWithComments() error = %v", err)
+	}
+
+	loaded, err := LoadFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("LoadFile() = %+v, want %+v", loaded, cfg)
+	}
+}
@@ -0,0 +1,225 @@
+package flow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// createReleaseTag creates the annotated tag for a release on HEAD.
+// With changelog_in_tag enabled, the annotation is the generated release
+// notes instead of defaultMessage, so "git show <tag>" displays them.
+// A message given by the user (-m) takes precedence over both.
+// With a notes file set, the notes are also written there.
+func (f *Flow) createReleaseTag(tagName, ver, defaultMessage, message string) error {
+	useNotes := f.changelogInTag && message == ""
+	if message == "" {
+		message = defaultMessage
+	}
+	if !useNotes && f.notesFile == "" {
+		return f.repo.CreateTag(tagName, message)
+	}
+
+	notes, err := f.releaseNotes(ver)
+	if err != nil {
+		return fmt.Errorf("failed to generate release notes: %w", err)
+	}
+
+	if f.notesFile != "" {
+		f.step("write-notes", map[string]string{"path": f.notesFile},
+			"    Writing release notes: %s", f.notesFile)
+		if !f.dryRun {
+			if err := WriteOutput(f.notesFile, notes); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !useNotes {
+		return f.repo.CreateTag(tagName, message)
+	}
+	return f.repo.CreateTagFromStdin(tagName, notes)
+}
+
+// WriteOutput writes a result (a version, release notes) to path for
+// CI to pick up, creating parent directories as needed. A trailing
+// newline is added if missing.
+func WriteOutput(path, text string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// updateChangelogFile adds the release notes of ver to the changelog
+// file, if one is set, and commits it on the current branch. A version
+// the file already has an entry for is left alone.
+func (f *Flow) updateChangelogFile(ver string) error {
+	if f.changelogFile == "" {
+		return nil
+	}
+
+	f.step("update-changelog", map[string]string{"path": f.changelogFile, "version": ver},
+		"    Adding %s to %s", ver, f.changelogFile)
+	if f.dryRun {
+		return nil
+	}
+
+	root, err := f.repo.Root()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, f.changelogFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", f.changelogFile, err)
+	}
+
+	notes, err := f.releaseNotes(ver)
+	if err != nil {
+		return fmt.Errorf("failed to generate release notes: %w", err)
+	}
+	content, added := changelog.Prepend(string(data), ver, notes)
+	if !added {
+		f.printAlways("    %s already has %s, nothing to commit", f.changelogFile, ver)
+		return nil
+	}
+
+	if err := WriteOutput(path, content); err != nil {
+		return err
+	}
+	if err := f.repo.Add(path); err != nil {
+		return err
+	}
+	if err := f.repo.Commit(releaseCommitPrefix + "add " + ver + " to " + f.changelogFile); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", f.changelogFile, err)
+	}
+	return nil
+}
+
+// releaseNotes generates the changelog section for ver from the commits
+// on HEAD since the previous release.
+func (f *Flow) releaseNotes(ver string) (string, error) {
+	previous, err := f.previousReleaseTag("HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	sections, err := f.changes(previous, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return changelog.Render(ver, time.Now(), sections), nil
+}
+
+// Notes are the release notes of a tagged release.
+type Notes struct {
+	Version  string
+	Tag      string
+	Previous string    // Previous release tag; empty for the first release
+	Since    string    // Start of the range: Previous, or the first commit for the first release
+	Date     time.Time // Date of the tagged commit
+	Sections []changelog.Section
+}
+
+// TagNotes generates the release notes of an existing tag, from the
+// commits since the release before it.
+func (f *Flow) TagNotes(tag string) (*Notes, error) {
+	if !f.repo.TagExists(tag) {
+		return nil, fmt.Errorf("tag %s not found", tag)
+	}
+	ver, ok := f.repo.TagVersion(tag)
+	if !ok || !f.isVersion(ver) {
+		return nil, fmt.Errorf("tag %s is not a release version", tag)
+	}
+
+	// A tag on the root commit has no earlier release
+	var previous string
+	if _, err := f.repo.ResolveRef(tag + "^"); err == nil {
+		previous, err = f.previousReleaseTag(tag + "^")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	since, err := f.rangeStart(previous, tag)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := f.changes(previous, tag)
+	if err != nil {
+		return nil, err
+	}
+	date, err := f.repo.TagDate(tag)
+	if err != nil {
+		return nil, err
+	}
+	return &Notes{Version: ver, Tag: tag, Previous: previous, Since: since, Date: date, Sections: sections}, nil
+}
+
+// TagMessage returns the message stored in a release tag, such as the
+// release notes written with changelog_in_tag. Returns empty string for
+// lightweight tags.
+func (f *Flow) TagMessage(tag string) (string, error) {
+	if !f.repo.TagExists(tag) {
+		return "", fmt.Errorf("tag %s not found", tag)
+	}
+	return f.repo.TagMessage(tag)
+}
+
+// rangeStart returns where the changes of a release on ref start: the
+// previous release tag, or the first commit for the first release.
+func (f *Flow) rangeStart(previous, ref string) (string, error) {
+	if previous != "" {
+		return previous, nil
+	}
+	first, err := f.repo.FirstCommitFrom(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the first commit: %w", err)
+	}
+	return first, nil
+}
+
+// changes categorizes the commits on to since from. If from is empty
+// (the first release), the whole history is included, from the first
+// commit on.
+func (f *Flow) changes(from, to string) ([]changelog.Section, error) {
+	commits, err := f.repo.Log(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]changelog.Commit, 0, len(commits))
+	for _, c := range commits {
+		// The version and changelog commits of earlier finishes
+		if strings.HasPrefix(c.Subject, releaseCommitPrefix) {
+			continue
+		}
+		entries = append(entries, changelog.Commit{Hash: c.Hash, Subject: c.Subject})
+	}
+	return changelog.CategorizeStyle(entries, f.changelogStyle, f.excludeCommits...), nil
+}
+
+// previousReleaseTag returns the latest version tag reachable from ref
+// that isn't a prerelease, so notes for 1.3.0 cover everything since 1.2.0 rather
+// than only the changes since 1.3.0-rc.0.
+// Prereleases are skipped rather than searched past, so a release tag
+// behind a prerelease tag (e.g., from "release start --push") is found.
+func (f *Flow) previousReleaseTag(ref string) (string, error) {
+	return f.repo.LatestVersionTag(ref, func(v string) bool {
+		return f.isVersion(v) && !version.IsPrerelease(v, f.versioner.Scheme())
+	})
+}
@@ -0,0 +1,103 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// EventType identifies the kind of progress event.
+type EventType string
+
+const (
+	EventInfo      EventType = "info"       // Informational detail (current version, branch found)
+	EventStepStart EventType = "step_start" // A step that changes the repository is starting
+	EventStepDone  EventType = "step_done"  // An operation (start or finish) completed
+	EventWarning   EventType = "warning"    // A non-fatal problem
+)
+
+// Event reports progress of a flow operation to Options.OnEvent.
+type Event struct {
+	Type    EventType
+	Step    string            // Step name (e.g., "merge", "tag", "push"); empty for info
+	Message string            // Human-readable text, as printed without a callback
+	Fields  map[string]string // Step details (e.g., "branch", "tag", "version")
+}
+
+// emit delivers an event to the callback, or prints it when none is set.
+// Messages that aren't always shown only print in verbose or dry-run mode.
+func (f *Flow) emit(e Event, always bool) {
+	text := e.Message
+	// Callbacks get the bare message without console decoration
+	e.Message = strings.TrimPrefix(strings.TrimSpace(text), "==> ")
+	f.log(e)
+
+	if f.onEvent != nil {
+		f.onEvent(e)
+		return
+	}
+	if always || f.dryRun || f.verbose {
+		fmt.Fprintln(f.out, text)
+	}
+}
+
+// log records an event to Options.Logger, if set: warnings at warn level,
+// steps at info level and details at debug level. Blank lines aren't
+// logged.
+func (f *Flow) log(e Event) {
+	if f.logger == nil || e.Message == "" {
+		return
+	}
+	level := slog.LevelDebug
+	switch e.Type {
+	case EventWarning:
+		level = slog.LevelWarn
+	case EventStepStart, EventStepDone:
+		level = slog.LevelInfo
+	}
+	attrs := []slog.Attr{slog.String("type", string(e.Type))}
+	if e.Step != "" {
+		attrs = append(attrs, slog.String("step", e.Step))
+	}
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, e.Fields[key]))
+	}
+	f.logger.LogAttrs(context.Background(), level, e.Message, attrs...)
+}
+
+// step reports the start of a step that changes the repository.
+func (f *Flow) step(name string, fields map[string]string, format string, args ...interface{}) {
+	f.emit(Event{Type: EventStepStart, Step: name, Message: fmt.Sprintf(format, args...), Fields: fields}, false)
+}
+
+// done reports a completed operation. It is always shown, unless
+// Options.Messages overrides it with an empty message.
+func (f *Flow) done(name string, fields map[string]string, format string, args ...interface{}) {
+	msg := f.message(name, fields, fmt.Sprintf(format, args...))
+	if msg == "" && f.onEvent == nil {
+		return
+	}
+	f.emit(Event{Type: EventStepDone, Step: name, Message: msg, Fields: fields}, true)
+}
+
+// warn reports a non-fatal problem. It is always shown.
+func (f *Flow) warn(format string, args ...interface{}) {
+	f.emit(Event{Type: EventWarning, Message: fmt.Sprintf(format, args...)}, true)
+}
+
+// print outputs a message, respecting verbose mode.
+func (f *Flow) print(format string, args ...interface{}) {
+	f.emit(Event{Type: EventInfo, Message: fmt.Sprintf(format, args...)}, false)
+}
+
+// printAlways outputs a message regardless of verbose mode.
+func (f *Flow) printAlways(format string, args ...interface{}) {
+	f.emit(Event{Type: EventInfo, Message: fmt.Sprintf(format, args...)}, true)
+}
@@ -2,51 +2,181 @@
 package flow
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
 	"github.com/kloudlabs-io/mkrel/internal/git"
 	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
 // Flow orchestrates Git Flow operations for releases and hotfixes.
 type Flow struct {
-	repo       *git.Repository
-	versioner  version.Versioner
-	remote     string // Remote name (usually "origin")
-	mainBranch string // Main/production branch name
-	devBranch  string // Development branch name
-	dryRun     bool
-	verbose    bool
+	repo        *git.Repository
+	versioner   version.Versioner
+	remote      string // Remote name (usually "origin")
+	mainBranch  string // Main/production branch name
+	devBranch   string // Development branch name (empty = main-only)
+	dryRun      bool
+	verbose     bool
+	forceUnlock bool
+	onEvent     func(Event)
+	out         io.Writer
+	logger      *slog.Logger // Structured log of events (optional)
+
+	autoUnshallow   bool                      // Fetch the full history in a shallow clone before computing versions
+	confirmMainPush func(summary string) bool // Asked before a finish pushes main (optional)
+
+	messages map[string]*template.Template // Completion message overrides, by operation
+
+	changelogInTag bool             // Use release notes as the tag annotation
+	notesFile      string           // Also write release notes to this file (optional)
+	changelogStyle changelog.Style  // How commits are classified in release notes
+	excludeCommits []*regexp.Regexp // Commits to leave out of release notes
+	missingDevelop string           // Develop branch to create on the first release start
+
+	branchSchemes map[string]version.Scheme // Per-branch scheme overrides
+	defaultScheme version.Scheme            // Scheme for branches without an override
+
+	validatePush bool // Check that the push would succeed before merging
+	lintCommits  bool // Check commit messages since the last release
+
+	verifySignatures bool // Require good signatures on commits since the last release
+
+	hotfixSkipDevelop  bool // Leave develop out of hotfix finish
+	hotfixNeedsRelease bool // Refuse to start a hotfix before the first release
+	releaseSkipDevelop bool // Leave develop out of release finish
+
+	mergeMessage string // Template of finish merge commit messages (empty = git's default)
+
+	finalBranches bool // Leave the prerelease suffix out of release branch names
+	pruneFeatures bool // Delete merged feature branches on release finish
+
+	movingTags []string // Tags moved to each new release (e.g., "latest")
+
+	versionFile   string // Read and record the version in this file instead of tags (optional)
+	changelogFile string // Add release notes to this Markdown file on finish (optional)
+
+	publisher     ReleasePublisher // Publishes pushed releases on the Git host (optional)
+	draftReleases bool             // Publish releases as drafts
+
+	releasePrefix string // Release branch prefix (e.g., "release/")
+	hotfixPrefix  string // Hotfix branch prefix (e.g., "hotfix/")
 }
 
 // Options configures a Flow instance.
 type Options struct {
-	WorkDir    string         // Repository directory (empty = current)
-	Scheme     version.Scheme // Versioning scheme
-	Remote     string         // Git remote name
-	MainBranch string         // Main/production branch name (empty = auto-detect)
-	DevBranch  string         // Development branch name (empty = auto-detect)
-	DryRun     bool
-	Verbose    bool
+	WorkDir           string                    // Repository directory (empty = current)
+	GitDir            string                    // Git directory outside WorkDir (empty = GIT_DIR or WorkDir/.git)
+	Scheme            version.Scheme            // Versioning scheme
+	BranchSchemes     map[string]version.Scheme // Scheme overrides by target branch (optional)
+	Remote            string                    // Git remote name
+	Namespace         string                    // Prefix for flow branches and tags (optional)
+	MainBranch        string                    // Main/production branch name (empty = auto-detect)
+	DevBranch         string                    // Development branch name (empty = auto-detect)
+	DevelopOptional   bool                      // Run main-only when no develop branch exists
+	AutoCreateDevelop bool                      // Create a missing develop branch from main on release start
+	DevelopCandidates []string                  // Names tried when DevBranch is empty (default: git.DevelopCandidates)
+	DryRun            bool
+	Verbose           bool
+	Debug             bool            // Also print git output (implies Verbose)
+	ForceUnlock       bool            // Remove an existing lock before acquiring it
+	OnEvent           func(Event)     // Receives progress events instead of printing (optional)
+	Output            io.Writer       // Where progress is printed (default: os.Stdout)
+	ChangelogInTag    bool            // Use generated release notes as the tag annotation
+	ChangelogStyle    changelog.Style // How commits are classified in release notes (default: conventional)
+	ChangelogExclude  []string        // Patterns of commit subjects to leave out of release notes
+	ValidatePush      bool            // Run "git push --dry-run" before merging and tagging
+	LintCommits       bool            // Check commits since the last release follow Conventional Commits
+	VerifySignatures  bool            // Fail a finish if commits since the last release aren't signed
+	FinalBranchNames  bool            // Name release branches release/1.3.0 instead of release/1.3.0-rc.0
+	NotesFile         string          // Write the release notes of a finish to this file (optional)
+	PruneFeatures     bool            // Delete local feature branches merged into develop on release finish
+	MovingTags        []string        // Tags to force-update to each finished release (e.g., "latest")
+	VersionFile       string          // Read the current version from this file instead of tags (optional)
+	ChangelogFile     string          // Add the release notes of finishes to this file, e.g., CHANGELOG.md (optional)
+
+	Publisher     ReleasePublisher // Publishes finished releases on the Git host (optional)
+	DraftReleases bool             // Publish releases as drafts, for review
+
+	// Messages overrides completion messages, keyed by operation (see
+	// MessageNames), as text/template templates over the event fields,
+	// e.g., "Shipped {{.version}}". An empty template suppresses it.
+	Messages map[string]string
+
+	HotfixSkipDevelop  bool // Don't merge finished hotfixes back into develop
+	HotfixNeedsRelease bool // Fail hotfix start when nothing has been released yet
+	ReleaseSkipDevelop bool // Don't merge main back into develop on release finish
+
+	// MergeMessage is the message of the merge commits finishes create,
+	// with {{source}}, {{target}} and {{version}} placeholders (empty =
+	// git's default, "Merge branch 'release/1.3.0'").
+	MergeMessage string
+
+	// CacheReads caches branch and tag queries until the next change the
+	// Flow makes, for one-shot runs where nothing else changes the
+	// repository meanwhile (see git.Repository.EnableCache).
+	CacheReads bool
+
+	Timeout time.Duration // Stop git commands that run longer, e.g., a hung push (0 = no limit)
+
+	// IgnoreDirty lists path patterns of untracked files that don't
+	// count as uncommitted (see git.Repository.SetIgnoreDirty).
+	IgnoreDirty []string
+
+	// AutoUnshallow fetches the full history and tags ("git fetch
+	// --unshallow --tags") before computing versions in a shallow clone,
+	// instead of only warning.
+	AutoUnshallow bool
+
+	// ConfirmMainPush is asked, with a summary of the refs, before a
+	// finish pushes the main branch and its tag; returning false stops
+	// the finish with ErrMainPushCanceled, leaving the merges and tag
+	// local. Pushes that leave main alone, and dry runs, don't ask. Nil
+	// pushes without asking.
+	ConfirmMainPush func(summary string) bool
+
+	// Logger receives a structured log of each git command (debug level)
+	// and progress event, separate from the printed output (optional).
+	Logger *slog.Logger
 }
 
 // New creates a new Flow instance.
 func New(opts Options) (*Flow, error) {
 	// Create repository wrapper
-	repo, err := git.NewRepository(opts.WorkDir, opts.DryRun, opts.Verbose)
+	repo, err := git.NewRepositoryWithGitDir(opts.WorkDir, opts.GitDir, opts.DryRun, opts.Verbose)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
-
-	// Create versioner with a function to get latest tag
-	// This is dependency injection: versioner doesn't depend on git package
-	latestTagFn := func() (string, error) {
-		return repo.LatestTag()
+	repo.SetDebug(opts.Debug)
+	repo.SetNamespace(opts.Namespace)
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	repo.SetOutput(out)
+	repo.SetTimeout(opts.Timeout)
+	repo.SetLogger(opts.Logger)
+	repo.SetIgnoreDirty(opts.IgnoreDirty)
+	if opts.CacheReads {
+		repo.EnableCache()
 	}
 
-	versioner, err := version.New(opts.Scheme, latestTagFn)
+	// Nothing to branch from or tag in an empty repository
+	hasCommits, err := repo.HasCommits()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if !hasCommits {
+		return nil, fmt.Errorf("repository has no commits yet")
 	}
 
 	remote := opts.Remote
@@ -57,40 +187,515 @@ func New(opts Options) (*Flow, error) {
 	// Use configured branches or auto-detect
 	mainBranch := opts.MainBranch
 	if mainBranch == "" {
-		mainBranch, err = repo.GetMainBranch()
+		// Never mistake the develop branch for main
+		exclude := opts.DevelopCandidates
+		if opts.DevBranch != "" {
+			exclude = []string{opts.DevBranch}
+		} else if len(exclude) == 0 {
+			exclude = git.DevelopCandidates
+		}
+		mainBranch, err = repo.GetMainBranch(remote, exclude...)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	devBranch := opts.DevBranch
+	var missingDevelop string
 	if devBranch == "" {
-		devBranch, err = repo.GetDevelopBranch()
-		if err != nil {
+		devBranch, err = repo.GetDevelopBranch(opts.DevelopCandidates...)
+		if err != nil && opts.AutoCreateDevelop {
+			missingDevelop = "develop"
+		} else if err != nil && !opts.DevelopOptional {
 			return nil, err
 		}
+	} else if !repo.BranchExists(devBranch) {
+		if opts.AutoCreateDevelop {
+			missingDevelop = devBranch
+			devBranch = ""
+		} else if opts.DevelopOptional {
+			devBranch = ""
+		}
+	}
+
+	changelogExclude, err := changelog.CompileExclude(opts.ChangelogExclude)
+	if err != nil {
+		return nil, fmt.Errorf("changelog_exclude: %w", err)
+	}
+
+	messages, err := compileMessages(opts.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Releases land on main, so its scheme applies unless overridden
+	scheme := schemeFor(opts.BranchSchemes, mainBranch, opts.Scheme)
+
+	// Create versioner with a function to get latest tag
+	// This is dependency injection: versioner doesn't depend on git package
+	latestTagFn := func() (string, error) {
+		return currentVersion(repo, scheme, opts.VersionFile, "")
+	}
+
+	versioner, err := version.New(scheme, latestTagFn)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Flow{
-		repo:       repo,
-		versioner:  versioner,
-		remote:     remote,
-		mainBranch: mainBranch,
-		devBranch:  devBranch,
-		dryRun:     opts.DryRun,
-		verbose:    opts.Verbose,
+		repo:        repo,
+		versioner:   versioner,
+		remote:      remote,
+		mainBranch:  mainBranch,
+		devBranch:   devBranch,
+		dryRun:      opts.DryRun,
+		verbose:     opts.Verbose || opts.Debug,
+		forceUnlock: opts.ForceUnlock,
+		onEvent:     opts.OnEvent,
+		out:         out,
+		logger:      opts.Logger,
+		messages:    messages,
+
+		autoUnshallow:   opts.AutoUnshallow,
+		confirmMainPush: opts.ConfirmMainPush,
+
+		changelogInTag: opts.ChangelogInTag,
+		missingDevelop: missingDevelop,
+		branchSchemes:  opts.BranchSchemes,
+		defaultScheme:  opts.Scheme,
+		validatePush:   opts.ValidatePush,
+		lintCommits:    opts.LintCommits,
+		finalBranches:  opts.FinalBranchNames,
+		pruneFeatures:  opts.PruneFeatures,
+		movingTags:     opts.MovingTags,
+		versionFile:    opts.VersionFile,
+		publisher:      opts.Publisher,
+		draftReleases:  opts.DraftReleases,
+		notesFile:      opts.NotesFile,
+		changelogFile:  opts.ChangelogFile,
+		changelogStyle: opts.ChangelogStyle,
+		excludeCommits: changelogExclude,
+		releasePrefix:  branchPrefix(opts.Namespace, "release"),
+		hotfixPrefix:   branchPrefix(opts.Namespace, "hotfix"),
+
+		verifySignatures:   opts.VerifySignatures,
+		hotfixSkipDevelop:  opts.HotfixSkipDevelop,
+		hotfixNeedsRelease: opts.HotfixNeedsRelease,
+		releaseSkipDevelop: opts.ReleaseSkipDevelop,
+
+		mergeMessage: opts.MergeMessage,
 	}, nil
 }
 
-// print outputs a message, respecting verbose mode.
-func (f *Flow) print(format string, args ...interface{}) {
-	// Always print in dry-run, otherwise respect verbose
-	if f.dryRun || f.verbose {
-		fmt.Printf(format+"\n", args...)
+// branchPrefix returns the prefix for flow branches of a kind,
+// nested under the namespace if one is set ("mytool/release/").
+func branchPrefix(namespace, kind string) string {
+	if namespace == "" {
+		return kind + "/"
+	}
+	return namespace + "/" + kind + "/"
+}
+
+// latestVersion returns the version of the most recent tag reachable
+// from ref (empty = HEAD) that is valid in scheme, with any namespace
+// removed. Tags that aren't versions, like "nightly", are ignored.
+func latestVersion(repo *git.Repository, scheme version.Scheme, ref string) (string, error) {
+	tag, err := repo.LatestVersionTag(ref, func(v string) bool {
+		return version.IsValid(v, scheme)
+	})
+	if err != nil || tag == "" {
+		return tag, err
+	}
+	v, _ := repo.TagVersion(tag)
+	return v, nil
+}
+
+// schemeFor returns the versioning scheme for a target branch:
+// its entry in branchSchemes if any, otherwise fallback.
+func schemeFor(branchSchemes map[string]version.Scheme, branch string, fallback version.Scheme) version.Scheme {
+	if scheme, ok := branchSchemes[branch]; ok {
+		return scheme
+	}
+	return fallback
+}
+
+// findBranch locates an in-progress flow branch (e.g., "release/1.2.0").
+// If version is given, the matching prefix+version branch is selected;
+// otherwise exactly one branch with the prefix must exist.
+func (f *Flow) findBranch(prefix, kind, version string) (string, error) {
+	branches, err := f.repo.ListBranches(prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s branches: %w", kind, err)
+	}
+
+	if version != "" {
+		// Accept both "1.2.0" and "release/1.2.0"
+		want := prefix + strings.TrimPrefix(version, prefix)
+		for _, branch := range branches {
+			if branch == want {
+				return branch, nil
+			}
+		}
+		return "", fmt.Errorf("%s branch %s not found", kind, want)
+	}
+
+	if len(branches) == 0 {
+		return "", fmt.Errorf("no %s in progress", kind)
+	}
+	if len(branches) > 1 {
+		return "", fmt.Errorf("multiple %ss in progress: %v (specify which version to finish)", kind, branches)
+	}
+	return branches[0], nil
+}
+
+// releaseBase returns the branch releases start from: develop,
+// or main when running without a develop branch.
+func (f *Flow) releaseBase() string {
+	if f.devBranch == "" {
+		return f.mainBranch
+	}
+	return f.devBranch
+}
+
+// CreateDevelop creates the develop branch from main and pushes it, when
+// auto-creation is enabled and the branch doesn't exist yet (see
+// Options.AutoCreateDevelop), for "mkrel init". It returns the name of
+// the branch it created, or "" if there was nothing to create.
+func (f *Flow) CreateDevelop() (string, error) {
+	name := f.missingDevelop
+	if name == "" {
+		return "", nil
+	}
+	unlock, err := f.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if err := f.createMissingDevelop(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// createMissingDevelop creates the develop branch from main and pushes
+// it, when auto-creation is enabled and the branch doesn't exist yet.
+// Until then, flows run main-only. Without a remote, the first finish
+// pushes it instead.
+func (f *Flow) createMissingDevelop() error {
+	if f.missingDevelop == "" {
+		return nil
+	}
+	name := f.missingDevelop
+
+	f.step("create-branch", map[string]string{"branch": name, "base": f.mainBranch},
+		"    Creating missing %s branch from %s", name, f.mainBranch)
+	if err := f.repo.SwitchCreate(name, f.mainBranch); err != nil {
+		return fmt.Errorf("failed to create %s branch: %w", name, err)
+	}
+
+	if f.HasRemote() {
+		f.step("push", map[string]string{"remote": f.remote, "branch": name},
+			"    Pushing %s to %s", name, f.remote)
+		if err := f.repo.Push(f.remote, false, name); err != nil {
+			return fmt.Errorf("failed to push %s branch: %w", name, err)
+		}
 	}
+
+	f.devBranch = name
+	f.missingDevelop = ""
+	return nil
 }
 
-// printAlways outputs a message regardless of verbose mode.
-func (f *Flow) printAlways(format string, args ...interface{}) {
-	fmt.Printf(format+"\n", args...)
+// lock acquires the repository lock for a mutating operation.
+// The returned function releases it. Dry runs don't take the lock.
+func (f *Flow) lock() (func(), error) {
+	if f.dryRun {
+		return func() {}, nil
+	}
+
+	if f.forceUnlock {
+		path, err := f.repo.LockPath()
+		if err != nil {
+			return nil, err
+		}
+		f.print("    Removing lock: %s", path)
+		if err := git.ForceUnlock(path); err != nil {
+			return nil, err
+		}
+	}
+
+	lock, err := f.repo.Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := lock.Release(); err != nil {
+			f.warn("Warning: %v", err)
+		}
+	}, nil
+}
+
+// ensureClean returns an error listing uncommitted changes, if any.
+// where describes the checked-out branch for the message.
+func (f *Flow) ensureClean(where string) error {
+	entries, err := f.repo.StatusFiles()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "uncommitted changes in %s:", where)
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\n  %s %s", entry.Status, entry.Path)
+	}
+	b.WriteString("\ncommit or stash them first")
+	return errors.New(b.String())
+}
+
+// checkWorktrees returns an error if any of the branches (empty names are
+// skipped) is checked out in another worktree, where git would refuse to
+// check it out here.
+func (f *Flow) checkWorktrees(branches ...string) error {
+	for _, branch := range branches {
+		if branch == "" {
+			continue
+		}
+		path, err := f.repo.OtherWorktree(branch)
+		if err != nil {
+			return fmt.Errorf("failed to list worktrees: %w", err)
+		}
+		if path != "" {
+			return fmt.Errorf("%s is checked out in another worktree: %s\nswitch that worktree to another branch first", branch, path)
+		}
+	}
+	return nil
+}
+
+// versionTagExists checks if a tag for version already exists.
+func (f *Flow) versionTagExists(v string) bool {
+	tagName, err := f.repo.FormatTag(v)
+	return err == nil && f.repo.TagExists(tagName)
+}
+
+// merge merges source into target, the current branch, with a merge
+// commit whose message follows Options.MergeMessage.
+func (f *Flow) merge(source, target, ver string) error {
+	return f.repo.MergeWithMessage(source, renderMergeMessage(f.mergeMessage, source, target, ver), true)
+}
+
+// renderMergeMessage fills in the placeholders of a merge message
+// template. An empty template gives an empty message.
+func renderMergeMessage(tmpl, source, target, ver string) string {
+	return strings.NewReplacer("{{source}}", source, "{{target}}", target, "{{version}}", ver).Replace(tmpl)
+}
+
+// verifyTag checks that tagName points at commit, the merge commit it
+// was created for, before it is pushed. Something committing while the
+// finish runs (e.g., a hook) would otherwise publish a tag on the wrong
+// commit.
+func (f *Flow) verifyTag(tagName, commit string) error {
+	if f.dryRun {
+		return nil
+	}
+	ok, err := f.repo.TagPointsAt(tagName, commit)
+	if err != nil {
+		return fmt.Errorf("failed to verify tag %s: %w", tagName, err)
+	}
+	if !ok {
+		return fmt.Errorf("tag %s doesn't point at the merge commit %s; nothing was pushed (did HEAD move while finishing?)",
+			tagName, shortSHA(commit))
+	}
+	return nil
+}
+
+// nonEmpty returns the branches with empty names left out, e.g., develop
+// when running main-only.
+func nonEmpty(branches ...string) []string {
+	var names []string
+	for _, branch := range branches {
+		if branch != "" {
+			names = append(names, branch)
+		}
+	}
+	return names
+}
+
+// pushWithTag pushes the given branches (empty names are skipped) along
+// with the tag. If no branches were updated, only the tag is pushed.
+// If setUpstream is set, branches without an upstream are pushed with -u;
+// existing tracking is left alone.
+func (f *Flow) pushWithTag(tagName string, setUpstream bool, branches ...string) error {
+	refs := nonEmpty(branches...)
+	if len(refs) == 0 {
+		return f.repo.Push(f.remote, false, "refs/tags/"+tagName)
+	}
+	if !setUpstream {
+		return f.repo.PushWithTags(f.remote, false, refs...)
+	}
+
+	missing := f.missingUpstreams(refs...)
+	var tracked []string
+	for _, ref := range refs {
+		if !slices.Contains(missing, ref) {
+			tracked = append(tracked, ref)
+		}
+	}
+	if len(tracked) > 0 {
+		if err := f.repo.PushWithTags(f.remote, false, tracked...); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		f.print("    Setting upstream: %s", strings.Join(missing, ", "))
+		return f.repo.PushWithTags(f.remote, true, missing...)
+	}
+	return nil
+}
+
+// pushFailed describes a failed finish push. If the remote rejected it
+// because of branch protection, it explains how to proceed.
+func (f *Flow) pushFailed(err error) error {
+	if errors.Is(err, git.ErrProtectedBranch) {
+		return fmt.Errorf("failed to push: %w\n"+
+			"%s only accepts changes through pull requests: run \"mkrel undo\" to roll back the local merge and tag, "+
+			"then merge the branch with a pull request and tag the merge", err, f.remote)
+	}
+	return fmt.Errorf("failed to push: %w", err)
+}
+
+// ErrMainPushCanceled is returned by finishes when
+// Options.ConfirmMainPush declines.
+var ErrMainPushCanceled = errors.New("push to main canceled")
+
+// confirmPush asks Options.ConfirmMainPush before pushing the branches
+// (empty names are skipped) and tag, if main is among them.
+func (f *Flow) confirmPush(tagName string, branches ...string) error {
+	if f.confirmMainPush == nil || f.dryRun || !slices.Contains(branches, f.mainBranch) {
+		return nil
+	}
+
+	refs := nonEmpty(branches...)
+	refs = append(refs, "tag "+tagName)
+	if f.confirmMainPush(fmt.Sprintf("Push to %s: %s", f.remote, strings.Join(refs, ", "))) {
+		return nil
+	}
+	return fmt.Errorf("%w: the merges and tag %s are local only; push them yourself, or run \"mkrel undo\"",
+		ErrMainPushCanceled, tagName)
+}
+
+// checkPush verifies, when validate_push is enabled, that the branches
+// (empty names are skipped) can be pushed, so a finish doesn't merge and
+// tag locally only to fail at the push.
+func (f *Flow) checkPush(branches ...string) error {
+	if !f.validatePush {
+		return nil
+	}
+
+	refs := nonEmpty(branches...)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	f.print("    Validating push to %s", f.remote)
+	return f.repo.PushDryRun(f.remote, refs...)
+}
+
+// warnMissingUpstreams warns about branches (empty names are skipped)
+// without an upstream, since pushing them may not update what the
+// remote's users expect.
+func (f *Flow) warnMissingUpstreams(branches ...string) {
+	for _, branch := range f.missingUpstreams(branches...) {
+		f.warn("    Warning: %s has no upstream; rerun with --set-upstream to set it on push", branch)
+	}
+}
+
+// ShallowCloneWarning explains why versions may be wrong in a shallow
+// clone and how to fix it.
+const ShallowCloneWarning = "this is a shallow clone, so older tags and history may be missing and versions computed wrong; run \"git fetch --unshallow --tags\" first"
+
+// IsShallow reports whether the repository is a shallow clone, as CI
+// systems often make.
+func (f *Flow) IsShallow() (bool, error) {
+	return f.repo.IsShallow()
+}
+
+// Unshallow fetches the full history and tags from the remote if the
+// repository is a shallow clone and Options.AutoUnshallow is set. It
+// reports whether it fetched, which it never does in dry-run mode.
+func (f *Flow) Unshallow() (bool, error) {
+	if !f.autoUnshallow {
+		return false, nil
+	}
+	if shallow, err := f.repo.IsShallow(); err != nil || !shallow {
+		return false, err
+	}
+	if err := f.repo.FetchUnshallow(f.remote); err != nil {
+		return false, fmt.Errorf("failed to fetch the full history: %w", err)
+	}
+	return !f.dryRun, nil
+}
+
+// CheckShallow runs before versions are computed. In a shallow clone, it
+// fetches the full history with auto_unshallow, and warns otherwise.
+func (f *Flow) CheckShallow() {
+	shallow, err := f.repo.IsShallow()
+	if err != nil || !shallow {
+		return
+	}
+	fetched, err := f.Unshallow()
+	if fetched {
+		f.printAlways("    Shallow clone: fetched the full history and tags from %s", f.remote)
+		return
+	}
+	if err != nil {
+		f.warn("    Warning: %v", err)
+	}
+	f.warn("    Warning: %s", ShallowCloneWarning)
+}
+
+// noteSigning tells the user when commit.gpgsign is set, since the
+// merge commits will be signed and may prompt for a passphrase.
+func (f *Flow) noteSigning() {
+	value, err := f.repo.ConfigGet("commit.gpgsign")
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		f.print("    commit.gpgsign is set; merge commits will be signed")
+	}
+}
+
+// missingUpstreams returns the branches (empty names are skipped) that
+// have no upstream configured.
+func (f *Flow) missingUpstreams(branches ...string) []string {
+	var missing []string
+	for _, branch := range nonEmpty(branches...) {
+		if upstream, err := f.repo.Upstream(branch); err == nil && upstream == "" {
+			missing = append(missing, branch)
+		}
+	}
+	return missing
+}
+
+// deleteBranch deletes a local branch, with "git branch -D" if force is
+// set and "-d" (merged branches only) otherwise.
+func (f *Flow) deleteBranch(branch string, force bool) error {
+	if force {
+		return f.repo.DeleteBranchForce(branch)
+	}
+	return f.repo.DeleteBranch(branch)
+}
+
+// shortSHA abbreviates a commit SHA for display.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
 }
@@ -0,0 +1,380 @@
+package flow
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// runGit runs a git command in dir with a fixed identity, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newTestRepo creates a repository with an initial commit on main, a develop
+// branch, and a bare "origin" remote that both branches are pushed to.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	remote := t.TempDir()
+	runGit(t, remote, "init", "--bare", "-b", "main")
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "develop")
+	runGit(t, dir, "remote", "add", "origin", remote)
+	runGit(t, dir, "push", "-q", "origin", "main", "develop")
+	return dir
+}
+
+// newTestFlow opens a Flow on dir with default branch names.
+func newTestFlow(t *testing.T, dir string, scheme version.Scheme) *Flow {
+	t.Helper()
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     scheme,
+		MainBranch: "main",
+		DevBranch:  "develop",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return f
+}
+
+func TestFindBranch(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if _, err := f.findBranch("release/", "release", ""); err == nil {
+		t.Error("findBranch() expected error with no release in progress")
+	}
+
+	runGit(t, dir, "branch", "release/1.1.0")
+	got, err := f.findBranch("release/", "release", "")
+	if err != nil {
+		t.Fatalf("findBranch() error = %v", err)
+	}
+	if got != "release/1.1.0" {
+		t.Errorf("findBranch() = %q, want %q", got, "release/1.1.0")
+	}
+
+	runGit(t, dir, "branch", "release/1.2.0")
+	if _, err := f.findBranch("release/", "release", ""); err == nil {
+		t.Error("findBranch() expected error with multiple releases in progress")
+	}
+
+	for _, v := range []string{"1.2.0", "release/1.2.0"} {
+		got, err := f.findBranch("release/", "release", v)
+		if err != nil {
+			t.Fatalf("findBranch(%q) error = %v", v, err)
+		}
+		if got != "release/1.2.0" {
+			t.Errorf("findBranch(%q) = %q, want %q", v, got, "release/1.2.0")
+		}
+	}
+
+	if _, err := f.findBranch("release/", "release", "9.9.9"); err == nil {
+		t.Error("findBranch() expected error for unknown version")
+	}
+}
+
+func TestNew_EmptyRepository(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+
+	_, err := New(Options{
+		WorkDir: dir,
+		Scheme:  version.SchemeSemVer,
+	})
+	if err == nil {
+		t.Fatal("New() expected error for repository without commits")
+	}
+	if !strings.Contains(err.Error(), "no commits yet") {
+		t.Errorf("New() error = %q, want it to mention no commits", err)
+	}
+}
+
+func TestWarnMissingUpstreams(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "branch", "-u", "origin/develop", "develop")
+
+	var warnings []string
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		OnEvent: func(e Event) {
+			if e.Type == EventWarning {
+				warnings = append(warnings, e.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	f.warnMissingUpstreams("main", "develop", "")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "main has no upstream") {
+		t.Errorf("warnings = %q, want one for main only", warnings)
+	}
+}
+
+func TestCheckShallow(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: more")
+	runGit(t, dir, "push", "-q", "origin", "main")
+	remote := runGit(t, dir, "remote", "get-url", "origin")
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	runGit(t, dir, "clone", "-q", "--depth", "1", "--no-single-branch", "file://"+remote, shallow)
+
+	for path, want := range map[string]int{dir: 0, shallow: 1} {
+		var warnings []string
+		f, err := New(Options{
+			WorkDir:    path,
+			Scheme:     version.SchemeSemVer,
+			MainBranch: "main",
+			DevBranch:  "develop",
+			OnEvent: func(e Event) {
+				if e.Type == EventWarning {
+					warnings = append(warnings, e.Message)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		f.CheckShallow()
+		if len(warnings) != want || (want > 0 && !strings.Contains(warnings[0], "git fetch --unshallow --tags")) {
+			t.Errorf("warnings in %s = %q, want %d suggesting to unshallow", filepath.Base(path), warnings, want)
+		}
+	}
+}
+
+func TestCheckShallow_AutoUnshallow(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: more")
+	runGit(t, dir, "push", "-q", "origin", "main")
+	remote := runGit(t, dir, "remote", "get-url", "origin")
+
+	for _, auto := range []bool{true, false} {
+		shallow := filepath.Join(t.TempDir(), "shallow")
+		runGit(t, dir, "clone", "-q", "--depth", "1", "--no-single-branch", "file://"+remote, shallow)
+
+		var warnings []string
+		f, err := New(Options{
+			WorkDir:       shallow,
+			Scheme:        version.SchemeSemVer,
+			MainBranch:    "main",
+			DevBranch:     "develop",
+			AutoUnshallow: auto,
+			OnEvent: func(e Event) {
+				if e.Type == EventWarning {
+					warnings = append(warnings, e.Message)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		f.CheckShallow()
+
+		stillShallow, err := f.IsShallow()
+		if err != nil {
+			t.Fatalf("IsShallow() error = %v", err)
+		}
+		if stillShallow == auto {
+			t.Errorf("auto_unshallow %v: shallow after check = %v, want %v", auto, stillShallow, !auto)
+		}
+		if want := map[bool]int{true: 0, false: 1}[auto]; len(warnings) != want {
+			t.Errorf("auto_unshallow %v: warnings = %q, want %d", auto, warnings, want)
+		}
+		if fetched, err := f.Unshallow(); err != nil || fetched {
+			t.Errorf("auto_unshallow %v: second Unshallow() = %v, %v, want false, nil", auto, fetched, err)
+		}
+	}
+}
+
+func TestCheckShallow_DryRun(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: more")
+	runGit(t, dir, "push", "-q", "origin", "main")
+	remote := runGit(t, dir, "remote", "get-url", "origin")
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	runGit(t, dir, "clone", "-q", "--depth", "1", "--no-single-branch", "file://"+remote, shallow)
+
+	var events []Event
+	f, err := New(Options{
+		WorkDir:       shallow,
+		Scheme:        version.SchemeSemVer,
+		MainBranch:    "main",
+		DevBranch:     "develop",
+		AutoUnshallow: true,
+		DryRun:        true,
+		OnEvent:       func(e Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	f.CheckShallow()
+
+	// Nothing was fetched, so the clone is still shallow and warned about
+	if stillShallow, err := f.IsShallow(); err != nil || !stillShallow {
+		t.Errorf("IsShallow() = %v, %v; want true", stillShallow, err)
+	}
+	for _, e := range events {
+		if strings.Contains(e.Message, "fetched") {
+			t.Errorf("dry run reported %q, want no fetch reported", e.Message)
+		}
+	}
+	if len(events) == 0 || events[len(events)-1].Type != EventWarning {
+		t.Errorf("events = %+v, want a shallow clone warning", events)
+	}
+}
+
+func TestWarnUnfetchedTags(t *testing.T) {
+	dir := newTestRepo(t)
+
+	var warnings []string
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		OnEvent: func(e Event) {
+			if e.Type == EventWarning {
+				warnings = append(warnings, e.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Tags already fetched (or none at all) are fine
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "push", "-q", "origin", "v1.0.0")
+	f.warnUnfetchedTags()
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %q, want none", warnings)
+	}
+
+	// Someone else released 1.1.0
+	remote := runGit(t, dir, "remote", "get-url", "origin")
+	runGit(t, remote, "tag", "v1.1.0", "main")
+	f.warnUnfetchedTags()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "v1.1.0") {
+		t.Errorf("warnings = %q, want one for v1.1.0", warnings)
+	}
+}
+
+func TestHasRemote(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if !f.HasRemote() {
+		t.Fatal("HasRemote() = false, want true for origin")
+	}
+
+	runGit(t, dir, "remote", "remove", "origin")
+	if f.HasRemote() {
+		t.Error("HasRemote() = true after removing origin")
+	}
+}
+
+// logRecorder is a slog.Handler that keeps the records it handles.
+type logRecorder struct {
+	records *[]slog.Record
+}
+
+func (h logRecorder) Enabled(context.Context, slog.Level) bool { return true }
+func (h logRecorder) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h logRecorder) WithGroup(string) slog.Handler            { return h }
+
+func (h logRecorder) Handle(_ context.Context, r slog.Record) error {
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+// recordAttrs returns the attributes of a log record by key.
+func recordAttrs(r slog.Record) map[string]string {
+	attrs := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	return attrs
+}
+
+func TestLogger(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "v1.0.0")
+
+	var records []slog.Record
+	var out strings.Builder
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		Output:     &out,
+		Logger:     slog.New(logRecorder{&records}),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := f.ReleaseStart(ReleaseStartOptions{AllowEmpty: true}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	var gitCommands, steps int
+	var done *slog.Record
+	for i, r := range records {
+		attrs := recordAttrs(r)
+		switch {
+		case r.Message == "git":
+			gitCommands++
+			if r.Level != slog.LevelDebug || attrs["args"] == "" || attrs["duration"] == "" {
+				t.Errorf("git record = %v %v, want a debug record with args and duration", r.Level, attrs)
+			}
+		case attrs["type"] == string(EventStepStart):
+			steps++
+		case attrs["type"] == string(EventStepDone):
+			done = &records[i]
+		}
+	}
+	if gitCommands == 0 || steps == 0 {
+		t.Errorf("logged %d git commands and %d steps, want both", gitCommands, steps)
+	}
+	if done == nil {
+		t.Fatal("no record of the completed release start")
+	}
+	if attrs := recordAttrs(*done); done.Level != slog.LevelInfo || attrs["step"] != "release-start" || attrs["branch"] != "release/"+attrs["version"] {
+		t.Errorf("done record = %v %q %v", done.Level, done.Message, attrs)
+	}
+
+	// Logging doesn't replace the printed output
+	if !strings.Contains(out.String(), recordAttrs(*done)["version"]) {
+		t.Errorf("output = %q, want the completion message", out.String())
+	}
+}
@@ -0,0 +1,155 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphStep is one branch in a Graph, with how the flow gets there from
+// the previous step.
+type GraphStep struct {
+	Ref string // Branch name
+	Via string // What leads here from the previous step (empty for the first)
+	Tag string // Tag created on this branch (optional)
+}
+
+// Graph describes the branches a release or hotfix passes through, for
+// "mkrel graph". It is built from the current state without changing
+// anything.
+type Graph struct {
+	Title  string      // e.g., "Release 1.3.0 (release/1.3.0-rc.0 in progress)"
+	Steps  []GraphStep // Branches in the order the flow visits them
+	Remote string      // Remote the finish pushes to
+	Push   []string    // Branches and tags the finish pushes
+}
+
+// String draws the graph as ASCII art.
+func (g *Graph) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", g.Title)
+	for i, step := range g.Steps {
+		if i > 0 {
+			fmt.Fprintf(&b, "    |  %s\n", step.Via)
+			b.WriteString("    v\n")
+		}
+		b.WriteString("  " + step.Ref)
+		if step.Tag != "" {
+			b.WriteString(" -- tag " + step.Tag)
+		}
+		b.WriteString("\n")
+	}
+	if len(g.Push) > 0 {
+		fmt.Fprintf(&b, "\n  Then push to %s: %s\n", g.Remote, strings.Join(g.Push, ", "))
+	}
+	return b.String()
+}
+
+// ReleaseGraph returns the graph of the release in progress, or of the
+// one "release start" would create.
+func (f *Flow) ReleaseGraph() (*Graph, error) {
+	releases, err := f.repo.ListBranches(f.releasePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release branches: %w", err)
+	}
+
+	var branch, base, title string
+	switch len(releases) {
+	case 0:
+		plan, err := f.PlanRelease()
+		if err != nil {
+			return nil, err
+		}
+		branch, base = plan.Branch, plan.Base
+		title = fmt.Sprintf("Release %s (not started)", f.versioner.RemovePrerelease(plan.Version))
+	case 1:
+		branch, base = releases[0], f.releaseBase()
+		title = fmt.Sprintf("Release %s (%s in progress)",
+			f.versioner.RemovePrerelease(strings.TrimPrefix(branch, f.releasePrefix)), branch)
+	default:
+		return nil, fmt.Errorf("multiple releases in progress: %v", releases)
+	}
+
+	tag, err := f.repo.FormatTag(f.versioner.RemovePrerelease(strings.TrimPrefix(branch, f.releasePrefix)))
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{
+		Title:  title,
+		Remote: f.remote,
+		Steps: []GraphStep{
+			{Ref: base},
+			{Ref: branch, Via: "release start"},
+			{Ref: f.mainBranch, Via: "release finish: merge", Tag: tag},
+		},
+		Push: []string{f.mainBranch},
+	}
+	if f.devBranch != "" && !f.releaseSkipDevelop {
+		g.Steps = append(g.Steps, GraphStep{Ref: f.devBranch, Via: "merge " + f.mainBranch + " back"})
+		g.Push = append(g.Push, f.devBranch)
+	}
+	g.Push = append(g.Push, tag)
+	g.Push = append(g.Push, f.movingTags...)
+	return g, nil
+}
+
+// HotfixGraph returns the graph of the hotfix in progress, or of the
+// one "hotfix start" would create from main. Hotfixes from a support
+// branch merge back into it only; hotfixes from an older tag are tagged
+// on their own branch.
+func (f *Flow) HotfixGraph() (*Graph, error) {
+	hotfixes, err := f.repo.ListBranches(f.hotfixPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotfix branches: %w", err)
+	}
+
+	var branch, base, title string
+	switch len(hotfixes) {
+	case 0:
+		plan, err := f.PlanHotfix()
+		if err != nil {
+			return nil, err
+		}
+		branch, base = plan.Branch, plan.Base
+		title = fmt.Sprintf("Hotfix %s (not started)", plan.Version)
+	case 1:
+		branch = hotfixes[0]
+		if base, err = f.repo.BranchConfig(branch, hotfixBaseKey); err != nil {
+			return nil, err
+		}
+		if base == "" {
+			base = f.mainBranch
+		}
+		title = fmt.Sprintf("Hotfix %s (%s in progress)", strings.TrimPrefix(branch, f.hotfixPrefix), branch)
+	default:
+		return nil, fmt.Errorf("multiple hotfixes in progress: %v", hotfixes)
+	}
+
+	tag, err := f.repo.FormatTag(strings.TrimPrefix(branch, f.hotfixPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{
+		Title:  title,
+		Remote: f.remote,
+		Steps:  []GraphStep{{Ref: base}, {Ref: branch, Via: "hotfix start"}},
+	}
+	switch {
+	case base != f.mainBranch && !f.repo.BranchExists(base):
+		// Started from a tag: nothing to merge into
+		g.Steps[1].Tag = tag
+	case base != f.mainBranch:
+		g.Steps = append(g.Steps, GraphStep{Ref: base, Via: "hotfix finish: merge", Tag: tag})
+		g.Push = append(g.Push, base)
+	default:
+		g.Steps = append(g.Steps, GraphStep{Ref: f.mainBranch, Via: "hotfix finish: merge", Tag: tag})
+		g.Push = append(g.Push, f.mainBranch)
+		if f.devBranch != "" && !f.hotfixSkipDevelop {
+			g.Steps = append(g.Steps, GraphStep{Ref: f.devBranch, Via: "merge " + f.mainBranch + " back"})
+			g.Push = append(g.Push, f.devBranch)
+		}
+	}
+	g.Push = append(g.Push, tag)
+	return g, nil
+}
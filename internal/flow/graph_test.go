@@ -0,0 +1,124 @@
+package flow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestReleaseGraph(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	g, err := f.ReleaseGraph()
+	if err != nil {
+		t.Fatalf("ReleaseGraph() error = %v", err)
+	}
+	want := `Release 0.1.0 (not started)
+
+  develop
+    |  release start
+    v
+  release/0.1.0-rc.0
+    |  release finish: merge
+    v
+  main -- tag v0.1.0
+    |  merge main back
+    v
+  develop
+
+  Then push to origin: main, develop, v0.1.0
+`
+	if got := g.String(); got != want {
+		t.Errorf("ReleaseGraph() =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if g, err = f.ReleaseGraph(); err != nil {
+		t.Fatalf("ReleaseGraph() error = %v", err)
+	}
+	if want := "Release 0.1.0 (release/0.1.0-rc.0 in progress)"; g.Title != want {
+		t.Errorf("Title = %q, want %q", g.Title, want)
+	}
+}
+
+func TestReleaseGraph_MainOnly(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "branch", "-D", "develop")
+	f, err := New(Options{WorkDir: dir, Scheme: version.SchemeSemVer, MainBranch: "main", DevelopOptional: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	g, err := f.ReleaseGraph()
+	if err != nil {
+		t.Fatalf("ReleaseGraph() error = %v", err)
+	}
+	want := []GraphStep{
+		{Ref: "main"},
+		{Ref: "release/0.1.0-rc.0", Via: "release start"},
+		{Ref: "main", Via: "release finish: merge", Tag: "v0.1.0"},
+	}
+	if !reflect.DeepEqual(g.Steps, want) {
+		t.Errorf("Steps = %+v, want %+v", g.Steps, want)
+	}
+	if want := []string{"main", "v0.1.0"}; !reflect.DeepEqual(g.Push, want) {
+		t.Errorf("Push = %q, want %q", g.Push, want)
+	}
+}
+
+func TestHotfixGraph(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	runGit(t, dir, "tag", "v1.0.0", "main")
+	runGit(t, dir, "branch", "support/1.0", "main")
+
+	tests := []struct {
+		base string
+		want []GraphStep
+		push []string
+	}{
+		{"", []GraphStep{
+			{Ref: "main"},
+			{Ref: "hotfix/1.0.1", Via: "hotfix start"},
+			{Ref: "main", Via: "hotfix finish: merge", Tag: "v1.0.1"},
+			{Ref: "develop", Via: "merge main back"},
+		}, []string{"main", "develop", "v1.0.1"}},
+		{"support/1.0", []GraphStep{
+			{Ref: "support/1.0"},
+			{Ref: "hotfix/1.0.1", Via: "hotfix start"},
+			{Ref: "support/1.0", Via: "hotfix finish: merge", Tag: "v1.0.1"},
+		}, []string{"support/1.0", "v1.0.1"}},
+		{"v1.0.0", []GraphStep{
+			{Ref: "v1.0.0"},
+			{Ref: "hotfix/1.0.1", Via: "hotfix start", Tag: "v1.0.1"},
+		}, []string{"v1.0.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			if err := f.HotfixStart(HotfixStartOptions{Base: tt.base}); err != nil {
+				t.Fatalf("HotfixStart() error = %v", err)
+			}
+			t.Cleanup(func() {
+				runGit(t, dir, "checkout", "-q", "main")
+				runGit(t, dir, "branch", "-D", "hotfix/1.0.1")
+			})
+
+			g, err := f.HotfixGraph()
+			if err != nil {
+				t.Fatalf("HotfixGraph() error = %v", err)
+			}
+			if !reflect.DeepEqual(g.Steps, tt.want) {
+				t.Errorf("Steps = %+v, want %+v", g.Steps, tt.want)
+			}
+			if !reflect.DeepEqual(g.Push, tt.push) {
+				t.Errorf("Push = %q, want %q", g.Push, tt.push)
+			}
+		})
+	}
+}
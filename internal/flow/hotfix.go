@@ -1,64 +1,121 @@
 package flow
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/kloudlabs-io/mkrel/internal/git"
 	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
+// hotfixBaseKey is the branch config key recording a hotfix's custom base.
+// Git removes it together with the branch.
+const hotfixBaseKey = "mkrelBase"
+
+// HotfixStartOptions configures HotfixStart.
+type HotfixStartOptions struct {
+	AllowMultiple bool   // Start even if another hotfix is in progress
+	Base          string // Branch or tag to start from (empty = main)
+}
+
+// HotfixFinishOptions configures HotfixFinish.
+type HotfixFinishOptions struct {
+	Version     string // Hotfix to finish (empty = the only hotfix in progress)
+	SetUpstream bool   // Push main and develop with -u if they have no upstream
+	Draft       bool   // Publish the release as a draft
+	Prerelease  bool   // Publish the release marked as a prerelease
+	Message     string // Tag annotation, overriding the default and changelog_in_tag
+	ForceDelete bool   // Delete the hotfix branch even if git doesn't consider it merged
+}
+
+// HotfixApplyOptions configures HotfixApply.
+type HotfixApplyOptions struct {
+	Version string // Hotfix to apply (empty = the latest release on main)
+}
+
 // HotfixStart begins a new hotfix.
-// It creates a hotfix branch from main with a patch/hotfix version bump.
-func (f *Flow) HotfixStart() error {
+// It creates a hotfix branch from main (or opts.Base) with a patch/hotfix
+// version bump computed from the latest tag reachable from that base.
+func (f *Flow) HotfixStart(opts HotfixStartOptions) error {
 	f.print("==> Starting new hotfix")
 
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// 1. Check no hotfix already in progress
-	hotfixes, err := f.repo.ListBranches("hotfix/")
+	hotfixes, err := f.repo.ListBranches(f.hotfixPrefix)
 	if err != nil {
 		return fmt.Errorf("failed to list hotfix branches: %w", err)
 	}
-	if len(hotfixes) > 0 {
-		return fmt.Errorf("hotfix already in progress: %s", hotfixes[0])
+	if len(hotfixes) > 0 && !opts.AllowMultiple {
+		return fmt.Errorf("hotfix already in progress: %s (use --allow-multiple to start another)", hotfixes[0])
 	}
 
-	// 2. Use configured main branch
-	f.print("    Using main branch: %s", f.mainBranch)
+	// 2. Use configured main branch unless a base was given
+	base := f.mainBranch
+	versioner := f.versioner
+	if opts.Base != "" && opts.Base != f.mainBranch {
+		if _, err := f.repo.ResolveRef(opts.Base); err != nil {
+			return fmt.Errorf("unknown hotfix base: %s", opts.Base)
+		}
+		base = opts.Base
 
-	// 3. Checkout main and ensure clean
-	if err := f.repo.Checkout(f.mainBranch); err != nil {
-		return fmt.Errorf("failed to checkout %s: %w", f.mainBranch, err)
+		// Version from the base's history, not the latest overall,
+		// using the base branch's own scheme if it has one
+		scheme := schemeFor(f.branchSchemes, base, f.defaultScheme)
+		versioner, err = version.New(scheme, func() (string, error) {
+			return currentVersion(f.repo, scheme, f.versionFile, base)
+		})
+		if err != nil {
+			return err
+		}
 	}
+	f.print("    Using base: %s", base)
 
-	hasChanges, err := f.repo.HasUncommittedChanges()
-	if err != nil {
+	// 3. Checkout base and ensure clean
+	if err := f.checkWorktrees(base); err != nil {
 		return err
 	}
-	if hasChanges {
-		return fmt.Errorf("uncommitted changes in working directory")
+	// Checkout rather than Switch: the base may be a tag
+	if err := f.repo.Checkout(base); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", base, err)
 	}
 
-	// 4. Calculate next hotfix version
-	current, err := f.versioner.Current()
-	if err != nil {
-		return fmt.Errorf("failed to get current version: %w", err)
+	if err := f.ensureClean("working directory"); err != nil {
+		return err
 	}
-	f.print("    Current version: %s", current)
 
-	nextVersion, err := f.versioner.Next(current, version.BumpHotfix)
+	// 4. Calculate next hotfix version
+	f.CheckShallow()
+	nextVersion, err := f.nextHotfix(versioner, hotfixes)
 	if err != nil {
-		return fmt.Errorf("failed to calculate next version: %w", err)
+		return err
 	}
 	f.print("    Hotfix version: %s", nextVersion)
 
 	// 5. Create hotfix branch
-	branchName := "hotfix/" + nextVersion
-	f.print("    Creating branch: %s", branchName)
+	branchName := f.hotfixPrefix + nextVersion
+	f.step("create-branch", map[string]string{"branch": branchName, "base": base},
+		"    Creating branch: %s", branchName)
 
-	if err := f.repo.CreateBranch(branchName, f.mainBranch); err != nil {
+	if err := f.repo.CheckoutNew(branchName, base); err != nil {
 		return fmt.Errorf("failed to create hotfix branch: %w", err)
 	}
 
-	f.printAlways("==> Hotfix %s started", nextVersion)
+	// Remember a custom base so finish knows where the hotfix belongs
+	if base != f.mainBranch {
+		if err := f.repo.SetBranchConfig(branchName, hotfixBaseKey, base); err != nil {
+			return fmt.Errorf("failed to record hotfix base: %w", err)
+		}
+	}
+
+	f.done("hotfix-start", map[string]string{"version": nextVersion, "branch": branchName},
+		"==> Hotfix %s started", nextVersion)
 	f.printAlways("    Branch: %s", branchName)
 	f.printAlways("")
 	f.printAlways("    Make your fixes, then run:")
@@ -69,86 +126,268 @@ func (f *Flow) HotfixStart() error {
 
 // HotfixFinish completes the current hotfix.
 // It merges to main, tags, merges to develop, and pushes.
-func (f *Flow) HotfixFinish() error {
+//
+// Hotfixes started from a support branch merge back into that branch only.
+// Hotfixes started from an older tag are tagged on their own tip, since
+// main and develop have already moved past that version.
+func (f *Flow) HotfixFinish(opts HotfixFinishOptions) error {
 	f.print("==> Finishing hotfix")
 
-	// 1. Find hotfix branch
-	hotfixes, err := f.repo.ListBranches("hotfix/")
+	unlock, err := f.lock()
 	if err != nil {
-		return fmt.Errorf("failed to list hotfix branches: %w", err)
-	}
-	if len(hotfixes) == 0 {
-		return fmt.Errorf("no hotfix in progress")
-	}
-	if len(hotfixes) > 1 {
-		return fmt.Errorf("multiple hotfixes in progress: %v", hotfixes)
+		return err
 	}
+	defer unlock()
 
-	hotfixBranch := hotfixes[0]
+	// 1. Find hotfix branch
+	hotfixBranch, err := f.findBranch(f.hotfixPrefix, "hotfix", opts.Version)
+	if err != nil {
+		return err
+	}
 	f.print("    Hotfix branch: %s", hotfixBranch)
 
 	// Extract version from branch name
-	hotfixVersion := strings.TrimPrefix(hotfixBranch, "hotfix/")
+	hotfixVersion := strings.TrimPrefix(hotfixBranch, f.hotfixPrefix)
 	f.print("    Version: %s", hotfixVersion)
 
-	// 2. Use configured main and develop branches
+	// 2. Use configured main and develop branches, or the recorded base
 	mainBranch := f.mainBranch
 	developBranch := f.devBranch
 
+	base, err := f.repo.BranchConfig(hotfixBranch, hotfixBaseKey)
+	if err != nil {
+		return err
+	}
+	if base != "" && base != f.mainBranch {
+		f.print("    Base: %s", base)
+		developBranch = ""
+		mainBranch = ""
+		if f.repo.BranchExists(base) {
+			mainBranch = base
+		}
+	}
+
+	skippedDevelop := ""
+	if f.hotfixSkipDevelop && developBranch != "" {
+		f.print("    Not merging into %s (hotfix_merge_develop is false)", developBranch)
+		skippedDevelop, developBranch = developBranch, ""
+	}
+
 	// 3. Checkout hotfix branch and verify clean
-	if err := f.repo.Checkout(hotfixBranch); err != nil {
+	if err := f.checkWorktrees(hotfixBranch, mainBranch, developBranch); err != nil {
+		return err
+	}
+	if err := f.repo.Switch(hotfixBranch); err != nil {
 		return fmt.Errorf("failed to checkout hotfix branch: %w", err)
 	}
 
-	hasChanges, err := f.repo.HasUncommittedChanges()
-	if err != nil {
+	if err := f.ensureClean("hotfix branch"); err != nil {
 		return err
 	}
-	if hasChanges {
-		return fmt.Errorf("uncommitted changes in hotfix branch")
+	if err := f.checkSignatures(hotfixBranch); err != nil {
+		return err
 	}
 
-	// 4. Merge to main
-	f.print("    Merging to %s", mainBranch)
-	if err := f.repo.Checkout(mainBranch); err != nil {
+	if err := f.checkPush(mainBranch, developBranch); err != nil {
 		return err
 	}
-	if err := f.repo.Merge(hotfixBranch, true); err != nil {
-		return fmt.Errorf("failed to merge to %s: %w", mainBranch, err)
+	if err := f.checkPublish(); err != nil {
+		return err
+	}
+	if !opts.SetUpstream {
+		f.warnMissingUpstreams(mainBranch, developBranch)
 	}
+	f.noteSigning()
+	f.CheckShallow()
 
-	// 5. Create tag
 	tagName, err := f.repo.FormatTag(hotfixVersion)
 	if err != nil {
 		return err
 	}
-	f.print("    Creating tag: %s", tagName)
-	if err := f.repo.CreateTag(tagName, "Hotfix "+hotfixVersion); err != nil {
-		return fmt.Errorf("failed to create tag: %w", err)
+	if err := f.saveSnapshot("hotfix finish", tagName, tagName, nil, hotfixBranch, mainBranch, developBranch); err != nil {
+		return err
 	}
 
-	// 6. Merge to develop
-	f.print("    Merging to %s", developBranch)
-	if err := f.repo.Checkout(developBranch); err != nil {
+	if err := f.updateVersionFile(hotfixVersion); err != nil {
+		return err
+	}
+	if err := f.updateChangelogFile(hotfixVersion); err != nil {
 		return err
 	}
-	if err := f.repo.Merge(mainBranch, true); err != nil {
-		return fmt.Errorf("failed to merge to %s: %w", developBranch, err)
+
+	// 4. Merge to main (tag-based hotfixes stay on the hotfix branch)
+	if mainBranch != "" {
+		f.step("merge", map[string]string{"source": hotfixBranch, "target": mainBranch},
+			"    Merging to %s", mainBranch)
+		if err := f.repo.Switch(mainBranch); err != nil {
+			return err
+		}
+		if err := f.merge(hotfixBranch, mainBranch, hotfixVersion); err != nil {
+			return fmt.Errorf("failed to merge to %s: %w", mainBranch, err)
+		}
+	}
+
+	// 5. Create tag on the merge commit
+	commit, err := f.repo.ResolveRef("HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	f.print("    Commit: %s", commit)
+
+	f.step("tag", map[string]string{"tag": tagName, "commit": commit},
+		"    Creating tag: %s", tagName)
+	if err := f.createReleaseTag(tagName, hotfixVersion, "Hotfix "+hotfixVersion, opts.Message); err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	// 6. Merge to develop
+	if developBranch != "" {
+		f.step("merge", map[string]string{"source": mainBranch, "target": developBranch},
+			"    Merging to %s", developBranch)
+		if err := f.repo.Switch(developBranch); err != nil {
+			return err
+		}
+		if err := f.merge(mainBranch, developBranch, hotfixVersion); err != nil {
+			return fmt.Errorf("failed to merge to %s: %w", developBranch, err)
+		}
 	}
 
 	// 7. Push everything
-	f.print("    Pushing to %s", f.remote)
-	if err := f.repo.PushWithTags(f.remote, mainBranch, developBranch); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	if err := f.verifyTag(tagName, commit); err != nil {
+		return err
+	}
+	if err := f.confirmPush(tagName, mainBranch, developBranch); err != nil {
+		return err
+	}
+	f.step("push", map[string]string{"remote": f.remote},
+		"    Pushing to %s", f.remote)
+	if err := f.pushWithTag(tagName, opts.SetUpstream, mainBranch, developBranch); err != nil {
+		return f.pushFailed(err)
+	}
+	if err := f.publish(tagName, hotfixVersion, opts.Draft, opts.Prerelease); err != nil {
+		return err
 	}
 
 	// 8. Delete hotfix branch
-	f.print("    Deleting branch: %s", hotfixBranch)
-	if err := f.repo.DeleteBranch(hotfixBranch); err != nil {
-		f.print("    Warning: failed to delete branch: %v", err)
+	f.step("delete-branch", map[string]string{"branch": hotfixBranch},
+		"    Deleting branch: %s", hotfixBranch)
+	if mainBranch == "" {
+		// Tag-based hotfix: nothing merged it, but the new tag keeps its commits
+		if err := f.repo.Switch(f.mainBranch); err != nil {
+			return err
+		}
+		if err := f.repo.DeleteBranchForce(hotfixBranch); err != nil {
+			f.warn("    Warning: failed to delete branch: %v", err)
+		}
+	} else if err := f.deleteBranch(hotfixBranch, opts.ForceDelete); err != nil {
+		f.warn("    Warning: failed to delete branch: %v (use --force-delete to delete it anyway)", err)
+	}
+
+	f.done("hotfix-finish", map[string]string{"version": hotfixVersion, "tag": tagName, "commit": commit},
+		"==> Hotfix %s released (%s)", hotfixVersion, shortSHA(commit))
+	if skippedDevelop != "" {
+		f.printAlways("    %s doesn't have this fix; cherry-pick it if it needs it", skippedDevelop)
+	}
+
+	return nil
+}
+
+// HotfixApply cherry-picks the commits of a finished hotfix onto another
+// branch, such as a support branch for an older release or a develop
+// branch the hotfix wasn't merged into. The commits are those since the
+// previous release, without merges and mkrel's own release commits.
+// Nothing is pushed, so the result can be reviewed first.
+func (f *Flow) HotfixApply(branch string, opts HotfixApplyOptions) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !f.repo.BranchExists(branch) {
+		return fmt.Errorf("branch %s not found", branch)
+	}
+
+	tag, err := f.hotfixTag(opts.Version)
+	if err != nil {
+		return err
+	}
+	ver, _ := f.repo.TagVersion(tag)
+	previous, err := f.previousReleaseTag(tag + "^")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return fmt.Errorf("%s is the first release; there is no hotfix to apply", tag)
+	}
+	// Applying a minor release would cherry-pick all of its features
+	previousVer, _ := f.repo.TagVersion(previous)
+	if !version.IsHotfix(ver, previousVer, f.versioner.Scheme()) {
+		return fmt.Errorf("%s is a release, not a hotfix of %s; pass --version with the hotfix to apply", tag, previous)
 	}
 
-	f.printAlways("==> Hotfix %s released", hotfixVersion)
+	commits, err := f.repo.LogNoMerges(previous, tag)
+	if err != nil {
+		return fmt.Errorf("failed to list the commits of %s: %w", tag, err)
+	}
+	// Oldest first, without the version and changelog commits of the finish
+	var hashes []string
+	for _, commit := range slices.Backward(commits) {
+		if strings.HasPrefix(commit.Subject, releaseCommitPrefix) {
+			continue
+		}
+		hashes = append(hashes, commit.Hash)
+		f.print("    %s %s", shortSHA(commit.Hash), commit.Subject)
+	}
+	if len(hashes) == 0 {
+		return fmt.Errorf("no commits to apply between %s and %s", previous, tag)
+	}
 
+	if err := f.checkWorktrees(branch); err != nil {
+		return err
+	}
+	if err := f.repo.Switch(branch); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", branch, err)
+	}
+	if err := f.ensureClean("working directory"); err != nil {
+		return err
+	}
+
+	f.step("cherry-pick", map[string]string{"tag": tag, "branch": branch},
+		"    Cherry-picking %d commit(s) from %s onto %s", len(hashes), tag, branch)
+	if err := f.repo.CherryPick(hashes...); err != nil {
+		if errors.Is(err, git.ErrCherryPickConflict) {
+			return fmt.Errorf("%w\nresolve the conflicts and run \"git cherry-pick --continue\" to apply the rest, or \"git cherry-pick --abort\" to undo it", err)
+		}
+		return fmt.Errorf("failed to cherry-pick onto %s: %w", branch, err)
+	}
+
+	f.done("hotfix-apply", map[string]string{"version": ver, "tag": tag, "branch": branch},
+		"==> Hotfix %s applied to %s", ver, branch)
+	f.printAlways("    Review it, then push it: git push %s %s", f.remote, branch)
 	return nil
 }
+
+// hotfixTag returns the tag of the hotfix version ver, or of the latest
+// release on main if ver is empty. HotfixApply checks it is a hotfix.
+func (f *Flow) hotfixTag(ver string) (string, error) {
+	if ver == "" {
+		tag, err := f.previousReleaseTag(f.mainBranch)
+		if err != nil {
+			return "", err
+		}
+		if tag == "" {
+			return "", fmt.Errorf("no release found on %s", f.mainBranch)
+		}
+		return tag, nil
+	}
+
+	tag, err := f.repo.FormatTag(ver)
+	if err != nil {
+		return "", err
+	}
+	if !f.repo.TagExists(tag) {
+		return "", fmt.Errorf("tag %s not found", tag)
+	}
+	return tag, nil
+}
@@ -0,0 +1,346 @@
+package flow
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/git"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestHotfixStart_AllowMultiple(t *testing.T) {
+	// Nothing is released, so the first CalVer hotfix is versioned like a release
+	today := version.NewCalVer(nil).FormatForToday()
+	tests := []struct {
+		name   string
+		scheme version.Scheme
+		want   []string // Suffixes of the two hotfix branches, in order
+	}{
+		{name: "semver", scheme: version.SchemeSemVer, want: []string{"/0.0.1", "/0.0.2"}},
+		{name: "calver", scheme: version.SchemeCalVer, want: []string{"/" + today, "/" + today + "-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			f := newTestFlow(t, dir, tt.scheme)
+
+			if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+				t.Fatalf("HotfixStart() error = %v", err)
+			}
+			if err := f.HotfixStart(HotfixStartOptions{}); err == nil {
+				t.Fatal("HotfixStart() expected error with hotfix in progress")
+			}
+			if err := f.HotfixStart(HotfixStartOptions{AllowMultiple: true}); err != nil {
+				t.Fatalf("HotfixStart(AllowMultiple) error = %v", err)
+			}
+
+			hotfixes, err := f.repo.ListBranches("hotfix/")
+			if err != nil {
+				t.Fatalf("ListBranches() error = %v", err)
+			}
+			if len(hotfixes) != 2 {
+				t.Fatalf("ListBranches() = %v, want 2 hotfix branches", hotfixes)
+			}
+			for i, suffix := range tt.want {
+				if !strings.HasSuffix(hotfixes[i], suffix) {
+					t.Errorf("hotfix branch %d = %q, want suffix %q", i, hotfixes[i], suffix)
+				}
+			}
+		})
+	}
+}
+
+func TestHotfixStart_NeedsRelease(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:            dir,
+		Scheme:             version.SchemeCalVer,
+		MainBranch:         "main",
+		DevBranch:          "develop",
+		HotfixNeedsRelease: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = f.HotfixStart(HotfixStartOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no release to hotfix") {
+		t.Fatalf("HotfixStart() error = %v, want one saying there is no release", err)
+	}
+	if hotfixes, _ := f.repo.ListBranches("hotfix/"); len(hotfixes) != 0 {
+		t.Errorf("hotfix branches = %v, want none", hotfixes)
+	}
+
+	// Once released, hotfixes start as usual
+	tag, _ := f.repo.FormatTag("2025.01.02")
+	runGit(t, dir, "tag", "-a", tag, "-m", "Release")
+	if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+		t.Fatalf("HotfixStart() after a release error = %v", err)
+	}
+}
+
+func TestHotfix_Base(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       string // Base passed to HotfixStart
+		wantBranch string // Branch that must contain the hotfix tag ("" = none)
+	}{
+		{name: "support branch", base: "support/1.0", wantBranch: "support/1.0"},
+		{name: "tag", base: "v1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+			runGit(t, dir, "branch", "support/1.0")
+			runGit(t, dir, "commit", "--allow-empty", "-m", "feature")
+			runGit(t, dir, "tag", "-a", "v1.1.0", "-m", "Release 1.1.0")
+			f := newTestFlow(t, dir, version.SchemeSemVer)
+
+			if err := f.HotfixStart(HotfixStartOptions{Base: tt.base}); err != nil {
+				t.Fatalf("HotfixStart() error = %v", err)
+			}
+			// Version comes from the base, not the latest tag (v1.1.0)
+			if !f.repo.BranchExists("hotfix/1.0.1") {
+				t.Fatal("HotfixStart() did not create hotfix/1.0.1")
+			}
+			runGit(t, dir, "commit", "--allow-empty", "-m", "fix")
+
+			if err := f.HotfixFinish(HotfixFinishOptions{}); err != nil {
+				t.Fatalf("HotfixFinish() error = %v", err)
+			}
+			if !f.repo.TagExists("v1.0.1") {
+				t.Fatal("HotfixFinish() did not create tag v1.0.1")
+			}
+			if f.repo.BranchExists("hotfix/1.0.1") {
+				t.Error("HotfixFinish() did not delete the hotfix branch")
+			}
+
+			// Main and develop never receive an old-base hotfix
+			for _, branch := range []string{"main", "develop"} {
+				if contains := runGit(t, dir, "branch", "--contains", "v1.0.1", "--list", branch); contains != "" {
+					t.Errorf("hotfix tag reachable from %s, want it kept off", branch)
+				}
+			}
+			if tt.wantBranch != "" {
+				if contains := runGit(t, dir, "branch", "--contains", "v1.0.1", "--list", tt.wantBranch); contains == "" {
+					t.Errorf("hotfix tag not reachable from %s", tt.wantBranch)
+				}
+			}
+		})
+	}
+}
+
+func TestHotfixFinish_ConfirmMainPush(t *testing.T) {
+	// Only hotfixes of main ask; support branches aren't production
+	for base, wantAsked := range map[string]int{"": 1, "support/1.0": 0} {
+		dir := newTestRepo(t)
+		runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+		runGit(t, dir, "branch", "support/1.0")
+		asked := 0
+		f, err := New(Options{
+			WorkDir:    dir,
+			Scheme:     version.SchemeSemVer,
+			MainBranch: "main",
+			DevBranch:  "develop",
+			ConfirmMainPush: func(string) bool {
+				asked++
+				return true
+			},
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		if err := f.HotfixStart(HotfixStartOptions{Base: base}); err != nil {
+			t.Fatalf("HotfixStart() error = %v", err)
+		}
+		runGit(t, dir, "commit", "--allow-empty", "-m", "fix")
+		if err := f.HotfixFinish(HotfixFinishOptions{}); err != nil {
+			t.Fatalf("HotfixFinish() error = %v", err)
+		}
+		if asked != wantAsked {
+			t.Errorf("base %q: asked %d times, want %d", base, asked, wantAsked)
+		}
+	}
+}
+
+func TestHotfixStart_BranchScheme(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	runGit(t, dir, "branch", "lts")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feature")
+	runGit(t, dir, "tag", "-a", "v2025.12.25", "-m", "Release 2025.12.25")
+
+	f, err := New(Options{
+		WorkDir:       dir,
+		Scheme:        version.SchemeCalVer,
+		BranchSchemes: map[string]version.Scheme{"lts": version.SchemeSemVer},
+		MainBranch:    "main",
+		DevBranch:     "develop",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := f.versioner.Scheme(); got != version.SchemeCalVer {
+		t.Errorf("main scheme = %s, want %s", got, version.SchemeCalVer)
+	}
+
+	if err := f.HotfixStart(HotfixStartOptions{Base: "lts"}); err != nil {
+		t.Fatalf("HotfixStart() error = %v", err)
+	}
+	if !f.repo.BranchExists("hotfix/1.0.1") {
+		t.Error("HotfixStart() did not create a SemVer hotfix/1.0.1 from lts")
+	}
+}
+
+func TestInProgress(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	runGit(t, dir, "branch", "lts")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if releases, err := f.ReleasesInProgress(); err != nil || len(releases) != 0 {
+		t.Fatalf("ReleasesInProgress() = %+v, %v, want none", releases, err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+		t.Fatalf("HotfixStart() error = %v", err)
+	}
+	if err := f.HotfixStart(HotfixStartOptions{Base: "lts", AllowMultiple: true}); err != nil {
+		t.Fatalf("HotfixStart(lts) error = %v", err)
+	}
+
+	// A branch created by hand has no version
+	runGit(t, dir, "branch", "release/next", "develop")
+	releases, err := f.ReleasesInProgress()
+	if err != nil {
+		t.Fatalf("ReleasesInProgress() error = %v", err)
+	}
+	wantReleases := []InProgress{
+		{Branch: "release/1.1.0-rc.0", Version: "1.1.0-rc.0"},
+		{Branch: "release/next"},
+	}
+	if !reflect.DeepEqual(releases, wantReleases) {
+		t.Errorf("ReleasesInProgress() = %+v, want %+v", releases, wantReleases)
+	}
+
+	hotfixes, err := f.HotfixesInProgress()
+	if err != nil {
+		t.Fatalf("HotfixesInProgress() error = %v", err)
+	}
+	wantHotfixes := []InProgress{
+		{Branch: "hotfix/1.0.1", Version: "1.0.1", Base: "main"},
+		{Branch: "hotfix/1.0.2", Version: "1.0.2", Base: "lts"},
+	}
+	if !reflect.DeepEqual(hotfixes, wantHotfixes) {
+		t.Errorf("HotfixesInProgress() = %+v, want %+v", hotfixes, wantHotfixes)
+	}
+}
+
+func TestHotfixApply(t *testing.T) {
+	dir := newTestRepo(t)
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("app.txt", "v1\n")
+	runGit(t, dir, "add", "app.txt")
+	runGit(t, dir, "commit", "-q", "-m", "feat: add app")
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	runGit(t, dir, "branch", "support/1.0")
+	runGit(t, dir, "push", "-q", "origin", "main", "develop")
+	f, err := New(Options{
+		WorkDir:           dir,
+		Scheme:            version.SchemeSemVer,
+		MainBranch:        "main",
+		DevBranch:         "develop",
+		HotfixSkipDevelop: true,
+		ChangelogFile:     "CHANGELOG.md",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+		t.Fatalf("HotfixStart() error = %v", err)
+	}
+	writeFile("app.txt", "v1 fixed\n")
+	runGit(t, dir, "commit", "-q", "-am", "fix: crash")
+	if err := f.HotfixFinish(HotfixFinishOptions{}); err != nil {
+		t.Fatalf("HotfixFinish() error = %v", err)
+	}
+
+	if err := f.HotfixApply("support/1.0", HotfixApplyOptions{}); err != nil {
+		t.Fatalf("HotfixApply() error = %v", err)
+	}
+	if got := runGit(t, dir, "show", "support/1.0:app.txt"); got != "v1 fixed" {
+		t.Errorf("app.txt on support/1.0 = %q, want the fix", got)
+	}
+	// Only the fix: not the changelog commit of the finish
+	if got := runGit(t, dir, "log", "--format=%s", "v1.0.0..support/1.0"); got != "fix: crash" {
+		t.Errorf("commits applied = %q, want the fix only", got)
+	}
+
+	// A conflicting change leaves the cherry-pick in progress
+	writeFile("app.txt", "v1 patched differently\n")
+	runGit(t, dir, "commit", "-q", "-am", "fix: patch differently")
+	runGit(t, dir, "checkout", "-q", "develop")
+	err = f.HotfixApply("support/1.0", HotfixApplyOptions{Version: "1.0.1"})
+	if !errors.Is(err, git.ErrCherryPickConflict) || !strings.Contains(err.Error(), "git cherry-pick --continue") {
+		t.Fatalf("HotfixApply() error = %v, want a conflict with guidance", err)
+	}
+	runGit(t, dir, "cherry-pick", "--abort")
+
+	if err := f.HotfixApply("support/9.9", HotfixApplyOptions{}); err == nil {
+		t.Error("HotfixApply() succeeded onto a missing branch")
+	}
+}
+
+func TestHotfixApply_AfterMinorRelease(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	runGit(t, dir, "branch", "support/1.0")
+	for _, c := range []struct{ file, subject, tag string }{
+		{"fix.txt", "fix: crash", "v1.0.1"},
+		{"widgets.txt", "feat: add widgets", "v1.1.0"},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, c.file), []byte(c.subject+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", c.file)
+		runGit(t, dir, "commit", "-q", "-m", c.subject)
+		runGit(t, dir, "tag", "-a", c.tag, "-m", c.tag)
+	}
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	support := runGit(t, dir, "rev-parse", "support/1.0")
+
+	// The latest release is a minor one: its features aren't a hotfix
+	err := f.HotfixApply("support/1.0", HotfixApplyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "not a hotfix") {
+		t.Fatalf("HotfixApply() error = %v, want a refusal to apply v1.1.0", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "support/1.0"); got != support {
+		t.Error("HotfixApply() changed support/1.0 despite refusing")
+	}
+
+	if err := f.HotfixApply("support/1.0", HotfixApplyOptions{Version: "1.0.1"}); err != nil {
+		t.Fatalf("HotfixApply(1.0.1) error = %v", err)
+	}
+	if got := runGit(t, dir, "log", "--format=%s", "v1.0.0..support/1.0"); got != "fix: crash" {
+		t.Errorf("commits applied = %q, want the fix only", got)
+	}
+}
@@ -0,0 +1,58 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+)
+
+// checkCommits verifies, when lint_commits is enabled, that the commits
+// on ref since the previous release follow the changelog style
+// (Conventional Commits by default), so the generated changelog doesn't
+// file them under "Other Changes".
+// Offenders are reported as a warning, or as an error when strict.
+func (f *Flow) checkCommits(ref string, strict bool) error {
+	if !f.lintCommits {
+		return nil
+	}
+
+	previous, err := f.previousReleaseTag(ref)
+	if err != nil {
+		return err
+	}
+	commits, err := f.repo.LogNoMerges(previous, ref)
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var offenders []string
+	for _, c := range commits {
+		if _, ok := f.changelogStyle.Parse(c.Subject); !ok {
+			offenders = append(offenders, shortSHA(c.Hash)+" "+c.Subject)
+		}
+	}
+	if len(offenders) == 0 {
+		f.print("    Commit messages: %d conventional", len(commits))
+		return nil
+	}
+
+	since, err := f.rangeStart(previous, ref)
+	if err != nil {
+		return err
+	}
+	if since != previous {
+		since = "the first commit (" + shortSHA(since) + ")"
+	}
+	style := "Conventional Commits"
+	if f.changelogStyle == changelog.StyleGitmoji {
+		style = "gitmoji"
+	}
+	msg := fmt.Sprintf("%d commit(s) since %s don't follow %s:\n      %s",
+		len(offenders), since, style, strings.Join(offenders, "\n      "))
+	if strict {
+		return fmt.Errorf("%s\nreword them, or run without --strict", msg)
+	}
+	f.warn("    Warning: %s", msg)
+	return nil
+}
@@ -0,0 +1,139 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// Release is a published version, as listed by Releases.
+type Release struct {
+	Version    string
+	Tag        string
+	Date       time.Time // Date of the tagged commit
+	Subject    string    // First line of the tag message; empty for lightweight tags
+	Prerelease bool      // Whether the version is a prerelease (e.g., "1.3.0-rc.0")
+}
+
+// Releases returns the version tags in the repository, highest version
+// first. Tags that aren't versions in any configured scheme are skipped.
+// With a limit above 0, only the limit highest versions are listed.
+func (f *Flow) Releases(limit int) ([]Release, error) {
+	tags, err := f.repo.ListVersionTags(limit, f.isVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var releases []Release
+	for _, tag := range tags {
+		v, _ := f.repo.TagVersion(tag)
+		date, err := f.repo.TagDate(tag)
+		if err != nil {
+			return nil, err
+		}
+		message, err := f.repo.TagMessage(tag)
+		if err != nil {
+			return nil, err
+		}
+		subject, _, _ := strings.Cut(message, "\n")
+		releases = append(releases, Release{
+			Version:    v,
+			Tag:        tag,
+			Date:       date,
+			Subject:    subject,
+			Prerelease: f.isPrerelease(v),
+		})
+	}
+	return releases, nil
+}
+
+// isVersion reports whether v is valid in the main scheme or any
+// per-branch scheme.
+func (f *Flow) isVersion(v string) bool {
+	if f.versioner.IsValid(v) {
+		return true
+	}
+	for _, scheme := range f.branchSchemes {
+		if version.IsValid(v, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrerelease reports whether v is a prerelease in a scheme it's valid
+// in, the main scheme first.
+func (f *Flow) isPrerelease(v string) bool {
+	if f.versioner.IsValid(v) {
+		return version.IsPrerelease(v, f.versioner.Scheme())
+	}
+	for _, scheme := range f.branchSchemes {
+		if version.IsValid(v, scheme) {
+			return version.IsPrerelease(v, scheme)
+		}
+	}
+	return false
+}
+
+// InProgress is a release or hotfix branch that hasn't been finished yet.
+type InProgress struct {
+	Branch  string
+	Version string // Version in the branch name, empty if it isn't a valid version
+	Base    string // For hotfixes, the branch it merges into; release branches don't record theirs
+}
+
+// ReleasesInProgress returns the release branches, sorted by name.
+func (f *Flow) ReleasesInProgress() ([]InProgress, error) {
+	branches, err := f.repo.ListBranches(f.releasePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release branches: %w", err)
+	}
+
+	releases := make([]InProgress, 0, len(branches))
+	for _, branch := range branches {
+		releases = append(releases, InProgress{
+			Branch:  branch,
+			Version: f.branchVersion(branch, f.releasePrefix),
+		})
+	}
+	return releases, nil
+}
+
+// branchVersion returns the version in a release or hotfix branch name,
+// or "" if the rest of the name after prefix isn't a valid version (e.g.,
+// a branch created by hand).
+func (f *Flow) branchVersion(branch, prefix string) string {
+	v := strings.TrimPrefix(branch, prefix)
+	if !f.isVersion(v) {
+		return ""
+	}
+	return v
+}
+
+// HotfixesInProgress returns the hotfix branches, sorted by name, with
+// the base recorded by "hotfix start --base" (main if none).
+func (f *Flow) HotfixesInProgress() ([]InProgress, error) {
+	branches, err := f.repo.ListBranches(f.hotfixPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotfix branches: %w", err)
+	}
+
+	hotfixes := make([]InProgress, 0, len(branches))
+	for _, branch := range branches {
+		base, err := f.repo.BranchConfig(branch, hotfixBaseKey)
+		if err != nil {
+			return nil, err
+		}
+		if base == "" {
+			base = f.mainBranch
+		}
+		hotfixes = append(hotfixes, InProgress{
+			Branch:  branch,
+			Version: f.branchVersion(branch, f.hotfixPrefix),
+			Base:    base,
+		})
+	}
+	return hotfixes, nil
+}
@@ -0,0 +1,52 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// MessageNames lists the operations whose completion message
+// ("==> Released 1.3.0 (abc1234)") Options.Messages can override. They
+// match the Step of the EventStepDone events.
+var MessageNames = []string{
+	"release-start", "release-resume", "release-rename", "release-channel", "release-finish", "release-sync",
+	"hotfix-start", "hotfix-finish", "hotfix-apply", "undo",
+}
+
+// compileMessages parses the message templates of Options.Messages,
+// keyed by operation (see MessageNames). Templates see the event's fields
+// (e.g., {{.version}}, {{.tag}}) and a "short" function for commit SHAs.
+func compileMessages(messages map[string]string) (map[string]*template.Template, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	compiled := make(map[string]*template.Template, len(messages))
+	for name, text := range messages {
+		if !slices.Contains(MessageNames, name) {
+			return nil, fmt.Errorf("messages: unknown operation %q (valid: %s)", name, strings.Join(MessageNames, ", "))
+		}
+		tmpl, err := template.New(name).Funcs(template.FuncMap{"short": shortSHA}).
+			Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("messages.%s: %w", name, err)
+		}
+		compiled[name] = tmpl
+	}
+	return compiled, nil
+}
+
+// message renders the completion message of an operation from its
+// template, if one is configured. An empty result suppresses the message.
+func (f *Flow) message(name string, fields map[string]string, fallback string) string {
+	tmpl, ok := f.messages[name]
+	if !ok {
+		return fallback
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, fields); err != nil {
+		return fallback
+	}
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package flow
+
+import "fmt"
+
+// moveTags points the configured moving tags (e.g., "latest") at a
+// release commit and force-pushes them. Unlike version tags they are
+// rewritten on every release, so clones that fetched the old tag keep it
+// until they fetch with --force.
+func (f *Flow) moveTags(commit, ver string) error {
+	for _, name := range f.movingTags {
+		f.step("move-tag", map[string]string{"tag": name, "commit": commit},
+			"    Moving tag: %s -> %s", name, shortSHA(commit))
+		if f.repo.TagExists(name) {
+			f.warn("    Warning: rewriting tag %s and force-pushing it to %s", name, f.remote)
+		}
+
+		if err := f.repo.MoveTag(name, commit, "Release "+ver); err != nil {
+			return fmt.Errorf("failed to move tag %s: %w", name, err)
+		}
+		// The "+" forces the update, since the remote tag points elsewhere
+		if err := f.repo.Push(f.remote, false, "+refs/tags/"+name); err != nil {
+			return fmt.Errorf("failed to push tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// Plan describes what a start command would create.
+type Plan struct {
+	Version string // Version of the new release or hotfix
+	Branch  string // Branch that would be created
+	Base    string // Branch it would be created from
+}
+
+// PlanRelease returns what "release start" would create, without
+// changing anything.
+func (f *Flow) PlanRelease() (*Plan, error) {
+	nextVersion, err := f.NextRelease()
+	if err != nil {
+		return nil, err
+	}
+
+	// Release start creates a missing develop branch first when enabled
+	base := f.releaseBase()
+	if f.missingDevelop != "" {
+		base = f.missingDevelop
+	}
+	return &Plan{Version: nextVersion, Branch: f.releaseBranch(nextVersion), Base: base}, nil
+}
+
+// PlanHotfix returns what "hotfix start" would create from main,
+// without changing anything. With a hotfix already in progress, it
+// plans the one "hotfix start --allow-multiple" would add.
+func (f *Flow) PlanHotfix() (*Plan, error) {
+	hotfixes, err := f.repo.ListBranches(f.hotfixPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotfix branches: %w", err)
+	}
+
+	nextVersion, err := f.nextHotfix(f.versioner, hotfixes)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{Version: nextVersion, Branch: f.hotfixPrefix + nextVersion, Base: f.mainBranch}, nil
+}
+
+// UpcomingVersions returns the final versions of the next n releases, or
+// of the next n hotfixes with hotfix set, each assuming the one before
+// was finished. The first is the planned version without a prerelease
+// suffix. CalVer releases are all today's date, so it returns one.
+func (f *Flow) UpcomingVersions(hotfix bool, n int) ([]string, error) {
+	plan, bump := f.PlanRelease, version.BumpMinor
+	if hotfix {
+		plan, bump = f.PlanHotfix, version.BumpHotfix
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("invalid count %d: must be at least 1", n)
+	}
+
+	first, err := plan()
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{f.versioner.RemovePrerelease(first.Version)}
+	if n == 1 {
+		return versions, nil
+	}
+	rest, err := f.versioner.NextN(versions[0], bump, n-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate upcoming versions: %w", err)
+	}
+	return append(versions, rest...), nil
+}
+
+// releaseBranch returns the branch name for a release version, leaving
+// out the prerelease suffix if configured.
+func (f *Flow) releaseBranch(v string) string {
+	if f.finalBranches {
+		v = f.versioner.RemovePrerelease(v)
+	}
+	return f.releasePrefix + v
+}
+
+// nextHotfix calculates the next hotfix version with versioner, skipping
+// versions claimed by the in-progress hotfixes or already released.
+func (f *Flow) nextHotfix(versioner version.Versioner, hotfixes []string) (string, error) {
+	current, err := versioner.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current version: %w", err)
+	}
+	f.print("    Current version: %s", current)
+	if current == "" && f.hotfixNeedsRelease {
+		return "", fmt.Errorf("no release to hotfix yet; run \"mkrel release start\" first (or set hotfix_without_release: true)")
+	}
+
+	nextVersion, err := versioner.Next(current, version.BumpHotfix)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate next version: %w", err)
+	}
+
+	// Parallel hotfixes share the same base version, so skip past any
+	// version already claimed by an in-progress hotfix branch or, for
+	// older bases, already released by an earlier maintenance hotfix
+	for slices.Contains(hotfixes, f.hotfixPrefix+nextVersion) || f.versionTagExists(nextVersion) {
+		nextVersion, err = versioner.Next(nextVersion, version.BumpHotfix)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate next version: %w", err)
+		}
+	}
+	return nextVersion, nil
+}
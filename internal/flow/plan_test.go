@@ -0,0 +1,110 @@
+package flow
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestPlanRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		wantBranch string
+	}{
+		{"default", Options{}, "release/0.1.0-rc.0"},
+		{"namespace", Options{Namespace: "mytool"}, "mytool/release/0.1.0-rc.0"},
+		{"final branch names", Options{FinalBranchNames: true}, "release/0.1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			opts := tt.opts
+			opts.WorkDir, opts.Scheme, opts.MainBranch, opts.DevBranch = dir, version.SchemeSemVer, "main", "develop"
+			f, err := New(opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			plan, err := f.PlanRelease()
+			if err != nil {
+				t.Fatalf("PlanRelease() error = %v", err)
+			}
+			want := Plan{Version: "0.1.0-rc.0", Branch: tt.wantBranch, Base: "develop"}
+			if *plan != want {
+				t.Errorf("PlanRelease() = %+v, want %+v", *plan, want)
+			}
+
+			// The plan matches what release start does
+			if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+				t.Fatalf("ReleaseStart() error = %v", err)
+			}
+			if !f.repo.BranchExists(plan.Branch) {
+				t.Errorf("ReleaseStart() did not create planned branch %s", plan.Branch)
+			}
+		})
+	}
+}
+
+func TestPlanHotfix(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.2.0", "-m", "Release 1.2.0")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	plan, err := f.PlanHotfix()
+	if err != nil {
+		t.Fatalf("PlanHotfix() error = %v", err)
+	}
+	if want := (Plan{Version: "1.2.1", Branch: "hotfix/1.2.1", Base: "main"}); *plan != want {
+		t.Errorf("PlanHotfix() = %+v, want %+v", *plan, want)
+	}
+
+	// With a hotfix in progress, the next one gets the following version
+	if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+		t.Fatalf("HotfixStart() error = %v", err)
+	}
+	plan, err = f.PlanHotfix()
+	if err != nil {
+		t.Fatalf("PlanHotfix() error = %v", err)
+	}
+	if plan.Branch != "hotfix/1.2.2" {
+		t.Errorf("PlanHotfix() branch = %s, want hotfix/1.2.2", plan.Branch)
+	}
+}
+
+func TestUpcomingVersions(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.2.0", "-m", "Release 1.2.0")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	for _, tt := range []struct {
+		hotfix bool
+		want   []string
+	}{
+		{false, []string{"1.3.0", "1.4.0", "1.5.0"}},
+		{true, []string{"1.2.1", "1.2.2", "1.2.3"}},
+	} {
+		got, err := f.UpcomingVersions(tt.hotfix, 3)
+		if err != nil {
+			t.Fatalf("UpcomingVersions(%v) error = %v", tt.hotfix, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("UpcomingVersions(%v) = %q, want %q", tt.hotfix, got, tt.want)
+		}
+	}
+	if _, err := f.UpcomingVersions(false, 0); err == nil {
+		t.Error("UpcomingVersions() expected error for a count of 0")
+	}
+
+	// CalVer releases are all today's date
+	calver := newTestFlow(t, dir, version.SchemeCalVer)
+	got, err := calver.UpcomingVersions(false, 3)
+	if err != nil {
+		t.Fatalf("UpcomingVersions() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("UpcomingVersions() = %q, want today's version only", got)
+	}
+}
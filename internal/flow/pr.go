@@ -0,0 +1,70 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+)
+
+// PullRequest describes the pull request merging a release into main.
+type PullRequest struct {
+	Title     string
+	Head      string // Release branch
+	Base      string // Main branch
+	Body      string // Release notes
+	RemoteURL string // URL of the remote the branch was pushed to
+
+	// Version and FinishSteps make up the "release finish" that tags and
+	// publishes the release once the pull request is merged:
+	// "release finish <Version> --only <FinishSteps>".
+	Version     string
+	FinishSteps []string
+}
+
+// ReleasePR pushes the release branch (the only one in progress, or the
+// given version's) and describes the pull request merging it into main,
+// with the release notes as its body. Opening the pull request is left
+// to the caller, which knows the hosting provider.
+func (f *Flow) ReleasePR(ver string) (*PullRequest, error) {
+	f.print("==> Preparing release pull request")
+
+	releaseBranch, err := f.findBranch(f.releasePrefix, "release", ver)
+	if err != nil {
+		return nil, err
+	}
+	finalVersion := f.versioner.RemovePrerelease(strings.TrimPrefix(releaseBranch, f.releasePrefix))
+	f.print("    Release branch: %s", releaseBranch)
+
+	previous, err := f.previousReleaseTag(releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := f.changes(previous, releaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate release notes: %w", err)
+	}
+
+	remoteURL, err := f.repo.RemoteURL(f.remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the URL of %s: %w", f.remote, err)
+	}
+
+	f.step("push", map[string]string{"remote": f.remote, "branch": releaseBranch},
+		"    Pushing %s to %s", releaseBranch, f.remote)
+	if err := f.repo.Push(f.remote, true, releaseBranch); err != nil {
+		return nil, fmt.Errorf("failed to push: %w", err)
+	}
+
+	return &PullRequest{
+		Title:     "Release " + finalVersion,
+		Head:      releaseBranch,
+		Base:      f.mainBranch,
+		Body:      changelog.Render(finalVersion, time.Now(), sections),
+		RemoteURL: remoteURL,
+
+		Version:     strings.TrimPrefix(releaseBranch, f.releasePrefix),
+		FinishSteps: []string{StepTag, StepPush, StepPublish, StepCleanup},
+	}, nil
+}
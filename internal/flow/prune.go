@@ -0,0 +1,71 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// featurePrefix is the prefix of feature branches. Features are created
+// by hand, so it isn't namespaced like release and hotfix branches.
+const featurePrefix = "feature/"
+
+// pruneMergedFeatures deletes local feature branches fully merged into
+// the release base (develop, or main when running main-only), when
+// prune_features is enabled. Unmerged branches are kept. In a dry run
+// the candidates are only listed.
+func (f *Flow) pruneMergedFeatures() error {
+	merged, err := f.mergedFeatures()
+	if err != nil {
+		return err
+	}
+
+	for _, branch := range merged {
+		// Always shown: deleting branches the user created deserves a note
+		f.emit(Event{
+			Type:    EventStepStart,
+			Step:    "delete-branch",
+			Message: fmt.Sprintf("    Deleting merged feature branch: %s", branch),
+			Fields:  map[string]string{"branch": branch},
+		}, true)
+		// The branch is known to be merged; -d would also insist on an upstream
+		if err := f.repo.DeleteBranchForce(branch); err != nil {
+			f.warn("    Warning: failed to delete branch: %v", err)
+		}
+	}
+	return nil
+}
+
+// mergedFeatures returns the feature branches pruneMergedFeatures would
+// delete, or none when prune_features is disabled.
+func (f *Flow) mergedFeatures() ([]string, error) {
+	if !f.pruneFeatures {
+		return nil, nil
+	}
+
+	target := f.releaseBase()
+	merged, err := f.repo.MergedBranches(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches merged into %s: %w", target, err)
+	}
+	var features []string
+	for _, branch := range merged {
+		if strings.HasPrefix(branch, featurePrefix) {
+			features = append(features, branch)
+		}
+	}
+	return features, nil
+}
+
+// Branches returns the local branches. With merged, only those fully
+// merged into the release base (develop, or main when running
+// main-only) are returned, along with that base.
+func (f *Flow) Branches(merged bool) (branches []string, target string, err error) {
+	if !merged {
+		branches, err = f.repo.ListBranches("")
+		return branches, "", err
+	}
+
+	target = f.releaseBase()
+	branches, err = f.repo.MergedBranches(target)
+	return branches, target, err
+}
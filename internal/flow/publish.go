@@ -0,0 +1,92 @@
+package flow
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// Publication is a pushed release to publish on the Git host.
+type Publication struct {
+	Version    string
+	Tag        string
+	Notes      string // Release notes, in Markdown
+	RemoteURL  string // URL of the remote the tag was pushed to
+	Draft      bool   // Create the release as a draft, for review
+	Prerelease bool   // Mark the release as a prerelease
+
+	// Step and Warn report the publisher's progress (e.g., each asset it
+	// uploads) as events of the Flow, like its own steps and warnings.
+	Step func(name string, fields map[string]string, format string, args ...interface{})
+	Warn func(format string, args ...interface{})
+}
+
+// ReleasePublisher publishes releases on the Git host (e.g., as GitHub
+// releases).
+type ReleasePublisher interface {
+	// Check verifies that releases can be published to the remote at
+	// remoteURL (e.g., that a token is set), so a finish fails before
+	// merging rather than after pushing.
+	Check(remoteURL string) error
+
+	// Publish creates the release and returns its URL.
+	Publish(p Publication) (string, error)
+}
+
+// checkPublish verifies, when a publisher is set, that the release can
+// be published once pushed.
+func (f *Flow) checkPublish() error {
+	if f.publisher == nil {
+		return nil
+	}
+	remoteURL, err := f.repo.RemoteURL(f.remote)
+	if err != nil {
+		return fmt.Errorf("failed to get the URL of %s: %w", f.remote, err)
+	}
+	return f.publisher.Check(remoteURL)
+}
+
+// publish publishes a pushed release tag with its release notes, when a
+// publisher is set. Prereleases (e.g., 1.3.0-rc.0) are always marked as
+// such; draft and prerelease mark any release.
+func (f *Flow) publish(tagName, ver string, draft, prerelease bool) error {
+	if f.publisher == nil {
+		return nil
+	}
+
+	p := Publication{
+		Version:    ver,
+		Tag:        tagName,
+		Draft:      draft || f.draftReleases,
+		Prerelease: prerelease || version.IsPrerelease(ver, f.versioner.Scheme()),
+		Step:       f.step,
+		Warn:       f.warn,
+	}
+	f.step("publish", map[string]string{
+		"tag":        tagName,
+		"draft":      strconv.FormatBool(p.Draft),
+		"prerelease": strconv.FormatBool(p.Prerelease),
+	}, "    Publishing release: %s", tagName)
+	if f.dryRun {
+		// The tag wasn't created, so there are no notes to publish
+		return nil
+	}
+
+	notes, err := f.TagNotes(tagName)
+	if err != nil {
+		return fmt.Errorf("failed to generate release notes: %w", err)
+	}
+	p.Notes = changelog.Render(notes.Version, notes.Date, notes.Sections)
+	if p.RemoteURL, err = f.repo.RemoteURL(f.remote); err != nil {
+		return fmt.Errorf("failed to get the URL of %s: %w", f.remote, err)
+	}
+
+	url, err := f.publisher.Publish(p)
+	if err != nil {
+		return fmt.Errorf("%s is pushed, but publishing the release failed: %w", tagName, err)
+	}
+	f.printAlways("    Published: %s", url)
+	return nil
+}
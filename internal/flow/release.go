@@ -1,164 +1,828 @@
 package flow
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/kloudlabs-io/mkrel/internal/version"
 )
 
+// ReleaseStartOptions configures ReleaseStart.
+type ReleaseStartOptions struct {
+	Push   bool // Tag the release candidate and push the tag (SemVer only)
+	Strict bool // Fail instead of warning when commit linting finds offenders
+	Resume bool // Switch to a release already in progress instead of failing
+
+	AllowEmpty bool // Start even if nothing changed since the last release
+
+	// Confirm is asked with the current and computed next version before
+	// the branch is created. It returns the version to start (the next
+	// version, or one typed instead) and whether to proceed. Nil proceeds
+	// with the next version; dry runs don't ask.
+	Confirm func(current, next string) (string, bool)
+}
+
+// ErrStartCanceled is returned by ReleaseStart when Confirm declines.
+var ErrStartCanceled = errors.New("release start canceled")
+
 // ReleaseStart begins a new release.
-// It creates a release branch from develop with the next version.
-func (f *Flow) ReleaseStart() error {
+// It creates a release branch from develop (or main, when running
+// without a develop branch) with the next version.
+func (f *Flow) ReleaseStart(opts ReleaseStartOptions) error {
 	f.print("==> Starting new release")
 
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// 1. Check no release already in progress
-	releases, err := f.repo.ListBranches("release/")
+	releases, err := f.repo.ListBranches(f.releasePrefix)
 	if err != nil {
 		return fmt.Errorf("failed to list release branches: %w", err)
 	}
+	if len(releases) > 0 && opts.Resume {
+		return f.resumeRelease(releases)
+	}
 	if len(releases) > 0 {
-		return fmt.Errorf("release already in progress: %s", releases[0])
+		return fmt.Errorf("release already in progress: %s (use --resume to continue it)", releases[0])
 	}
+	f.warnUnfetchedTags()
+	f.CheckShallow()
 
-	// 2. Use configured develop branch
-	f.print("    Using develop branch: %s", f.devBranch)
+	// 2. Use configured develop branch, creating it if enabled
+	if err := f.createMissingDevelop(); err != nil {
+		return err
+	}
+	base := f.releaseBase()
+	f.print("    Using base branch: %s", base)
 
 	// 3. Checkout develop and ensure clean
-	if err := f.repo.Checkout(f.devBranch); err != nil {
-		return fmt.Errorf("failed to checkout %s: %w", f.devBranch, err)
+	if err := f.checkWorktrees(base); err != nil {
+		return err
+	}
+	if err := f.repo.Switch(base); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", base, err)
 	}
 
-	hasChanges, err := f.repo.HasUncommittedChanges()
-	if err != nil {
+	if err := f.ensureClean("working directory"); err != nil {
+		return err
+	}
+
+	if err := f.checkCommits(base, opts.Strict); err != nil {
 		return err
 	}
-	if hasChanges {
-		return fmt.Errorf("uncommitted changes in working directory")
+	if err := f.checkUnreleased(base, opts.AllowEmpty); err != nil {
+		return err
 	}
 
 	// 4. Calculate next version
+	nextVersion, err := f.NextRelease()
+	if err != nil {
+		return err
+	}
+	f.print("    New version: %s", nextVersion)
+
+	if opts.Confirm != nil && !f.dryRun {
+		if nextVersion, err = f.confirmStart(opts.Confirm, nextVersion); err != nil {
+			return err
+		}
+	}
+
+	// 5. Create release branch, named with the final version if configured
+	branchName := f.releaseBranch(nextVersion)
+	f.step("create-branch", map[string]string{"branch": branchName, "base": base},
+		"    Creating branch: %s", branchName)
+
+	if err := f.repo.CheckoutNew(branchName, base); err != nil {
+		return fmt.Errorf("failed to create release branch: %w", err)
+	}
+
+	// 6. Optionally publish the release candidate tag for CI to build
+	if opts.Push {
+		if err := f.pushCandidateTag(nextVersion); err != nil {
+			return err
+		}
+	}
+
+	if f.dryRun {
+		f.printAlways("")
+		f.printAlways("==> Dry run: release %s would be started", nextVersion)
+		f.printAlways("    Base branch: %s", base)
+		f.printAlways("    New branch:  %s", branchName)
+		f.printAlways("    Version:     %s", nextVersion)
+		f.printAlways("    No changes were made.")
+		return nil
+	}
+
+	f.done("release-start", map[string]string{"version": nextVersion, "branch": branchName},
+		"==> Release %s started", nextVersion)
+	f.printAlways("    Branch: %s", branchName)
+	f.printAlways("")
+	f.printAlways("    Make any final changes, then run:")
+	f.printAlways("      mkrel release finish")
+
+	return nil
+}
+
+// confirmStart asks confirm whether to start next, and checks a version
+// given instead is valid and not released yet.
+func (f *Flow) confirmStart(confirm func(current, next string) (string, bool), next string) (string, error) {
+	current, err := f.CurrentVersion()
+	if err != nil {
+		return "", err
+	}
+	chosen, ok := confirm(current, next)
+	if !ok {
+		return "", ErrStartCanceled
+	}
+	if chosen == next {
+		return next, nil
+	}
+
+	// Accept both "1.4.0" and "release/1.4.0"
+	chosen = strings.TrimPrefix(chosen, f.releasePrefix)
+	if !f.versioner.IsValid(chosen) {
+		return "", fmt.Errorf("invalid %s version: %s", f.versioner.Scheme(), chosen)
+	}
+	if f.versionTagExists(f.versioner.RemovePrerelease(chosen)) {
+		return "", fmt.Errorf("version %s is already released", chosen)
+	}
+	f.print("    Version overridden: %s", chosen)
+	return chosen, nil
+}
+
+// resumeRelease checks out the release already in progress, for pipelines
+// that rerun "release start" and then continue to finish it.
+func (f *Flow) resumeRelease(releases []string) error {
+	if len(releases) > 1 {
+		return fmt.Errorf("multiple releases in progress: %v (finish or delete all but one)", releases)
+	}
+	branchName := releases[0]
+	releaseVersion := strings.TrimPrefix(branchName, f.releasePrefix)
+
+	if err := f.checkWorktrees(branchName); err != nil {
+		return err
+	}
+	f.step("checkout", map[string]string{"branch": branchName},
+		"    Checking out: %s", branchName)
+	if err := f.repo.Switch(branchName); err != nil {
+		return fmt.Errorf("failed to checkout release branch: %w", err)
+	}
+
+	f.done("release-resume", map[string]string{"version": releaseVersion, "branch": branchName},
+		"==> Release %s already in progress, resumed", releaseVersion)
+	f.printAlways("    Branch: %s", branchName)
+	return nil
+}
+
+// checkUnreleased fails if base (checked out) has no commits since the
+// last release, so the new release would be identical to it. With
+// allowEmpty, it only warns.
+func (f *Flow) checkUnreleased(base string, allowEmpty bool) error {
+	last, err := f.previousReleaseTag("HEAD")
+	if err != nil || last == "" {
+		return err
+	}
+	count, err := f.repo.CommitsSinceTag(last)
+	if err != nil {
+		return fmt.Errorf("failed to count commits since %s: %w", last, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if !allowEmpty {
+		return fmt.Errorf("no changes on %s since %s, nothing to release (use --allow-empty to release anyway)", base, last)
+	}
+	f.warn("    Warning: %s has no changes since %s; the release will be identical to it", base, last)
+	return nil
+}
+
+// warnUnfetchedTags warns if the remote's latest version tag hasn't been
+// fetched, e.g., because someone else released in the meantime. It asks
+// the remote with ls-remote instead of fetching, and stays quiet if the
+// remote can't be reached.
+func (f *Flow) warnUnfetchedTags() {
+	tag, err := f.repo.LatestRemoteTag(f.remote, f.isVersion)
+	if err != nil || tag == "" || f.repo.TagExists(tag) {
+		return
+	}
+	f.warn("    Warning: %s has tag %s, which isn't fetched (someone may have released already; run \"git fetch --tags\")",
+		f.remote, tag)
+}
+
+// CurrentVersion returns the version of the latest release tag.
+// Returns empty string if nothing has been released yet.
+func (f *Flow) CurrentVersion() (string, error) {
 	current, err := f.versioner.Current()
 	if err != nil {
-		return fmt.Errorf("failed to get current version: %w", err)
+		return "", fmt.Errorf("failed to get current version: %w", err)
+	}
+	return current, nil
+}
+
+// Describe returns a version for builds between releases, like
+// "1.2.3-5-gabc1234" (see git.Repository.Describe).
+func (f *Flow) Describe() (string, error) {
+	described, err := f.repo.Describe()
+	if err != nil {
+		return "", fmt.Errorf("failed to describe HEAD: %w", err)
+	}
+	return described, nil
+}
+
+// NextRelease returns the version "release start" would create: the
+// next minor (SemVer, as an rc.0 prerelease) or today's date (CalVer).
+func (f *Flow) NextRelease() (string, error) {
+	current, err := f.CurrentVersion()
+	if err != nil {
+		return "", err
 	}
 	f.print("    Current version: %s", current)
 
 	nextVersion, err := f.versioner.Next(current, version.BumpMinor)
 	if err != nil {
-		return fmt.Errorf("failed to calculate next version: %w", err)
+		return "", fmt.Errorf("failed to calculate next version: %w", err)
 	}
 
 	// For SemVer, we might want an RC version during release
 	if f.versioner.Scheme() == version.SchemeSemVer {
 		nextVersion = f.versioner.SetPrerelease(nextVersion, "rc.0")
 	}
+	return nextVersion, nil
+}
 
-	f.print("    New version: %s", nextVersion)
+// pushCandidateTag tags the release branch tip with the prerelease
+// version and pushes the tag. CalVer has no release candidates.
+func (f *Flow) pushCandidateTag(candidate string) error {
+	if !version.IsPrerelease(candidate, f.versioner.Scheme()) {
+		f.printAlways("    Note: %s has no release candidate version; nothing to push", f.versioner.Scheme())
+		return nil
+	}
 
-	// 5. Create release branch
-	branchName := "release/" + nextVersion
-	f.print("    Creating branch: %s", branchName)
+	tagName, err := f.repo.FormatTag(candidate)
+	if err != nil {
+		return err
+	}
+	f.step("tag", map[string]string{"tag": tagName},
+		"    Creating tag: %s", tagName)
+	if err := f.repo.CreateTag(tagName, "Release candidate "+candidate); err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
 
-	if err := f.repo.CreateBranch(branchName, f.devBranch); err != nil {
-		return fmt.Errorf("failed to create release branch: %w", err)
+	f.step("push", map[string]string{"remote": f.remote, "tag": tagName},
+		"    Pushing %s to %s", tagName, f.remote)
+	if err := f.repo.Push(f.remote, false, "refs/tags/"+tagName); err != nil {
+		return fmt.Errorf("failed to push tag: %w", err)
 	}
+	return f.publish(tagName, candidate, false, true)
+}
 
-	f.printAlways("==> Release %s started", nextVersion)
-	f.printAlways("    Branch: %s", branchName)
-	f.printAlways("")
-	f.printAlways("    Make any final changes, then run:")
-	f.printAlways("      mkrel release finish")
+// ReleaseRename changes the version of the release in progress by
+// renaming its branch (e.g., release/1.3.0-rc.0 to release/1.4.0-rc.0),
+// for when the computed version was wrong. This avoids deleting and
+// restarting the release.
+func (f *Flow) ReleaseRename(newVersion string) error {
+	f.print("==> Renaming release")
+
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	releaseBranch, err := f.findBranch(f.releasePrefix, "release", "")
+	if err != nil {
+		return err
+	}
 
+	// Accept both "1.4.0" and "release/1.4.0"
+	newVersion = strings.TrimPrefix(newVersion, f.releasePrefix)
+	if !f.versioner.IsValid(newVersion) {
+		return fmt.Errorf("invalid %s version: %s", f.versioner.Scheme(), newVersion)
+	}
+
+	newBranch, err := f.renameRelease(releaseBranch, newVersion)
+	if err != nil {
+		return err
+	}
+
+	f.done("release-rename", map[string]string{"version": newVersion, "branch": newBranch},
+		"==> Release renamed to %s", newVersion)
 	return nil
 }
 
+// ReleaseChannel moves the release in progress to a prerelease channel
+// (alpha, beta, or rc) by renaming its branch, e.g., release/1.3.0-alpha.1
+// to release/1.3.0-beta.0. Staying on the same channel increments the
+// prerelease number. Going back to an earlier channel requires force.
+func (f *Flow) ReleaseChannel(channel string, force bool) error {
+	f.print("==> Changing release channel")
+
+	semVer, ok := f.versioner.(*version.SemVer)
+	if !ok {
+		return fmt.Errorf("release channels need semver versions (scheme is %s)", f.versioner.Scheme())
+	}
+	if f.finalBranches {
+		return fmt.Errorf("release channels need prerelease branch names (release_branch_version is final)")
+	}
+
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	releaseBranch, err := f.findBranch(f.releasePrefix, "release", "")
+	if err != nil {
+		return err
+	}
+
+	newVersion, err := semVer.SetChannel(strings.TrimPrefix(releaseBranch, f.releasePrefix), channel, force)
+	if err != nil {
+		return err
+	}
+
+	newBranch, err := f.renameRelease(releaseBranch, newVersion)
+	if err != nil {
+		return err
+	}
+
+	f.done("release-channel", map[string]string{"version": newVersion, "branch": newBranch, "channel": channel},
+		"==> Release moved to %s: %s", channel, newVersion)
+	return nil
+}
+
+// renameRelease renames a release branch to newVersion, checking that the
+// version isn't taken, and returns the new branch name.
+func (f *Flow) renameRelease(releaseBranch, newVersion string) (string, error) {
+	newBranch := f.releasePrefix + newVersion
+	if newBranch == releaseBranch {
+		return "", fmt.Errorf("release is already %s", newVersion)
+	}
+	if f.repo.BranchExists(newBranch) {
+		return "", fmt.Errorf("branch %s already exists", newBranch)
+	}
+	tagName, err := f.repo.FormatTag(f.versioner.RemovePrerelease(newVersion))
+	if err != nil {
+		return "", err
+	}
+	if f.repo.TagExists(tagName) {
+		return "", fmt.Errorf("version %s is already released (tag %s exists)", newVersion, tagName)
+	}
+
+	f.step("rename-branch", map[string]string{"source": releaseBranch, "target": newBranch},
+		"    Renaming branch: %s -> %s", releaseBranch, newBranch)
+	if err := f.repo.RenameBranch(releaseBranch, newBranch); err != nil {
+		return "", fmt.Errorf("failed to rename release branch: %w", err)
+	}
+	return newBranch, nil
+}
+
+// ReleaseFinishOptions configures ReleaseFinish.
+type ReleaseFinishOptions struct {
+	Version string // Release to finish (empty = the only release in progress)
+	Force   bool   // Finish even if the release branch doesn't look based on develop
+	Strict  bool   // Fail instead of warning when commit linting finds offenders
+
+	SetUpstream bool // Push main and develop with -u if they have no upstream
+
+	Draft      bool // Publish the release as a draft
+	Prerelease bool // Publish the release marked as a prerelease
+
+	Message     string // Tag annotation, overriding the default and changelog_in_tag
+	ForceDelete bool   // Delete the release branch even if git doesn't consider it merged
+
+	AutoHotfix bool // If the version is already tagged, finish as the next hotfix version instead
+	Retag      bool // If the version is already tagged, replace the tag
+
+	// Only runs just these steps (see FinishSteps; empty = all). The
+	// checks before merging still run. Skipping steps can leave main,
+	// develop and the remote inconsistent, so it is for recovering a
+	// botched release.
+	Only []string
+
+	// SyncDevelopOnly only merges main into develop and pushes develop,
+	// for a finish that updated and tagged main but was interrupted (or
+	// done outside mkrel) before the merge back. Main, tags and the
+	// release branch are left alone.
+	SyncDevelopOnly bool
+}
+
 // ReleaseFinish completes the current release.
 // It merges to main, tags, merges to develop, and pushes.
-func (f *Flow) ReleaseFinish() error {
+func (f *Flow) ReleaseFinish(opts ReleaseFinishOptions) error {
+	if opts.SyncDevelopOnly {
+		return f.syncDevelop(opts)
+	}
 	f.print("==> Finishing release")
 
-	// 1. Find release branch
-	releases, err := f.repo.ListBranches("release/")
+	steps, err := newStepSet(opts.Only)
 	if err != nil {
-		return fmt.Errorf("failed to list release branches: %w", err)
-	}
-	if len(releases) == 0 {
-		return fmt.Errorf("no release in progress")
+		return err
 	}
-	if len(releases) > 1 {
-		return fmt.Errorf("multiple releases in progress: %v", releases)
+
+	unlock, err := f.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	releaseBranch := releases[0]
+	// 1. Find release branch
+	releaseBranch, err := f.findBranch(f.releasePrefix, "release", opts.Version)
+	if err != nil {
+		return err
+	}
 	f.print("    Release branch: %s", releaseBranch)
 
-	// Extract version from branch name (release/X.Y.Z -> X.Y.Z)
-	releaseVersion := strings.TrimPrefix(releaseBranch, "release/")
+	// Extract version from branch name (release/X.Y.Z-rc.N or
+	// release/X.Y.Z -> X.Y.Z)
+	releaseVersion := strings.TrimPrefix(releaseBranch, f.releasePrefix)
 
 	// For SemVer, remove RC suffix for final version
 	finalVersion := f.versioner.RemovePrerelease(releaseVersion)
+	if version.IsPrerelease(releaseVersion, f.versioner.Scheme()) {
+		f.print("    Promoting prerelease: %s", releaseVersion)
+	}
 	f.print("    Final version: %s", finalVersion)
 
 	// 2. Use configured main and develop branches
 	mainBranch := f.mainBranch
 	developBranch := f.devBranch
 
+	if developBranch != "" {
+		if err := f.checkReleaseBase(releaseBranch, opts.Force); err != nil {
+			return err
+		}
+	}
+	// The release still starts from develop, but only main is updated
+	skippedDevelop := ""
+	if f.releaseSkipDevelop && developBranch != "" {
+		f.print("    Not merging into %s (release_merge_develop is false)", developBranch)
+		skippedDevelop, developBranch = developBranch, ""
+	}
+
 	// 3. Checkout release branch and verify clean
-	if err := f.repo.Checkout(releaseBranch); err != nil {
+	if err := f.checkWorktrees(releaseBranch, mainBranch, developBranch); err != nil {
+		return err
+	}
+	if err := f.repo.Switch(releaseBranch); err != nil {
 		return fmt.Errorf("failed to checkout release branch: %w", err)
 	}
 
-	hasChanges, err := f.repo.HasUncommittedChanges()
+	if err := f.ensureClean("release branch"); err != nil {
+		return err
+	}
+
+	if err := f.checkCommits(releaseBranch, opts.Strict); err != nil {
+		return err
+	}
+	if err := f.checkSignatures(releaseBranch); err != nil {
+		return err
+	}
+
+	if err := f.checkPush(mainBranch, developBranch); err != nil {
+		return err
+	}
+	if err := f.checkPublish(); err != nil {
+		return err
+	}
+	if !opts.SetUpstream {
+		f.warnMissingUpstreams(mainBranch, developBranch)
+	}
+	f.noteSigning()
+	f.CheckShallow()
+	if steps != nil {
+		f.warn("    Warning: running only %s; skipped steps can leave branches, tags and %s inconsistent", steps, f.remote)
+	}
+
+	if steps.has(StepTag) {
+		if finalVersion, err = f.untaggedVersion(finalVersion, opts); err != nil {
+			return err
+		}
+	}
+	tagName, err := f.repo.FormatTag(finalVersion)
 	if err != nil {
 		return err
 	}
-	if hasChanges {
-		return fmt.Errorf("uncommitted changes in release branch")
+	// Record only the refs the selected steps change
+	var createdTag string
+	var movedTags []string
+	if steps.has(StepTag) {
+		createdTag = tagName
+		if opts.Retag && f.repo.TagExists(tagName) {
+			movedTags = append(movedTags, tagName)
+		}
+	}
+	if steps.has(StepPush) {
+		movedTags = append(movedTags, f.movingTags...)
+	}
+	var changed []string
+	if steps.has(StepVersionFile) || steps.has(StepCleanup) {
+		changed = append(changed, releaseBranch)
+	}
+	if steps.has(StepMerge) {
+		changed = append(changed, mainBranch)
+	}
+	if steps.has(StepBackMerge) {
+		changed = append(changed, developBranch)
+	}
+	if steps.has(StepCleanup) {
+		// Pruned feature branches are force-deleted, so undo recreates them
+		features, err := f.mergedFeatures()
+		if err != nil {
+			return err
+		}
+		changed = append(changed, features...)
+	}
+	if err := f.saveSnapshot("release finish", tagName, createdTag, movedTags, changed...); err != nil {
+		return err
+	}
+
+	if steps.has(StepVersionFile) {
+		if err := f.updateVersionFile(finalVersion); err != nil {
+			return err
+		}
+		if err := f.updateChangelogFile(finalVersion); err != nil {
+			return err
+		}
 	}
 
 	// 4. Merge to main
-	f.print("    Merging to %s", mainBranch)
-	if err := f.repo.Checkout(mainBranch); err != nil {
+	if err := f.repo.Switch(mainBranch); err != nil {
 		return err
 	}
-	if err := f.repo.Merge(releaseBranch, true); err != nil {
-		return fmt.Errorf("failed to merge to %s: %w", mainBranch, err)
+	if steps.has(StepMerge) {
+		f.step("merge", map[string]string{"source": releaseBranch, "target": mainBranch},
+			"    Merging to %s", mainBranch)
+		if err := f.merge(releaseBranch, mainBranch, finalVersion); err != nil {
+			return fmt.Errorf("failed to merge to %s: %w", mainBranch, err)
+		}
 	}
 
-	// 5. Create tag
-	tagName, err := f.repo.FormatTag(finalVersion)
+	// 5. Create tag on the merge commit
+	commit, err := f.repo.ResolveRef("HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", mainBranch, err)
+	}
+	f.print("    Commit: %s", commit)
+
+	if steps.has(StepTag) {
+		f.step("tag", map[string]string{"tag": tagName, "commit": commit},
+			"    Creating tag: %s", tagName)
+		if opts.Retag && f.repo.TagExists(tagName) {
+			if err := f.repo.DeleteTag(tagName); err != nil {
+				return fmt.Errorf("failed to delete tag %s: %w", tagName, err)
+			}
+		}
+		if err := f.createReleaseTag(tagName, finalVersion, "Release "+finalVersion, opts.Message); err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+	}
+
+	// 6. Merge to develop (skipped when running main-only)
+	if developBranch != "" && steps.has(StepBackMerge) {
+		f.step("merge", map[string]string{"source": mainBranch, "target": developBranch},
+			"    Merging to %s", developBranch)
+		if err := f.repo.Switch(developBranch); err != nil {
+			return err
+		}
+		if err := f.merge(mainBranch, developBranch, finalVersion); err != nil {
+			return fmt.Errorf("failed to merge to %s: %w", developBranch, err)
+		}
+	}
+
+	// 7. Push everything
+	if steps.has(StepPush) {
+		if steps.has(StepTag) {
+			if err := f.verifyTag(tagName, commit); err != nil {
+				return err
+			}
+		}
+		if err := f.confirmPush(tagName, mainBranch, developBranch); err != nil {
+			return err
+		}
+		f.step("push", map[string]string{"remote": f.remote},
+			"    Pushing to %s", f.remote)
+		if err := f.pushRelease(tagName, opts, mainBranch, developBranch); err != nil {
+			return f.pushFailed(err)
+		}
+		if err := f.moveTags(commit, finalVersion); err != nil {
+			return err
+		}
+	}
+	if steps.has(StepPublish) {
+		if err := f.publish(tagName, finalVersion, opts.Draft, opts.Prerelease); err != nil {
+			return err
+		}
+	}
+
+	if steps.has(StepCleanup) {
+		// 8. Delete release branch
+		f.step("delete-branch", map[string]string{"branch": releaseBranch},
+			"    Deleting branch: %s", releaseBranch)
+		if err := f.deleteBranch(releaseBranch, opts.ForceDelete); err != nil {
+			// Non-fatal - git may not consider the branch merged
+			f.warn("    Warning: failed to delete branch: %v (use --force-delete to delete it anyway)", err)
+		}
+
+		// 9. Delete feature branches the release has merged
+		if err := f.pruneMergedFeatures(); err != nil {
+			return err
+		}
+	}
+
+	f.done("release-finish", map[string]string{"version": finalVersion, "tag": tagName, "commit": commit},
+		"==> Released %s (%s)", finalVersion, shortSHA(commit))
+	if skippedDevelop != "" {
+		f.printAlways("    %s wasn't merged; reconcile it with %s yourself", skippedDevelop, mainBranch)
+	}
+
+	return nil
+}
+
+// pushRelease pushes a finished release: the branches (empty names are
+// skipped) and the tag. A tag replaced with --retag goes in one atomic
+// push with the branches, so a rejected branch doesn't leave the moved
+// tag on the remote; the "+" forces it, since the remote tag points at
+// the earlier release.
+func (f *Flow) pushRelease(tagName string, opts ReleaseFinishOptions, branches ...string) error {
+	if !opts.Retag {
+		return f.pushWithTag(tagName, opts.SetUpstream, branches...)
+	}
+
+	refs := nonEmpty(branches...)
+	setUpstream := opts.SetUpstream && len(f.missingUpstreams(refs...)) > 0
+	return f.repo.PushAtomic(f.remote, setUpstream, append(refs, "+refs/tags/"+tagName)...)
+}
+
+// syncDevelop merges main into develop and pushes develop, for
+// ReleaseFinishOptions.SyncDevelopOnly. It fails unless main has commits
+// develop lacks.
+func (f *Flow) syncDevelop(opts ReleaseFinishOptions) error {
+	f.print("==> Syncing develop with %s", f.mainBranch)
+
+	if flag := syncConflict(opts); flag != "" {
+		return fmt.Errorf("--sync-develop-only can't be combined with %s", flag)
+	}
+	mainBranch, developBranch := f.mainBranch, f.devBranch
+	if developBranch == "" {
+		return fmt.Errorf("no develop branch to sync with %s", mainBranch)
+	}
+
+	unlock, err := f.lock()
 	if err != nil {
 		return err
 	}
-	f.print("    Creating tag: %s", tagName)
-	if err := f.repo.CreateTag(tagName, "Release "+finalVersion); err != nil {
-		return fmt.Errorf("failed to create tag: %w", err)
+	defer unlock()
+
+	synced, err := f.repo.IsAncestor(mainBranch, developBranch)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s and %s: %w", mainBranch, developBranch, err)
+	}
+	if synced {
+		return fmt.Errorf("%s already contains %s: nothing to sync", developBranch, mainBranch)
+	}
+
+	if err := f.checkWorktrees(developBranch); err != nil {
+		return err
+	}
+	if err := f.repo.Switch(developBranch); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", developBranch, err)
+	}
+	if err := f.ensureClean("develop branch"); err != nil {
+		return err
+	}
+	if err := f.checkPush(developBranch); err != nil {
+		return err
+	}
+	if !opts.SetUpstream {
+		f.warnMissingUpstreams(developBranch)
 	}
 
-	// 6. Merge to develop
-	f.print("    Merging to %s", developBranch)
-	if err := f.repo.Checkout(developBranch); err != nil {
+	// The version of the release on main, for merge_message_template
+	ver, err := latestVersion(f.repo, f.versioner.Scheme(), mainBranch)
+	if err != nil {
+		return fmt.Errorf("failed to get the version on %s: %w", mainBranch, err)
+	}
+	if err := f.saveSnapshot("release sync", "", "", nil, developBranch); err != nil {
 		return err
 	}
-	if err := f.repo.Merge(mainBranch, true); err != nil {
+
+	f.step("merge", map[string]string{"source": mainBranch, "target": developBranch},
+		"    Merging to %s", developBranch)
+	if err := f.merge(mainBranch, developBranch, ver); err != nil {
 		return fmt.Errorf("failed to merge to %s: %w", developBranch, err)
 	}
+	commit, err := f.repo.ResolveRef("HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", developBranch, err)
+	}
 
-	// 7. Push everything
-	f.print("    Pushing to %s", f.remote)
-	if err := f.repo.PushWithTags(f.remote, mainBranch, developBranch); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	f.step("push", map[string]string{"remote": f.remote},
+		"    Pushing to %s", f.remote)
+	setUpstream := opts.SetUpstream && len(f.missingUpstreams(developBranch)) > 0
+	if err := f.repo.Push(f.remote, setUpstream, developBranch); err != nil {
+		return f.pushFailed(err)
 	}
 
-	// 8. Delete release branch
-	f.print("    Deleting branch: %s", releaseBranch)
-	if err := f.repo.DeleteBranch(releaseBranch); err != nil {
-		// Non-fatal - branch might need force delete
-		f.print("    Warning: failed to delete branch: %v", err)
+	f.done("release-sync", map[string]string{"version": ver, "branch": developBranch, "commit": commit},
+		"==> Synced %s with %s (%s)", developBranch, mainBranch, shortSHA(commit))
+	return nil
+}
+
+// syncConflict returns the flag of the first option set in opts that
+// syncDevelop has no use for, or "" if there is none. Ignoring them would
+// hide that the release wasn't finished the way they ask.
+func syncConflict(opts ReleaseFinishOptions) string {
+	for _, option := range []struct {
+		set  bool
+		flag string
+	}{
+		{opts.Version != "", "a version"},
+		{opts.Force, "--force"},
+		{opts.Draft, "--draft"},
+		{opts.Prerelease, "--prerelease"},
+		{opts.Message != "", "--message"},
+		{opts.ForceDelete, "--force-delete"},
+		{opts.AutoHotfix, "--auto-hotfix"},
+		{opts.Retag, "--retag"},
+		{len(opts.Only) > 0, "--only"},
+	} {
+		if option.set {
+			return option.flag
+		}
 	}
+	return ""
+}
 
-	f.printAlways("==> Released %s", finalVersion)
+// untaggedVersion returns the version a release finishes as. A version
+// that is already tagged usually means a second CalVer release the same
+// day: with AutoHotfix it finishes as the next hotfix version (e.g.,
+// 2024.01.15-1), with Retag the existing tag is replaced, otherwise it
+// fails before anything is merged.
+func (f *Flow) untaggedVersion(finalVersion string, opts ReleaseFinishOptions) (string, error) {
+	if !f.versionTagExists(finalVersion) {
+		return finalVersion, nil
+	}
+	tagName, err := f.repo.FormatTag(finalVersion)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case opts.AutoHotfix && opts.Retag:
+		return "", fmt.Errorf("--auto-hotfix and --retag can't be used together")
+	case opts.AutoHotfix:
+		next, err := f.nextHotfix(f.versioner, nil)
+		if err != nil {
+			return "", err
+		}
+		f.printAlways("    Version %s is already released; finishing as %s", finalVersion, next)
+		return next, nil
+	case opts.Retag:
+		f.warn("    Warning: replacing tag %s and force-pushing it to %s", tagName, f.remote)
+		return finalVersion, nil
+	default:
+		return "", fmt.Errorf("version %s is already released (tag %s exists); use --auto-hotfix to finish as a hotfix or --retag to replace the tag",
+			finalVersion, tagName)
+	}
+}
+
+// checkReleaseBase verifies that a release branch was started from
+// develop. A branch created from main (or an old commit) by hand would
+// silently leave develop's unreleased work out of the release.
+//
+// The branch is considered based on develop if it contains develop, or if
+// its fork point from develop has work not yet released to main.
+func (f *Flow) checkReleaseBase(releaseBranch string, force bool) error {
+	contains, err := f.repo.IsAncestor(f.devBranch, releaseBranch)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s with %s: %w", releaseBranch, f.devBranch, err)
+	}
+	if contains {
+		return nil
+	}
+
+	forkPoint, err := f.repo.MergeBase(releaseBranch, f.devBranch)
+	if err != nil {
+		return fmt.Errorf("%s shares no history with %s", releaseBranch, f.devBranch)
+	}
+	released, err := f.repo.IsAncestor(forkPoint, f.mainBranch)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s with %s: %w", releaseBranch, f.mainBranch, err)
+	}
+	if !released {
+		return nil
+	}
 
+	msg := fmt.Sprintf("%s doesn't appear to be based on %s (it diverged at %s, which is already on %s)",
+		releaseBranch, f.devBranch, shortSHA(forkPoint), f.mainBranch)
+	if !force {
+		return fmt.Errorf("%s; use --force to finish anyway", msg)
+	}
+	f.warn("    Warning: %s", msg)
 	return nil
 }
@@ -0,0 +1,1640 @@
+package flow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/changelog"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestReleaseStart_DryRun(t *testing.T) {
+	dir := newTestRepo(t)
+	var out bytes.Buffer
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		DryRun:     true,
+		Output:     &out,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	releases, err := f.repo.ListBranches("release/")
+	if err != nil {
+		t.Fatalf("ListBranches() error = %v", err)
+	}
+	if len(releases) != 0 {
+		t.Errorf("ReleaseStart() in dry run created branches: %v", releases)
+	}
+
+	want := "==> Dry run: release 0.1.0-rc.0 would be started\n" +
+		"    Base branch: develop\n" +
+		"    New branch:  release/0.1.0-rc.0\n" +
+		"    Version:     0.1.0-rc.0\n" +
+		"    No changes were made.\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("ReleaseStart() printed:\n%s\nwant it to end with the summary:\n%s", out.String(), want)
+	}
+}
+
+func TestRelease_WithoutDevelop(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "branch", "-D", "develop")
+
+	if _, err := New(Options{WorkDir: dir, Scheme: version.SchemeSemVer}); err == nil {
+		t.Fatal("New() expected error without develop branch")
+	}
+
+	f, err := New(Options{
+		WorkDir:         dir,
+		Scheme:          version.SchemeSemVer,
+		MainBranch:      "main",
+		DevBranch:       "develop",
+		DevelopOptional: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	runGit(t, dir, "commit", "--allow-empty", "-m", "release fix")
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	if got := runGit(t, dir, "branch", "--contains", "v0.1.0", "--list", "main"); got == "" {
+		t.Error("release tag v0.1.0 not reachable from main")
+	}
+	if f.repo.BranchExists("develop") {
+		t.Error("ReleaseFinish() created a develop branch")
+	}
+}
+
+func TestReleaseStart_AutoCreateDevelop(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "branch", "-D", "develop")
+	runGit(t, dir, "push", "-q", "origin", "--delete", "develop")
+	mainCommit := runGit(t, dir, "rev-parse", "main")
+
+	f, err := New(Options{
+		WorkDir:           dir,
+		Scheme:            version.SchemeSemVer,
+		MainBranch:        "main",
+		DevBranch:         "develop",
+		AutoCreateDevelop: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	if got := runGit(t, dir, "rev-parse", "develop"); got != mainCommit {
+		t.Errorf("develop = %s, want it created from main (%s)", got, mainCommit)
+	}
+	if got := runGit(t, dir, "ls-remote", "--heads", "origin", "develop"); got == "" {
+		t.Error("ReleaseStart() did not push develop")
+	}
+	if got := runGit(t, dir, "merge-base", "release/0.1.0-rc.0", "develop"); got != mainCommit {
+		t.Errorf("release branch not based on develop")
+	}
+}
+
+func TestCreateDevelop(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "branch", "-D", "develop")
+	runGit(t, dir, "push", "-q", "origin", "--delete", "develop")
+	mainCommit := runGit(t, dir, "rev-parse", "main")
+
+	f, err := New(Options{
+		WorkDir:           dir,
+		Scheme:            version.SchemeSemVer,
+		MainBranch:        "main",
+		DevBranch:         "develop",
+		AutoCreateDevelop: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if created, err := f.CreateDevelop(); err != nil || created != "develop" {
+		t.Fatalf("CreateDevelop() = %q, %v; want develop", created, err)
+	}
+	if got := runGit(t, dir, "rev-parse", "develop"); got != mainCommit {
+		t.Errorf("develop = %s, want it created from main (%s)", got, mainCommit)
+	}
+	if got := runGit(t, dir, "ls-remote", "--heads", "origin", "develop"); got == "" {
+		t.Error("CreateDevelop() did not push develop")
+	}
+	if created, err := f.CreateDevelop(); err != nil || created != "" {
+		t.Errorf("CreateDevelop() again = %q, %v; want nothing to create", created, err)
+	}
+}
+
+func TestReleaseStart_Events(t *testing.T) {
+	dir := newTestRepo(t)
+
+	var events []Event
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		OnEvent:    func(e Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	var created, done *Event
+	for i := range events {
+		switch {
+		case events[i].Type == EventStepStart && events[i].Step == "create-branch":
+			created = &events[i]
+		case events[i].Type == EventStepDone:
+			done = &events[i]
+		}
+	}
+
+	if created == nil {
+		t.Fatal("no create-branch step event emitted")
+	}
+	if created.Fields["branch"] != "release/0.1.0-rc.0" || created.Fields["base"] != "develop" {
+		t.Errorf("create-branch fields = %v", created.Fields)
+	}
+
+	if done == nil {
+		t.Fatal("no step done event emitted")
+	}
+	if done.Fields["version"] != "0.1.0-rc.0" {
+		t.Errorf("done version = %q, want %q", done.Fields["version"], "0.1.0-rc.0")
+	}
+	if done.Message != "Release 0.1.0-rc.0 started" {
+		t.Errorf("done message = %q, want %q", done.Message, "Release 0.1.0-rc.0 started")
+	}
+}
+
+func TestReleaseStart_Push(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  version.Scheme
+		wantTag string // Tag expected on the remote ("" = none)
+	}{
+		{name: "semver", scheme: version.SchemeSemVer, wantTag: "v0.1.0-rc.0"},
+		{name: "calver", scheme: version.SchemeCalVer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			f := newTestFlow(t, dir, tt.scheme)
+
+			if err := f.ReleaseStart(ReleaseStartOptions{Push: true}); err != nil {
+				t.Fatalf("ReleaseStart() error = %v", err)
+			}
+
+			remoteTags := runGit(t, dir, "ls-remote", "--tags", "origin")
+			if tt.wantTag == "" {
+				if remoteTags != "" {
+					t.Errorf("remote tags = %q, want none", remoteTags)
+				}
+				return
+			}
+			if !strings.Contains(remoteTags, "refs/tags/"+tt.wantTag) {
+				t.Errorf("remote tags = %q, want %s", remoteTags, tt.wantTag)
+			}
+			if got := runGit(t, dir, "rev-parse", tt.wantTag+"^{commit}"); got != runGit(t, dir, "rev-parse", "HEAD") {
+				t.Errorf("tag %s not on release branch tip", tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestReleaseFinish_ChangelogInTag(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:        dir,
+		Scheme:         version.SchemeSemVer,
+		MainBranch:     "main",
+		DevBranch:      "develop",
+		ChangelogInTag: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{Push: true}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix(widgets): off-by-one")
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	// Notes span the whole release, not just the changes since the RC tag
+	message := runGit(t, dir, "tag", "-l", "--format=%(contents)", "v0.1.0")
+	for _, want := range []string{"## 0.1.0", "### Features", "- add widgets", "### Bug Fixes", "- **widgets:** off-by-one"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("tag message missing %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestReleaseFinish_NotesFile(t *testing.T) {
+	dir := newTestRepo(t)
+	notesFile := filepath.Join(t.TempDir(), "dist", "notes.md")
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		NotesFile:  notesFile,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if next, err := f.NextRelease(); err != nil || next != "0.1.0-rc.0" {
+		t.Fatalf("NextRelease() = %q, %v, want 0.1.0-rc.0", next, err)
+	}
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	data, err := os.ReadFile(notesFile)
+	if err != nil {
+		t.Fatalf("notes file not written: %v", err)
+	}
+	if notes := string(data); !strings.Contains(notes, "## 0.1.0") || !strings.Contains(notes, "- add widgets") {
+		t.Errorf("notes file = %q, want the 0.1.0 release notes", notes)
+	}
+	// Without changelog_in_tag the annotation stays the default message
+	if message := runGit(t, dir, "tag", "-l", "--format=%(contents)", "v0.1.0"); message != "Release 0.1.0" {
+		t.Errorf("tag message = %q, want %q", message, "Release 0.1.0")
+	}
+
+	if current, err := f.CurrentVersion(); err != nil || current != "0.1.0" {
+		t.Errorf("CurrentVersion() = %q, %v, want 0.1.0", current, err)
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "version.txt")
+
+	if err := WriteOutput(path, "1.2.0"); err != nil {
+		t.Fatalf("WriteOutput() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "1.2.0\n" {
+		t.Errorf("file = %q, want %q", data, "1.2.0\n")
+	}
+}
+
+func TestReleaseFinish_GitmojiChangelog(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:        dir,
+		Scheme:         version.SchemeSemVer,
+		MainBranch:     "main",
+		DevBranch:      "develop",
+		ChangelogInTag: true,
+		ChangelogStyle: changelog.StyleGitmoji,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "✨ add widgets")
+	runGit(t, dir, "commit", "--allow-empty", "-m", ":bug: off-by-one")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	message := runGit(t, dir, "tag", "-l", "--format=%(contents)", "v0.1.0")
+	for _, want := range []string{"### Features", "- add widgets", "### Bug Fixes", "- off-by-one"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("tag message missing %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestReleaseFinish_PruneFeatures(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "checkout", "-q", "-b", "feature/merged", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: merged work")
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge feature/merged", "feature/merged")
+	runGit(t, dir, "checkout", "-q", "-b", "feature/open", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: unfinished work")
+
+	f, err := New(Options{
+		WorkDir:       dir,
+		Scheme:        version.SchemeSemVer,
+		MainBranch:    "main",
+		DevBranch:     "develop",
+		PruneFeatures: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	if f.repo.BranchExists("feature/merged") {
+		t.Error("ReleaseFinish() kept merged feature branch")
+	}
+	if !f.repo.BranchExists("feature/open") {
+		t.Error("ReleaseFinish() deleted unmerged feature branch")
+	}
+
+	// Undo brings the pruned branch back
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if !f.repo.BranchExists("feature/merged") {
+		t.Error("Undo() did not recreate the pruned feature branch")
+	}
+}
+
+func TestReleases_Prerelease(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	runGit(t, dir, "tag", "-a", "v1.1.0-rc.0", "-m", "Release 1.1.0-rc.0")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	releases, err := f.Releases(0)
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "1.1.0-rc.0" {
+		t.Fatalf("Releases() = %+v, want the two releases, highest first", releases)
+	}
+	if !releases[0].Prerelease || releases[1].Prerelease {
+		t.Errorf("Prerelease = %v, %v; want only 1.1.0-rc.0 marked", releases[0].Prerelease, releases[1].Prerelease)
+	}
+}
+
+func TestRelease_Namespace(t *testing.T) {
+	dir := newTestRepo(t)
+	// Another tool's tag in the same repository must not affect versions
+	runGit(t, dir, "tag", "-a", "v5.0.0", "-m", "Release 5.0.0")
+
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		Namespace:  "mytool",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, want := range []string{"0.1.0", "0.2.0"} {
+		runGit(t, dir, "checkout", "-q", "develop")
+		runGit(t, dir, "commit", "--allow-empty", "-m", "feat: release "+want)
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+		if branch := "mytool/release/" + want + "-rc.0"; !f.repo.BranchExists(branch) {
+			t.Fatalf("ReleaseStart() did not create %s", branch)
+		}
+
+		if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+		if tag := "mytool-" + want; !f.repo.TagExists(tag) {
+			t.Fatalf("ReleaseFinish() did not create tag %s", tag)
+		}
+	}
+
+	releases, err := f.Releases(0)
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 2 || releases[0].Version != "0.2.0" {
+		t.Errorf("Releases() = %+v, want only the two namespaced releases, highest first", releases)
+	}
+	for _, r := range releases {
+		if r.Subject != "Release "+r.Version {
+			t.Errorf("Subject = %q, want the tag message", r.Subject)
+		}
+	}
+}
+
+func TestReleaseFinish_WrongBase(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "unreleased feature")
+	// Created by hand from main instead of develop
+	runGit(t, dir, "checkout", "-q", "-b", "release/0.1.0", "main")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	err := f.ReleaseFinish(ReleaseFinishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "based on develop") {
+		t.Fatalf("ReleaseFinish() error = %v, want a wrong-base error", err)
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Fatal("ReleaseFinish() tagged a release from the wrong base")
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Force: true}); err != nil {
+		t.Fatalf("ReleaseFinish(Force) error = %v", err)
+	}
+	if !f.repo.TagExists("v0.1.0") {
+		t.Error("ReleaseFinish(Force) did not create tag v0.1.0")
+	}
+}
+
+func TestReleaseFinish_ValidatePush(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:      dir,
+		Scheme:       version.SchemeSemVer,
+		MainBranch:   "main",
+		DevBranch:    "develop",
+		ValidatePush: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	// Someone else pushes to main in the meantime
+	origin := runGit(t, dir, "remote", "get-url", "origin")
+	other := t.TempDir()
+	runGit(t, other, "clone", "-q", origin, ".")
+	runGit(t, other, "-c", "user.name=Other", "-c", "user.email=other@example.com",
+		"commit", "--allow-empty", "-m", "concurrent change")
+	runGit(t, other, "push", "-q", "origin", "main")
+
+	err = f.ReleaseFinish(ReleaseFinishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "would be rejected") {
+		t.Fatalf("ReleaseFinish() error = %v, want a rejected push error", err)
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Error("ReleaseFinish() created a tag despite the push check failing")
+	}
+	if got := runGit(t, dir, "rev-list", "--count", "main"); got != "1" {
+		t.Errorf("main has %s commits, want it left unmerged", got)
+	}
+}
+
+func TestReleaseFinish_SetUpstream(t *testing.T) {
+	dir := newTestRepo(t)
+	// develop tracks something unusual, which --set-upstream must keep
+	runGit(t, dir, "branch", "-u", "origin/main", "develop")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{SetUpstream: true}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	if got, _ := f.repo.Upstream("main"); got != "origin/main" {
+		t.Errorf("upstream of main = %q, want %q", got, "origin/main")
+	}
+	if got, _ := f.repo.Upstream("develop"); got != "origin/main" {
+		t.Errorf("upstream of develop = %q, want it unchanged (origin/main)", got)
+	}
+	if got := runGit(t, dir, "ls-remote", "--tags", "origin", "v0.1.0"); got == "" {
+		t.Error("ReleaseFinish() did not push tag v0.1.0")
+	}
+}
+
+func TestReleaseFinish_OtherWorktree(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	// main is in use elsewhere, so finishing must stop before merging
+	runGit(t, dir, "checkout", "-q", "develop")
+	other := filepath.Join(t.TempDir(), "main")
+	runGit(t, dir, "worktree", "add", "-q", other, "main")
+
+	err := f.ReleaseFinish(ReleaseFinishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "main is checked out in another worktree") {
+		t.Fatalf("ReleaseFinish() error = %v, want a worktree conflict", err)
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Error("ReleaseFinish() created a tag despite the conflict")
+	}
+}
+
+func TestReleaseFinish_MovingTags(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "latest", "-m", "old", "main")
+	runGit(t, dir, "push", "-q", "origin", "latest")
+	old := runGit(t, dir, "rev-parse", "latest")
+
+	var warnings []string
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		MovingTags: []string{"latest"},
+		OnEvent: func(e Event) {
+			if e.Type == EventWarning {
+				warnings = append(warnings, e.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	release := runGit(t, dir, "rev-parse", "v0.1.0^{commit}")
+	if got := runGit(t, dir, "rev-parse", "latest^{commit}"); got != release {
+		t.Errorf("latest points at %s, want the release commit %s", got, release)
+	}
+	remote := runGit(t, dir, "ls-remote", "origin", "refs/tags/latest")
+	if local := runGit(t, dir, "rev-parse", "latest"); !strings.HasPrefix(remote, local) {
+		t.Errorf("origin has latest = %q, want it force-pushed to %s", remote, local)
+	}
+
+	var rewrote bool
+	for _, w := range warnings {
+		rewrote = rewrote || strings.Contains(w, "rewriting tag latest")
+	}
+	if !rewrote {
+		t.Errorf("warnings = %q, want one about rewriting latest", warnings)
+	}
+
+	// Undo puts the moving tag back, with its message
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "latest"); got != old {
+		t.Errorf("latest after undo = %s, want the old tag %s", got, old)
+	}
+}
+
+func TestReleaseStart_Resume(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	runGit(t, dir, "checkout", "-q", "develop")
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err == nil {
+		t.Fatal("ReleaseStart() expected error with a release in progress")
+	}
+
+	var resumed Event
+	f.onEvent = func(e Event) {
+		if e.Type == EventStepDone {
+			resumed = e
+		}
+	}
+	if err := f.ReleaseStart(ReleaseStartOptions{Resume: true}); err != nil {
+		t.Fatalf("ReleaseStart(Resume) error = %v", err)
+	}
+	if got := runGit(t, dir, "branch", "--show-current"); got != "release/0.1.0-rc.0" {
+		t.Errorf("current branch = %q, want the release in progress", got)
+	}
+	if resumed.Step != "release-resume" || resumed.Fields["version"] != "0.1.0-rc.0" {
+		t.Errorf("done event = %+v, want release-resume for 0.1.0-rc.0", resumed)
+	}
+
+	// The resumed release finishes as usual
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+}
+
+func TestReleasePR(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	pr, err := f.ReleasePR("")
+	if err != nil {
+		t.Fatalf("ReleasePR() error = %v", err)
+	}
+	if pr.Title != "Release 0.1.0" || pr.Head != "release/0.1.0-rc.0" || pr.Base != "main" {
+		t.Errorf("ReleasePR() = %+v", pr)
+	}
+	if !strings.Contains(pr.Body, "- add widgets") {
+		t.Errorf("body missing the release notes:\n%s", pr.Body)
+	}
+	if pr.RemoteURL != runGit(t, dir, "remote", "get-url", "origin") {
+		t.Errorf("RemoteURL = %q", pr.RemoteURL)
+	}
+
+	// The branch is pushed and tracks the remote; nothing is merged or tagged
+	if got := runGit(t, dir, "rev-parse", "--abbrev-ref", "release/0.1.0-rc.0@{upstream}"); got != "origin/release/0.1.0-rc.0" {
+		t.Errorf("upstream = %q", got)
+	}
+	if tags := runGit(t, dir, "tag", "--list"); tags != "" {
+		t.Errorf("tags = %q, want none", tags)
+	}
+
+	// Once the pull request is merged, the follow-up finish tags main
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge pull request", "release/0.1.0-rc.0")
+	merged := runGit(t, dir, "rev-parse", "HEAD")
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Version: pr.Version, Only: pr.FinishSteps}); err != nil {
+		t.Fatalf("ReleaseFinish(%s --only %v) error = %v", pr.Version, pr.FinishSteps, err)
+	}
+	if got := runGit(t, dir, "rev-parse", "v0.1.0^{commit}"); got != merged {
+		t.Errorf("v0.1.0 = %s, want the merge commit %s", got, merged)
+	}
+	if f.repo.BranchExists("release/0.1.0-rc.0") {
+		t.Error("the follow-up finish kept the release branch")
+	}
+}
+
+func TestRelease_LintCommits(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v0.1.0", "-m", "Release 0.1.0", "main")
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "wip stuff")
+
+	var warnings []string
+	f, err := New(Options{
+		WorkDir:     dir,
+		Scheme:      version.SchemeSemVer,
+		MainBranch:  "main",
+		DevBranch:   "develop",
+		LintCommits: true,
+		OnEvent: func(e Event) {
+			if e.Type == EventWarning {
+				warnings = append(warnings, e.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = f.ReleaseStart(ReleaseStartOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "wip stuff") || strings.Contains(err.Error(), "add widgets") {
+		t.Fatalf("ReleaseStart(Strict) error = %v, want only the non-conventional commit listed", err)
+	}
+	if f.repo.BranchExists("release/0.2.0-rc.0") {
+		t.Fatal("ReleaseStart(Strict) created a release branch")
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "1 commit(s) since v0.1.0") {
+		t.Errorf("warnings = %q, want one listing the non-conventional commit", warnings)
+	}
+}
+
+func TestRelease_FinalBranchNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		final      bool
+		wantBranch string
+	}{
+		{"prerelease", false, "release/0.1.0-rc.0"},
+		{"final", true, "release/0.1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			f, err := New(Options{
+				WorkDir:          dir,
+				Scheme:           version.SchemeSemVer,
+				MainBranch:       "main",
+				DevBranch:        "develop",
+				FinalBranchNames: tt.final,
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+				t.Fatalf("ReleaseStart() error = %v", err)
+			}
+			if !f.repo.BranchExists(tt.wantBranch) {
+				t.Fatalf("ReleaseStart() did not create %s", tt.wantBranch)
+			}
+
+			if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+				t.Fatalf("ReleaseFinish() error = %v", err)
+			}
+			if !f.repo.TagExists("v0.1.0") {
+				t.Error("ReleaseFinish() did not create tag v0.1.0")
+			}
+		})
+	}
+}
+
+func TestReleaseRename(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	for _, bad := range []string{"not-a-version", "0.1.0-rc.0"} {
+		if err := f.ReleaseRename(bad); err == nil {
+			t.Errorf("ReleaseRename(%q) expected error", bad)
+		}
+	}
+
+	if err := f.ReleaseRename("release/1.0.0-rc.0"); err != nil {
+		t.Fatalf("ReleaseRename() error = %v", err)
+	}
+	if f.repo.BranchExists("release/0.1.0-rc.0") || !f.repo.BranchExists("release/1.0.0-rc.0") {
+		t.Fatal("ReleaseRename() did not rename the release branch")
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+	if !f.repo.TagExists("v1.0.0") {
+		t.Error("ReleaseFinish() did not tag the renamed version")
+	}
+}
+
+func TestReleaseStart_IgnoresNonVersionTags(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.2.0", "-m", "Release 1.2.0", "main")
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	runGit(t, dir, "tag", "nightly")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if !f.repo.BranchExists("release/1.3.0-rc.0") {
+		t.Error("ReleaseStart() did not compute the version from v1.2.0")
+	}
+}
+
+func TestTagNotes(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: first feature")
+	for i := 0; i < 2; i++ {
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+		if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+		runGit(t, dir, "commit", "--allow-empty", "-m", "fix: second fix")
+	}
+
+	notes, err := f.TagNotes("v0.2.0")
+	if err != nil {
+		t.Fatalf("TagNotes() error = %v", err)
+	}
+	if notes.Version != "0.2.0" || notes.Previous != "v0.1.0" {
+		t.Errorf("TagNotes() = %+v, want 0.2.0 since v0.1.0", notes)
+	}
+	for _, s := range notes.Sections {
+		for _, e := range s.Entries {
+			if e.Description == "first feature" {
+				t.Errorf("TagNotes(v0.2.0) includes a change released in v0.1.0")
+			}
+		}
+	}
+
+	first, err := f.TagNotes("v0.1.0")
+	if err != nil {
+		t.Fatalf("TagNotes() error = %v", err)
+	}
+	if root := runGit(t, dir, "rev-list", "--max-parents=0", "HEAD"); first.Since != root {
+		t.Errorf("TagNotes(v0.1.0).Since = %q, want the first commit %q", first.Since, root)
+	}
+	if first.Previous != "" || len(first.Sections) == 0 || first.Sections[0].Entries[0].Description != "first feature" {
+		t.Errorf("TagNotes(v0.1.0) = %+v, want the first feature", first)
+	}
+
+	if _, err := f.TagNotes("v9.9.9"); err == nil {
+		t.Error("TagNotes() expected error for a missing tag")
+	}
+}
+
+func TestReleaseChannel(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	steps := []struct {
+		channel string
+		force   bool
+		want    string // Release branch afterwards; empty if the change fails
+	}{
+		{"alpha", false, ""}, // rc -> alpha goes backwards
+		{"alpha", true, "release/0.1.0-alpha.0"},
+		{"alpha", false, "release/0.1.0-alpha.1"},
+		{"beta", false, "release/0.1.0-beta.0"},
+		{"rc", false, "release/0.1.0-rc.0"},
+	}
+	branch := "release/0.1.0-rc.0"
+	for _, step := range steps {
+		err := f.ReleaseChannel(step.channel, step.force)
+		if step.want == "" {
+			if err == nil {
+				t.Errorf("ReleaseChannel(%s) expected error from %s", step.channel, branch)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ReleaseChannel(%s) error = %v", step.channel, err)
+		}
+		if !f.repo.BranchExists(step.want) || f.repo.BranchExists(branch) {
+			t.Fatalf("ReleaseChannel(%s) from %s, want branch %s", step.channel, branch, step.want)
+		}
+		branch = step.want
+	}
+
+	calver := newTestFlow(t, newTestRepo(t), version.SchemeCalVer)
+	if err := calver.ReleaseChannel("beta", false); err == nil {
+		t.Error("ReleaseChannel() expected error for calver")
+	}
+}
+
+// fakePublisher records the releases it is asked to publish.
+type fakePublisher struct {
+	checkErr  error
+	published []Publication
+}
+
+func (p *fakePublisher) Check(remoteURL string) error { return p.checkErr }
+
+func (p *fakePublisher) Publish(pub Publication) (string, error) {
+	p.published = append(p.published, pub)
+	pub.Step("upload-asset", map[string]string{"tag": pub.Tag, "path": "dist/app"}, "    Uploading: dist/app")
+	return "https://example.com/releases/" + pub.Tag, nil
+}
+
+func TestRelease_Publish(t *testing.T) {
+	dir := newTestRepo(t)
+	publisher := &fakePublisher{}
+	var uploads []string
+	f, err := New(Options{
+		WorkDir:       dir,
+		Scheme:        version.SchemeSemVer,
+		MainBranch:    "main",
+		DevBranch:     "develop",
+		Publisher:     publisher,
+		DraftReleases: true,
+		OnEvent: func(e Event) {
+			if e.Step == "upload-asset" {
+				uploads = append(uploads, e.Fields["tag"])
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{Push: true}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	if len(publisher.published) != 2 {
+		t.Fatalf("published = %+v, want the candidate and the release", publisher.published)
+	}
+	candidate, release := publisher.published[0], publisher.published[1]
+	if candidate.Tag != "v0.1.0-rc.0" || !candidate.Prerelease || !candidate.Draft {
+		t.Errorf("candidate = %+v, want a draft prerelease of v0.1.0-rc.0", candidate)
+	}
+	if release.Tag != "v0.1.0" || release.Prerelease || !release.Draft {
+		t.Errorf("release = %+v, want a draft of v0.1.0", release)
+	}
+	if !strings.Contains(release.Notes, "- add widgets") {
+		t.Errorf("notes missing the changes:\n%s", release.Notes)
+	}
+	if release.RemoteURL != runGit(t, dir, "remote", "get-url", "origin") {
+		t.Errorf("RemoteURL = %q", release.RemoteURL)
+	}
+	// The publisher's progress arrives as events of the flow
+	if want := []string{"v0.1.0-rc.0", "v0.1.0"}; !reflect.DeepEqual(uploads, want) {
+		t.Errorf("upload-asset events = %q, want %q", uploads, want)
+	}
+
+	// A publisher that can't publish stops the finish before merging
+	publisher.checkErr = errors.New("no token")
+	if err := f.ReleaseStart(ReleaseStartOptions{AllowEmpty: true}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	mainBefore := runGit(t, dir, "rev-parse", "main")
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err == nil || !strings.Contains(err.Error(), "no token") {
+		t.Errorf("ReleaseFinish() error = %v, want the check error", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != mainBefore {
+		t.Error("ReleaseFinish() merged despite the failed check")
+	}
+}
+
+func TestReleaseStart_NoChanges(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	// develop only has the merge back from main
+	err := f.ReleaseStart(ReleaseStartOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no changes on develop since v0.1.0") {
+		t.Fatalf("ReleaseStart() error = %v, want no changes since v0.1.0", err)
+	}
+	if f.repo.BranchExists("release/0.2.0-rc.0") {
+		t.Error("ReleaseStart() created a release branch with no changes")
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{AllowEmpty: true}); err != nil {
+		t.Fatalf("ReleaseStart(AllowEmpty) error = %v", err)
+	}
+	if !f.repo.BranchExists("release/0.2.0-rc.0") {
+		t.Error("ReleaseStart(AllowEmpty) did not create the release branch")
+	}
+}
+
+func TestReleaseStart_NoChangesAfterCandidate(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	// A pushed candidate, then abandoned: its tag is nearer than v0.1.0
+	if err := f.ReleaseStart(ReleaseStartOptions{Push: true, AllowEmpty: true}); err != nil {
+		t.Fatalf("ReleaseStart(Push) error = %v", err)
+	}
+	if !f.repo.TagExists("v0.2.0-rc.0") {
+		t.Fatal("ReleaseStart(Push) did not tag the candidate")
+	}
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "branch", "-D", "release/0.2.0-rc.0")
+
+	err := f.ReleaseStart(ReleaseStartOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no changes on develop since v0.1.0") {
+		t.Fatalf("ReleaseStart() error = %v, want no changes since v0.1.0", err)
+	}
+}
+
+func TestReleaseFinish_Message(t *testing.T) {
+	for _, changelogInTag := range []bool{false, true} {
+		dir := newTestRepo(t)
+		f, err := New(Options{
+			WorkDir:        dir,
+			Scheme:         version.SchemeSemVer,
+			MainBranch:     "main",
+			DevBranch:      "develop",
+			ChangelogInTag: changelogInTag,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		runGit(t, dir, "checkout", "-q", "develop")
+		runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+		if err := f.ReleaseFinish(ReleaseFinishOptions{Message: "Widgets release\n\nShips widgets."}); err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+
+		// -m wins over both the default message and changelog_in_tag
+		if got := runGit(t, dir, "tag", "-l", "--format=%(contents)", "v0.1.0"); got != "Widgets release\n\nShips widgets." {
+			t.Errorf("changelog_in_tag=%v: tag message = %q, want the -m message", changelogInTag, got)
+		}
+	}
+}
+
+func TestReleaseFinish_ForceDelete(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		dir := newTestRepo(t)
+		f := newTestFlow(t, dir, version.SchemeSemVer)
+
+		// The release branch tracks a remote branch that lacks its last
+		// commit, so "git branch -d" refuses to delete it
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+		if _, err := f.ReleasePR(""); err != nil {
+			t.Fatalf("ReleasePR() error = %v", err)
+		}
+		runGit(t, dir, "commit", "--allow-empty", "-m", "fix: last-minute fix")
+
+		if err := f.ReleaseFinish(ReleaseFinishOptions{ForceDelete: force}); err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+		if got := f.repo.BranchExists("release/0.1.0-rc.0"); got == force {
+			t.Errorf("ForceDelete=%v: release branch exists = %v", force, got)
+		}
+	}
+}
+
+func TestReleaseFinish_VerifySignatures(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:          dir,
+		Scheme:           version.SchemeSemVer,
+		MainBranch:       "main",
+		DevBranch:        "develop",
+		VerifySignatures: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	mainBefore := runGit(t, dir, "rev-parse", "main")
+
+	err = f.ReleaseFinish(ReleaseFinishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "feat: add widgets (unsigned)") {
+		t.Fatalf("ReleaseFinish() error = %v, want the unsigned commit listed", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != mainBefore {
+		t.Error("ReleaseFinish() merged despite unsigned commits")
+	}
+}
+
+func TestReleaseStart_Confirm(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	runGit(t, dir, "tag", "v1.2.0", "main")
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+
+	var asked []string
+	answer := func(v string, ok bool) func(string, string) (string, bool) {
+		return func(current, next string) (string, bool) {
+			asked = append(asked, current+" -> "+next)
+			return v, ok
+		}
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{Confirm: answer("", false)}); !errors.Is(err, ErrStartCanceled) {
+		t.Fatalf("ReleaseStart() error = %v, want ErrStartCanceled", err)
+	}
+	if len(asked) != 1 || asked[0] != "1.2.0 -> 1.3.0-rc.0" {
+		t.Errorf("asked %q, want 1.2.0 -> 1.3.0-rc.0", asked)
+	}
+	if f.repo.BranchExists("release/1.3.0-rc.0") {
+		t.Error("ReleaseStart() created the branch despite being declined")
+	}
+
+	for _, v := range []string{"latest", "1.2.0"} {
+		if err := f.ReleaseStart(ReleaseStartOptions{Confirm: answer(v, true)}); err == nil {
+			t.Errorf("ReleaseStart() with %s succeeded, want an error", v)
+		}
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{Confirm: answer("2.0.0-rc.0", true)}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if !f.repo.BranchExists("release/2.0.0-rc.0") {
+		t.Error("ReleaseStart() didn't start the typed version")
+	}
+}
+
+func TestReleaseFinish_SameDay(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ReleaseFinishOptions
+		wantErr bool
+		hotfix  bool // Second release expected as today's first hotfix
+	}{
+		{name: "refuse", wantErr: true},
+		{name: "auto-hotfix", opts: ReleaseFinishOptions{AutoHotfix: true}, hotfix: true},
+		{name: "retag", opts: ReleaseFinishOptions{Retag: true}},
+		{name: "both", opts: ReleaseFinishOptions{AutoHotfix: true, Retag: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			f := newTestFlow(t, dir, version.SchemeCalVer)
+			today, err := f.versioner.Next("", version.BumpMinor)
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+
+			for i, msg := range []string{"feat: add widgets", "fix: widget size"} {
+				runGit(t, dir, "checkout", "-q", "develop")
+				runGit(t, dir, "commit", "--allow-empty", "-m", msg)
+				if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+					t.Fatalf("ReleaseStart() #%d error = %v", i+1, err)
+				}
+				if i == 0 {
+					if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+						t.Fatalf("ReleaseFinish() #1 error = %v", err)
+					}
+				}
+			}
+			mainBefore := runGit(t, dir, "rev-parse", "main")
+
+			err = f.ReleaseFinish(tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ReleaseFinish() #2 expected error")
+				}
+				if got := runGit(t, dir, "rev-parse", "main"); got != mainBefore {
+					t.Error("ReleaseFinish() #2 merged despite failing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReleaseFinish() #2 error = %v", err)
+			}
+
+			main := runGit(t, dir, "rev-parse", "main")
+			firstTag, _ := f.repo.FormatTag(today)
+			wantTag := firstTag
+			if tt.hotfix {
+				wantTag, _ = f.repo.FormatTag(today + "-1")
+				if got := runGit(t, dir, "rev-parse", firstTag+"^{commit}"); got != mainBefore {
+					t.Errorf("tag %s moved, want it left on the first release", firstTag)
+				}
+			}
+			if got := runGit(t, dir, "rev-parse", wantTag+"^{commit}"); got != main {
+				t.Errorf("tag %s not on main", wantTag)
+			}
+			if remote := runGit(t, dir, "ls-remote", "origin", "refs/tags/"+wantTag+"^{}"); !strings.HasPrefix(remote, main) {
+				t.Errorf("remote tag %s = %q, want %s", wantTag, remote, main)
+			}
+		})
+	}
+}
+
+func TestReleaseFinish_Only(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{"merge", "deploy"}}); err == nil {
+		t.Fatal("ReleaseFinish() expected error for an unknown step")
+	}
+
+	developBefore := runGit(t, dir, "rev-parse", "develop")
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{StepMerge}}); err != nil {
+		t.Fatalf("ReleaseFinish(merge) error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main^2"); got != runGit(t, dir, "rev-parse", "release/0.1.0-rc.0") {
+		t.Error("ReleaseFinish(merge) didn't merge the release into main")
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Error("ReleaseFinish(merge) created the tag")
+	}
+	if got := runGit(t, dir, "rev-parse", "develop"); got != developBefore {
+		t.Error("ReleaseFinish(merge) merged into develop")
+	}
+
+	// Recover: tag and push what was merged
+	main := runGit(t, dir, "rev-parse", "main")
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{StepTag, StepPush}}); err != nil {
+		t.Fatalf("ReleaseFinish(tag, push) error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != main {
+		t.Error("ReleaseFinish(tag, push) merged again")
+	}
+	if got := runGit(t, dir, "rev-parse", "v0.1.0^{commit}"); got != main {
+		t.Error("tag v0.1.0 not on main")
+	}
+	if remote := runGit(t, dir, "ls-remote", "origin", "refs/tags/v0.1.0"); remote == "" {
+		t.Error("tag v0.1.0 not pushed")
+	}
+	if !f.repo.BranchExists("release/0.1.0-rc.0") {
+		t.Error("ReleaseFinish(tag, push) deleted the release branch")
+	}
+}
+
+func TestReleaseFinish_SyncDevelopOnly(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{SyncDevelopOnly: true}); err == nil ||
+		!strings.Contains(err.Error(), "nothing to sync") {
+		t.Errorf("ReleaseFinish(sync) error = %v, want nothing to sync", err)
+	}
+
+	// Interrupted finish: main merged, tagged and pushed, develop not
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{StepMerge, StepTag, StepPush}}); err != nil {
+		t.Fatalf("ReleaseFinish(merge, tag, push) error = %v", err)
+	}
+	main := runGit(t, dir, "rev-parse", "main")
+	tag := runGit(t, dir, "rev-parse", "v0.1.0")
+
+	for flag, opts := range map[string]ReleaseFinishOptions{
+		"--only":  {SyncDevelopOnly: true, Only: []string{StepPush}},
+		"--retag": {SyncDevelopOnly: true, Retag: true},
+		"--draft": {SyncDevelopOnly: true, Draft: true},
+	} {
+		if err := f.ReleaseFinish(opts); err == nil || !strings.Contains(err.Error(), "combined with "+flag) {
+			t.Errorf("ReleaseFinish(sync, %s) error = %v, want it rejected", flag, err)
+		}
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{SyncDevelopOnly: true}); err != nil {
+		t.Fatalf("ReleaseFinish(sync) error = %v", err)
+	}
+
+	if got := runGit(t, dir, "rev-parse", "develop^2"); got != main {
+		t.Error("ReleaseFinish(sync) didn't merge main into develop")
+	}
+	if got := runGit(t, dir, "rev-parse", "origin/develop"); got != runGit(t, dir, "rev-parse", "develop") {
+		t.Error("ReleaseFinish(sync) didn't push develop")
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != main {
+		t.Error("ReleaseFinish(sync) changed main")
+	}
+	if got := runGit(t, dir, "rev-parse", "v0.1.0"); got != tag {
+		t.Error("ReleaseFinish(sync) changed the tag")
+	}
+	if !f.repo.BranchExists("release/0.1.0-rc.0") {
+		t.Error("ReleaseFinish(sync) deleted the release branch")
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{SyncDevelopOnly: true}); err == nil {
+		t.Error("ReleaseFinish(sync) expected error once develop contains main")
+	}
+}
+
+func TestReleaseFinish_ConfirmMainPush(t *testing.T) {
+	for _, accept := range []bool{true, false} {
+		dir := newTestRepo(t)
+		var asked []string
+		f, err := New(Options{
+			WorkDir:    dir,
+			Scheme:     version.SchemeSemVer,
+			MainBranch: "main",
+			DevBranch:  "develop",
+			ConfirmMainPush: func(summary string) bool {
+				asked = append(asked, summary)
+				return accept
+			},
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		runGit(t, dir, "checkout", "-q", "develop")
+		runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+
+		err = f.ReleaseFinish(ReleaseFinishOptions{})
+		if accept && err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+		if !accept && !errors.Is(err, ErrMainPushCanceled) {
+			t.Fatalf("ReleaseFinish() error = %v, want ErrMainPushCanceled", err)
+		}
+		if want := "Push to origin: main, develop, tag v0.1.0"; len(asked) != 1 || asked[0] != want {
+			t.Errorf("asked %q, want once %q", asked, want)
+		}
+		if !f.repo.TagExists("v0.1.0") {
+			t.Error("ReleaseFinish() didn't tag locally")
+		}
+		if pushed := runGit(t, dir, "ls-remote", "origin", "refs/tags/v0.1.0") != ""; pushed != accept {
+			t.Errorf("accept %v: tag pushed = %v", accept, pushed)
+		}
+	}
+}
+
+func TestVerifyTag(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	main := runGit(t, dir, "rev-parse", "main")
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0", "main")
+
+	if err := f.verifyTag("v1.0.0", main); err != nil {
+		t.Errorf("verifyTag() error = %v", err)
+	}
+
+	// HEAD moved between resolving the merge commit and tagging
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "chore: sneaked in")
+	runGit(t, dir, "tag", "-a", "v1.0.1", "-m", "Hotfix 1.0.1")
+	if err := f.verifyTag("v1.0.1", main); err == nil || !strings.Contains(err.Error(), "doesn't point at") {
+		t.Errorf("verifyTag() error = %v, want a mismatch", err)
+	}
+
+	if err := f.verifyTag("v2.0.0", main); err == nil {
+		t.Error("verifyTag() expected error for a missing tag")
+	}
+}
+
+func TestReleaseFinish_Messages(t *testing.T) {
+	dir := newTestRepo(t)
+	var out bytes.Buffer
+	f, err := New(Options{
+		WorkDir:    dir,
+		Scheme:     version.SchemeSemVer,
+		MainBranch: "main",
+		DevBranch:  "develop",
+		Output:     &out,
+		Messages: map[string]string{
+			"release-start":  "",
+			"release-finish": "Shipped {{.version}} as {{.tag}} ({{short .commit}})",
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if strings.Contains(out.String(), "started") {
+		t.Errorf("output = %q, want the start message suppressed", out.String())
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	want := fmt.Sprintf("Shipped 0.1.0 as v0.1.0 (%s)\n", runGit(t, dir, "rev-parse", "--short=7", "v0.1.0^{commit}"))
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("output = %q, want it to end with %q", out.String(), want)
+	}
+}
+
+func TestNew_InvalidMessages(t *testing.T) {
+	dir := newTestRepo(t)
+	for _, messages := range []map[string]string{
+		{"release-done": "Shipped"},
+		{"release-finish": "Shipped {{.version"},
+	} {
+		if _, err := New(Options{WorkDir: dir, Scheme: version.SchemeSemVer, Messages: messages}); err == nil {
+			t.Errorf("New() with %v expected error", messages)
+		}
+	}
+}
+
+func TestFinish_MergeDevelop(t *testing.T) {
+	// Each finish returns its tag and develop as pushed before it
+	finishRelease := func(t *testing.T, f *Flow, dir string) (string, string) {
+		runGit(t, dir, "checkout", "-q", "develop")
+		runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+		runGit(t, dir, "push", "-q", "origin", "develop")
+		developBefore := runGit(t, dir, "rev-parse", "develop")
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() error = %v", err)
+		}
+		if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+			t.Fatalf("ReleaseFinish() error = %v", err)
+		}
+		return "v0.1.0", developBefore
+	}
+	finishHotfix := func(t *testing.T, f *Flow, dir string) (string, string) {
+		runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0", "main")
+		developBefore := runGit(t, dir, "rev-parse", "develop")
+		if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+			t.Fatalf("HotfixStart() error = %v", err)
+		}
+		runGit(t, dir, "commit", "--allow-empty", "-m", "fix: crash")
+		if err := f.HotfixFinish(HotfixFinishOptions{}); err != nil {
+			t.Fatalf("HotfixFinish() error = %v", err)
+		}
+		return "v1.0.1", developBefore
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		finish      func(t *testing.T, f *Flow, dir string) (string, string)
+		wantDevelop bool // develop expected to contain the release
+	}{
+		{name: "release", finish: finishRelease, wantDevelop: true},
+		{name: "release main only", opts: Options{ReleaseSkipDevelop: true}, finish: finishRelease},
+		{name: "hotfix", finish: finishHotfix, wantDevelop: true},
+		{name: "hotfix main only", opts: Options{HotfixSkipDevelop: true}, finish: finishHotfix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newTestRepo(t)
+			runGit(t, dir, "push", "-q", "origin", "main", "develop")
+			opts := tt.opts
+			opts.WorkDir = dir
+			opts.Scheme = version.SchemeSemVer
+			opts.MainBranch = "main"
+			opts.DevBranch = "develop"
+			f, err := New(opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			tag, developBefore := tt.finish(t, f, dir)
+
+			tagged := runGit(t, dir, "rev-parse", tag+"^{commit}")
+			if got := runGit(t, dir, "rev-parse", "origin/main"); got != tagged {
+				t.Errorf("origin/main = %s, want the merge %s", got, tagged)
+			}
+			merged, err := f.repo.IsAncestor(tag, "develop")
+			if err != nil {
+				t.Fatalf("IsAncestor() error = %v", err)
+			}
+			if merged != tt.wantDevelop {
+				t.Errorf("%s merged into develop = %v, want %v", tag, merged, tt.wantDevelop)
+			}
+			if pushed := runGit(t, dir, "rev-parse", "origin/develop") != developBefore; pushed != tt.wantDevelop {
+				t.Errorf("develop pushed = %v, want %v", pushed, tt.wantDevelop)
+			}
+		})
+	}
+}
+
+func TestReleaseFinish_MergeMessage(t *testing.T) {
+	dir := newTestRepo(t)
+	f, err := New(Options{
+		WorkDir:      dir,
+		Scheme:       version.SchemeSemVer,
+		MainBranch:   "main",
+		DevBranch:    "develop",
+		MergeMessage: "Merge {{source}} into {{target}} for {{version}}",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	for branch, want := range map[string]string{
+		"main":    "Merge release/0.1.0-rc.0 into main for 0.1.0",
+		"develop": "Merge main into develop for 0.1.0",
+	} {
+		if got := runGit(t, dir, "log", "-1", "--format=%s", branch); got != want {
+			t.Errorf("merge message on %s = %q, want %q", branch, got, want)
+		}
+	}
+}
+
+func TestRenderMergeMessage(t *testing.T) {
+	if got := renderMergeMessage("", "release/1.3.0", "main", "1.3.0"); got != "" {
+		t.Errorf("renderMergeMessage() = %q, want empty for no template", got)
+	}
+	got := renderMergeMessage("{{version}}: {{source}} -> {{target}} ({{other}})", "hotfix/1.2.1", "main", "1.2.1")
+	if want := "1.2.1: hotfix/1.2.1 -> main ({{other}})"; got != want {
+		t.Errorf("renderMergeMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestReleaseFinish_ChangelogFile(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "push", "-q", "origin", "main", "develop")
+	f, err := New(Options{
+		WorkDir:       dir,
+		Scheme:        version.SchemeSemVer,
+		MainBranch:    "main",
+		DevBranch:     "develop",
+		ChangelogFile: "CHANGELOG.md",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add widgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	// Created with a title on the first release
+	got := runGit(t, dir, "show", "v0.1.0:CHANGELOG.md")
+	if !strings.HasPrefix(got, "# Changelog\n\n## 0.1.0 (") || !strings.Contains(got, "- add widgets") {
+		t.Errorf("CHANGELOG.md at v0.1.0 =\n%s\nwant a title and the 0.1.0 notes", got)
+	}
+	if develop := runGit(t, dir, "show", "develop:CHANGELOG.md"); develop != got {
+		t.Errorf("CHANGELOG.md on develop =\n%s\nwant the one released", develop)
+	}
+
+	// The next release goes above it
+	runGit(t, dir, "commit", "--allow-empty", "-m", "feat: add gadgets")
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+	got = runGit(t, dir, "show", "v0.2.0:CHANGELOG.md")
+	if i, j := strings.Index(got, "## 0.2.0"), strings.Index(got, "## 0.1.0"); !strings.HasPrefix(got, "# Changelog\n\n## 0.2.0 (") || j < i {
+		t.Errorf("CHANGELOG.md at v0.2.0 =\n%s\nwant 0.2.0 above 0.1.0", got)
+	}
+
+	// The changelog commits of the finishes aren't changes themselves
+	notes, err := f.TagNotes("v0.2.0")
+	if err != nil {
+		t.Fatalf("TagNotes() error = %v", err)
+	}
+	for _, section := range notes.Sections {
+		for _, e := range section.Entries {
+			if strings.Contains(e.Description, "CHANGELOG.md") {
+				t.Errorf("notes of v0.2.0 list %q, want no changelog commits", e.Description)
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package flow
+
+// HasRemote reports whether the configured remote exists.
+func (f *Flow) HasRemote() bool {
+	_, err := f.repo.RemoteURL(f.remote)
+	return err == nil
+}
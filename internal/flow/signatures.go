@@ -0,0 +1,60 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// signatureProblems describes the %G? statuses that aren't a good signature.
+var signatureProblems = map[string]string{
+	"N": "unsigned",
+	"B": "bad signature",
+	"X": "expired signature",
+	"Y": "signed with an expired key",
+	"R": "signed with a revoked key",
+	"E": "signature can't be checked (missing key?)",
+}
+
+// checkSignatures verifies, when verify_signatures is enabled, that every
+// commit on ref since the previous release has a good signature, so only
+// commits from known keys are released. Offenders fail the command.
+func (f *Flow) checkSignatures(ref string) error {
+	if !f.verifySignatures {
+		return nil
+	}
+
+	previous, err := f.previousReleaseTag(ref)
+	if err != nil {
+		return err
+	}
+	commits, err := f.repo.LogSignatures(previous, ref)
+	if err != nil {
+		return fmt.Errorf("failed to verify commit signatures: %w", err)
+	}
+
+	var offenders []string
+	for _, c := range commits {
+		if c.Signed() {
+			continue
+		}
+		problem, ok := signatureProblems[c.Signature]
+		if !ok {
+			problem = "signature status " + c.Signature
+		}
+		offenders = append(offenders, fmt.Sprintf("%s %s (%s)", shortSHA(c.Hash), c.Subject, problem))
+	}
+	if len(offenders) == 0 {
+		f.print("    Commit signatures: %d verified", len(commits))
+		return nil
+	}
+
+	since, err := f.rangeStart(previous, ref)
+	if err != nil {
+		return err
+	}
+	if since != previous {
+		since = "the first commit (" + shortSHA(since) + ")"
+	}
+	return fmt.Errorf("%d commit(s) since %s don't have a good signature:\n      %s\nsign them, or set verify_signatures: false",
+		len(offenders), since, strings.Join(offenders, "\n      "))
+}
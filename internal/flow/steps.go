@@ -0,0 +1,60 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Release finish steps, in the order they run. ReleaseFinishOptions.Only
+// selects a subset of them.
+const (
+	StepVersionFile = "version-file" // Commit the new version to version_file_path and changelog_file
+	StepMerge       = "merge"        // Merge the release branch into main
+	StepTag         = "tag"          // Tag main
+	StepBackMerge   = "back-merge"   // Merge main into develop
+	StepPush        = "push"         // Push main, develop, the tag and moving tags
+	StepPublish     = "publish"      // Publish the GitHub release
+	StepCleanup     = "cleanup"      // Delete the release branch and merged features
+)
+
+// FinishSteps lists the release finish steps in order.
+var FinishSteps = []string{
+	StepVersionFile, StepMerge, StepTag, StepBackMerge, StepPush, StepPublish, StepCleanup,
+}
+
+// stepSet is the set of steps a finish runs. A nil set runs them all.
+type stepSet map[string]bool
+
+// newStepSet validates the steps selected with --only. No steps selects
+// them all.
+func newStepSet(only []string) (stepSet, error) {
+	if len(only) == 0 {
+		return nil, nil
+	}
+	steps := stepSet{}
+	for _, step := range only {
+		step = strings.TrimSpace(step)
+		if !slices.Contains(FinishSteps, step) {
+			return nil, fmt.Errorf("unknown finish step %q (valid: %s)", step, strings.Join(FinishSteps, ", "))
+		}
+		steps[step] = true
+	}
+	return steps, nil
+}
+
+// has reports whether the finish runs step.
+func (s stepSet) has(step string) bool {
+	return s == nil || s[step]
+}
+
+// String lists the selected steps in the order they run.
+func (s stepSet) String() string {
+	var names []string
+	for _, step := range FinishSteps {
+		if s.has(step) {
+			names = append(names, step)
+		}
+	}
+	return strings.Join(names, ", ")
+}
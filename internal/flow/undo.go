@@ -0,0 +1,160 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kloudlabs-io/mkrel/internal/git"
+)
+
+// saveSnapshot records the branches a finish is about to change, the
+// tag it creates, the existing tags it moves, and the hotfix base of any
+// hotfix branch among the branches, for Undo. A snapshot of the same
+// operation on the same release (e.g., an earlier "release finish
+// --only" run) is added to rather than replaced.
+// Dry runs change nothing, so they don't replace the snapshot.
+func (f *Flow) saveSnapshot(operation, release, tag string, movedTags []string, branches ...string) error {
+	if f.dryRun {
+		return nil
+	}
+
+	snapshot, err := f.repo.TakeSnapshot(operation, tag, branches...)
+	if err != nil {
+		return fmt.Errorf("failed to record undo snapshot: %w", err)
+	}
+	snapshot.Release = release
+	if err := f.repo.RecordTags(snapshot, movedTags...); err != nil {
+		return fmt.Errorf("failed to record undo snapshot: %w", err)
+	}
+	for branch := range snapshot.Branches {
+		base, err := f.repo.BranchConfig(branch, hotfixBaseKey)
+		if err != nil {
+			return err
+		}
+		if base == "" {
+			continue
+		}
+		if snapshot.BranchConfig == nil {
+			snapshot.BranchConfig = make(map[string]map[string]string)
+		}
+		snapshot.BranchConfig[branch] = map[string]string{hotfixBaseKey: base}
+	}
+
+	path, err := f.repo.SnapshotPath()
+	if err != nil {
+		return err
+	}
+	if release != "" {
+		previous, err := git.LoadSnapshot(path)
+		if err == nil && previous.Operation == operation && previous.Release == release {
+			previous.Merge(snapshot)
+			snapshot = previous
+		}
+	}
+	return git.SaveSnapshot(path, snapshot)
+}
+
+// UndoOptions configures Undo.
+type UndoOptions struct {
+	// Confirm is asked, with a summary of the resets, before anything is
+	// changed; returning false cancels the undo. Nil proceeds without asking.
+	Confirm func(summary string) bool
+}
+
+// ErrUndoCanceled is returned by Undo when Confirm declines.
+var ErrUndoCanceled = errors.New("undo canceled")
+
+// Undo reverts the last release or hotfix finish locally: the affected
+// branches are reset to where they were before it (the finished branch
+// is recreated) and its tag is deleted. Pushes are not undone.
+func (f *Flow) Undo(opts UndoOptions) error {
+	path, err := f.repo.SnapshotPath()
+	if err != nil {
+		return err
+	}
+	snapshot, err := git.LoadSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	f.print("==> Undoing %s (%s)", snapshot.Operation, snapshot.Created.Format("2006-01-02 15:04:05"))
+
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := f.ensureClean("working directory"); err != nil {
+		return err
+	}
+
+	if opts.Confirm != nil && !f.dryRun && !opts.Confirm(undoSummary(snapshot)) {
+		return ErrUndoCanceled
+	}
+
+	for branch, sha := range snapshot.Branches {
+		f.step("reset-branch", map[string]string{"branch": branch, "commit": sha},
+			"    Resetting %s to %s", branch, shortSHA(sha))
+	}
+	if snapshot.Tag != "" {
+		f.step("delete-tag", map[string]string{"tag": snapshot.Tag},
+			"    Deleting tag: %s", snapshot.Tag)
+	}
+	for _, tag := range sortedKeys(snapshot.Tags) {
+		if object := snapshot.Tags[tag]; object != "" {
+			f.step("restore-tag", map[string]string{"tag": tag, "object": object},
+				"    Restoring tag: %s (%s)", tag, shortSHA(object))
+		} else {
+			f.step("delete-tag", map[string]string{"tag": tag},
+				"    Deleting tag: %s", tag)
+		}
+	}
+	if err := f.repo.RestoreSnapshot(snapshot); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	if f.dryRun {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove undo snapshot: %w", err)
+	}
+
+	f.done("undo", map[string]string{"operation": snapshot.Operation, "tag": snapshot.Tag},
+		"==> Undid %s", snapshot.Operation)
+	f.warn("    Note: undo only changes local refs. If the %s was pushed, the remote still has it.", snapshot.Operation)
+	return nil
+}
+
+// undoSummary describes what undoing snapshot changes, one line per ref.
+func undoSummary(snapshot *git.Snapshot) string {
+	lines := []string{fmt.Sprintf("Undo %s from %s:", snapshot.Operation, snapshot.Created.Format("2006-01-02 15:04:05"))}
+	for _, branch := range sortedKeys(snapshot.Branches) {
+		lines = append(lines, fmt.Sprintf("  reset %s to %s", branch, shortSHA(snapshot.Branches[branch])))
+	}
+	if snapshot.Tag != "" {
+		lines = append(lines, "  delete tag "+snapshot.Tag)
+	}
+	for _, tag := range sortedKeys(snapshot.Tags) {
+		if object := snapshot.Tags[tag]; object != "" {
+			lines = append(lines, fmt.Sprintf("  restore tag %s to %s", tag, shortSHA(object)))
+		} else {
+			lines = append(lines, "  delete tag "+tag)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package flow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/git"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestUndo_ReleaseFinish(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.Undo(UndoOptions{}); !errors.Is(err, git.ErrNoSnapshot) {
+		t.Fatalf("Undo() error = %v, want ErrNoSnapshot", err)
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix: last-minute fix")
+	before := map[string]string{}
+	for _, branch := range []string{"main", "develop", "release/0.1.0-rc.0"} {
+		before[branch] = runGit(t, dir, "rev-parse", branch)
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+	if !f.repo.TagExists("v0.1.0") {
+		t.Fatal("ReleaseFinish() did not create tag v0.1.0")
+	}
+
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	for branch, sha := range before {
+		if got := runGit(t, dir, "rev-parse", branch); got != sha {
+			t.Errorf("%s = %s after undo, want %s", branch, got, sha)
+		}
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Error("Undo() did not delete tag v0.1.0")
+	}
+
+	// The snapshot is consumed
+	if err := f.Undo(UndoOptions{}); !errors.Is(err, git.ErrNoSnapshot) {
+		t.Errorf("second Undo() error = %v, want ErrNoSnapshot", err)
+	}
+}
+
+func TestUndo_ReleaseFinishOnly(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix: last-minute fix")
+	before := map[string]string{}
+	for _, branch := range []string{"main", "develop", "release/0.1.0-rc.0"} {
+		before[branch] = runGit(t, dir, "rev-parse", branch)
+	}
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{StepMerge}}); err != nil {
+		t.Fatalf("ReleaseFinish(merge) error = %v", err)
+	}
+	path, err := f.repo.SnapshotPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := git.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if len(snapshot.Branches) != 1 || snapshot.Branches["main"] != before["main"] || snapshot.Tag != "" {
+		t.Errorf("snapshot = %+v, want only main", snapshot)
+	}
+
+	// The second part adds to the snapshot instead of replacing it
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Only: []string{StepTag, StepBackMerge}}); err != nil {
+		t.Fatalf("ReleaseFinish(tag, back-merge) error = %v", err)
+	}
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	for branch, sha := range before {
+		if got := runGit(t, dir, "rev-parse", branch); got != sha {
+			t.Errorf("%s = %s after undo, want %s", branch, got, sha)
+		}
+	}
+	if f.repo.TagExists("v0.1.0") {
+		t.Error("Undo() did not delete tag v0.1.0")
+	}
+}
+
+func TestUndo_KeepsExistingTag(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	if err := f.HotfixStart(HotfixStartOptions{}); err != nil {
+		t.Fatalf("HotfixStart() error = %v", err)
+	}
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix: crash")
+
+	// Someone tagged the hotfix version meanwhile: the finish merges, then
+	// fails to tag
+	runGit(t, dir, "tag", "-a", "v1.0.1", "-m", "Hotfix 1.0.1", "main")
+	tag := runGit(t, dir, "rev-parse", "v1.0.1")
+	main := runGit(t, dir, "rev-parse", "main")
+	if err := f.HotfixFinish(HotfixFinishOptions{}); err == nil {
+		t.Fatal("HotfixFinish() expected error for an existing tag")
+	}
+
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != main {
+		t.Errorf("main = %s after undo, want %s", got, main)
+	}
+	if !f.repo.TagExists("v1.0.1") || runGit(t, dir, "rev-parse", "v1.0.1") != tag {
+		t.Error("Undo() deleted or moved tag v1.0.1, which the finish didn't create")
+	}
+}
+
+func TestUndo_Retag(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeCalVer)
+	for i, msg := range []string{"feat: add widgets", "fix: widget size"} {
+		runGit(t, dir, "checkout", "-q", "develop")
+		runGit(t, dir, "commit", "--allow-empty", "-m", msg)
+		if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+			t.Fatalf("ReleaseStart() #%d error = %v", i+1, err)
+		}
+		if i == 0 {
+			if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+				t.Fatalf("ReleaseFinish() #1 error = %v", err)
+			}
+		}
+	}
+	today, _ := f.versioner.Next("", version.BumpMinor)
+	tag, _ := f.repo.FormatTag(today)
+	object := runGit(t, dir, "rev-parse", tag)
+
+	// Someone else pushed to main meanwhile, so the push is rejected
+	runGit(t, dir, "checkout", "-q", "-b", "elsewhere", "main")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix: elsewhere")
+	runGit(t, dir, "push", "-q", "origin", "elsewhere:main")
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "branch", "-D", "elsewhere")
+
+	if err := f.ReleaseFinish(ReleaseFinishOptions{Retag: true}); err == nil {
+		t.Fatal("ReleaseFinish() expected the push to main to be rejected")
+	}
+	if remote := runGit(t, dir, "ls-remote", "origin", "refs/tags/"+tag); !strings.HasPrefix(remote, object) {
+		t.Errorf("remote tag %s = %q, want it left at %s", tag, remote, object)
+	}
+
+	if err := f.Undo(UndoOptions{}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", tag); got != object {
+		t.Errorf("tag %s = %s after undo, want the original %s", tag, got, object)
+	}
+}
+
+func TestUndo_Confirm(t *testing.T) {
+	dir := newTestRepo(t)
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	mainBefore := runGit(t, dir, "rev-parse", "main")
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+	mainAfter := runGit(t, dir, "rev-parse", "main")
+
+	var summary string
+	decline := func(s string) bool {
+		summary = s
+		return false
+	}
+	if err := f.Undo(UndoOptions{Confirm: decline}); !errors.Is(err, ErrUndoCanceled) {
+		t.Fatalf("Undo() error = %v, want ErrUndoCanceled", err)
+	}
+	for _, line := range []string{"  reset main to " + mainBefore[:7], "  delete tag v0.1.0"} {
+		if !strings.Contains(summary, line) {
+			t.Errorf("summary missing %q:\n%s", line, summary)
+		}
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != mainAfter {
+		t.Error("Undo() reset main despite being declined")
+	}
+
+	// The snapshot is kept, so the undo can still be confirmed
+	if err := f.Undo(UndoOptions{Confirm: func(string) bool { return true }}); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "main"); got != mainBefore {
+		t.Errorf("main = %s after undo, want %s", got, mainBefore)
+	}
+}
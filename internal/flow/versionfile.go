@@ -0,0 +1,89 @@
+package flow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kloudlabs-io/mkrel/internal/git"
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+// releaseCommitPrefix starts the subject of the commits finishes make
+// to record a release in files (the version file, the changelog).
+const releaseCommitPrefix = "chore(release): "
+
+// currentVersion returns the current version on ref (empty = HEAD): the
+// contents of versionFile if one is set, otherwise the latest version tag.
+// A missing or empty version file means there is no release yet.
+func currentVersion(repo *git.Repository, scheme version.Scheme, versionFile, ref string) (string, error) {
+	if versionFile == "" {
+		return latestVersion(repo, scheme, ref)
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	content, ok, err := repo.FileAt(ref, versionFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", versionFile, err)
+	}
+	if !ok || content == "" {
+		return "", nil
+	}
+
+	v := strings.TrimPrefix(content, "v")
+	if err := version.Validate(v, scheme); err != nil {
+		return "", fmt.Errorf("%s: %w", versionFile, err)
+	}
+	return v, nil
+}
+
+// updateVersionFile writes ver to the version file, when the version is
+// read from one, and commits it on the current branch. The file is the
+// source of truth, so finishes update it before merging.
+func (f *Flow) updateVersionFile(ver string) error {
+	if f.versionFile == "" {
+		return nil
+	}
+
+	f.step("update-version-file", map[string]string{"path": f.versionFile, "version": ver},
+		"    Setting %s to %s", f.versionFile, ver)
+	if f.dryRun {
+		return nil
+	}
+
+	root, err := f.repo.Root()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, f.versionFile)
+
+	// Nothing to commit if it was already bumped by hand
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) == ver {
+		f.printAlways("    %s is already at %s, nothing to commit", f.versionFile, ver)
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(ver+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.versionFile, err)
+	}
+	if err := f.repo.Add(path); err != nil {
+		return err
+	}
+	// git refuses an empty commit, and the staged file can still match
+	// HEAD (e.g., with a clean filter or line-ending conversion)
+	staged, err := f.repo.HasStagedChanges()
+	if err != nil {
+		return err
+	}
+	if !staged {
+		f.printAlways("    %s is already at %s, nothing to commit", f.versionFile, ver)
+		return nil
+	}
+	if err := f.repo.Commit(releaseCommitPrefix + "set version to " + ver); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", f.versionFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kloudlabs-io/mkrel/internal/version"
+)
+
+func TestRelease_VersionFile(t *testing.T) {
+	dir := newTestRepo(t)
+
+	newFlow := func() *Flow {
+		f, err := New(Options{
+			WorkDir:     dir,
+			Scheme:      version.SchemeSemVer,
+			MainBranch:  "main",
+			DevBranch:   "develop",
+			VersionFile: "VERSION",
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return f
+	}
+
+	// No file yet: no release
+	if got, err := newFlow().CurrentVersion(); err != nil || got != "" {
+		t.Fatalf("CurrentVersion() = %q, %v, want no version", got, err)
+	}
+
+	runGit(t, dir, "checkout", "-q", "develop")
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "VERSION")
+	runGit(t, dir, "commit", "-q", "-m", "chore: add VERSION")
+
+	f := newFlow()
+	if got, err := f.CurrentVersion(); err != nil || got != "1.2.0" {
+		t.Fatalf("CurrentVersion() = %q, %v, want %q from the file", got, err, "1.2.0")
+	}
+
+	if err := f.ReleaseStart(ReleaseStartOptions{}); err != nil {
+		t.Fatalf("ReleaseStart() error = %v", err)
+	}
+	if !f.repo.BranchExists("release/1.3.0-rc.0") {
+		t.Fatal("ReleaseStart() did not compute the version from the file")
+	}
+	if err := f.ReleaseFinish(ReleaseFinishOptions{}); err != nil {
+		t.Fatalf("ReleaseFinish() error = %v", err)
+	}
+
+	for _, ref := range []string{"v1.3.0", "main", "develop"} {
+		if got := runGit(t, dir, "show", ref+":VERSION"); got != "1.3.0" {
+			t.Errorf("VERSION on %s = %q, want %q", ref, got, "1.3.0")
+		}
+	}
+}
+
+func TestCurrentVersion_InvalidFile(t *testing.T) {
+	dir := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("next\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "VERSION")
+	runGit(t, dir, "commit", "-q", "-m", "chore: add VERSION")
+
+	f := newTestFlow(t, dir, version.SchemeSemVer)
+	if _, err := currentVersion(f.repo, version.SchemeSemVer, "VERSION", ""); err == nil {
+		t.Error("currentVersion() expected error for an invalid version file")
+	}
+}
+
+func TestUpdateVersionFile_NothingStaged(t *testing.T) {
+	dir := newTestRepo(t)
+	path := filepath.Join(dir, "VERSION")
+	if err := os.WriteFile(path, []byte("1.3.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "VERSION")
+	runGit(t, dir, "commit", "-q", "-m", "chore: add VERSION")
+
+	f, err := New(Options{
+		WorkDir:     dir,
+		Scheme:      version.SchemeSemVer,
+		MainBranch:  "main",
+		DevBranch:   "develop",
+		VersionFile: "VERSION",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	// The write puts back what HEAD already has, so nothing is staged
+	if err := os.WriteFile(path, []byte("1.2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.updateVersionFile("1.3.0"); err != nil {
+		t.Fatalf("updateVersionFile() error = %v", err)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("updateVersionFile() committed with nothing staged")
+	}
+}
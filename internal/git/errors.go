@@ -0,0 +1,41 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrProtectedBranch reports a push the remote rejected because the branch
+// is protected, e.g., it only accepts changes through pull requests.
+var ErrProtectedBranch = errors.New("branch is protected on the remote")
+
+// ErrCherryPickConflict reports a cherry-pick that stopped because a
+// commit didn't apply cleanly. The cherry-pick is left in progress, to
+// resolve and continue or abort.
+var ErrCherryPickConflict = errors.New("cherry-pick stopped on a conflict")
+
+// protectedBranchMarkers are substrings (lowercase) of the messages hosts
+// send when rejecting a push to a protected branch.
+var protectedBranchMarkers = []string{
+	"gh006",            // GitHub: "Protected branch update failed"
+	"gh013",            // GitHub: "Repository rule violations found"
+	"protected branch", // GitLab: "not allowed to push code to protected branches"
+	"can only be modified through pull request", // Bitbucket
+	"tf402455", // Azure DevOps: "Pushes to this branch are not permitted"
+}
+
+// classifyPushError wraps a push error with ErrProtectedBranch if the
+// remote's message says the branch is protected.
+func classifyPushError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range protectedBranchMarkers {
+		if strings.Contains(msg, marker) {
+			return fmt.Errorf("%w: %w", ErrProtectedBranch, err)
+		}
+	}
+	return err
+}
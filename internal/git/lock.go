@@ -0,0 +1,183 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFile is the name of the lock file inside the git directory.
+const LockFile = "mkrel.lock"
+
+// staleLockAge is how old a lock may get before it's considered abandoned,
+// even if a process with its PID still appears to be running (PIDs get reused).
+const staleLockAge = time.Hour
+
+// lockWriteGrace is how long an unreadable lock is taken as held, in
+// case another mkrel is still writing it.
+const lockWriteGrace = 5 * time.Second
+
+// ErrLocked is returned when another mkrel operation holds the lock.
+var ErrLocked = errors.New("another mkrel operation is in progress")
+
+// Lock is an advisory lock preventing concurrent mkrel runs in a repository.
+type Lock struct {
+	path string
+}
+
+// lockInfo is written to the lock file to allow stale-lock detection.
+type lockInfo struct {
+	PID     int       `json:"pid"`
+	Created time.Time `json:"created"`
+}
+
+// AcquireLock creates the lock file at path.
+// A stale lock (dead process or older than an hour) is replaced.
+func AcquireLock(path string) (*Lock, error) {
+	err := createLockFile(path)
+	if errors.Is(err, os.ErrExist) {
+		if err := replaceStaleLock(path); err != nil {
+			return nil, err
+		}
+		err = createLockFile(path)
+	}
+	if errors.Is(err, os.ErrExist) {
+		// Lost a race with another process replacing the same stale lock
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	return &Lock{path: path}, nil
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// ForceUnlock removes the lock file at path regardless of who holds it.
+func ForceUnlock(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// LockPath returns the path of the mkrel lock file for this repository.
+func (r *Repository) LockPath() (string, error) {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, LockFile), nil
+}
+
+// Lock acquires the mkrel lock for this repository.
+func (r *Repository) Lock() (*Lock, error) {
+	path, err := r.LockPath()
+	if err != nil {
+		return nil, err
+	}
+	return AcquireLock(path)
+}
+
+// createLockFile atomically creates the lock file, failing if it exists.
+// The owner information is written to a temporary file first and then
+// linked into place, so the lock is never seen without it.
+func createLockFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	data, err := json.Marshal(lockInfo{PID: os.Getpid(), Created: time.Now()})
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+// replaceStaleLock removes the lock at path if its owner is gone, and
+// returns ErrLocked if it is still held. The lock is renamed aside and
+// checked again before it's removed, so a fresh lock another process put
+// in place of the stale one in the meantime is restored rather than lost.
+func replaceStaleLock(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if held, err := lockHeld(path, data, err); held {
+		return err
+	}
+
+	// A unique name, so concurrent runs don't move locks onto each other
+	placeholder, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.stale")
+	if err != nil {
+		return fmt.Errorf("failed to remove stale lock: %w", err)
+	}
+	aside := placeholder.Name()
+	placeholder.Close()
+	if err := os.Rename(path, aside); err != nil {
+		os.Remove(aside)
+		if errors.Is(err, os.ErrNotExist) {
+			// Another process removed it first
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock: %w", err)
+	}
+	defer os.Remove(aside)
+
+	if moved, err := os.ReadFile(aside); err != nil || !bytes.Equal(moved, data) {
+		// Not the lock found stale: put it back
+		if err := os.Link(aside, path); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to restore lock file: %w", err)
+		}
+		return ErrLocked
+	}
+	return nil
+}
+
+// lockHeld reports whether a lock file whose contents are data (read
+// with readErr) belongs to a running mkrel, and if so the error to
+// return. A lock that can't be read is taken as held while it is new,
+// in case its owner is still writing it.
+func lockHeld(path string, data []byte, readErr error) (bool, error) {
+	var info lockInfo
+	if readErr == nil && json.Unmarshal(data, &info) == nil {
+		if info.stale() {
+			return false, nil
+		}
+		return true, fmt.Errorf("%w (pid %d, started %s); use --force-unlock if it is stale",
+			ErrLocked, info.PID, info.Created.Format(time.RFC3339))
+	}
+
+	stat, err := os.Stat(path)
+	if err == nil && time.Since(stat.ModTime()) < lockWriteGrace {
+		return true, ErrLocked
+	}
+	return false, nil
+}
+
+// stale reports whether the lock's owner is gone or the lock is too old.
+func (i lockInfo) stale() bool {
+	if time.Since(i.Created) > staleLockAge {
+		return true
+	}
+	return !processAlive(i.PID)
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	// Second acquire must fail while the first is held
+	if _, err := AcquireLock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("AcquireLock() while held error = %v, want ErrLocked", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Release() did not remove the lock file")
+	}
+
+	// Can be acquired again after release
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() after release error = %v", err)
+	}
+	_ = lock.Release()
+}
+
+func TestAcquireLock_Stale(t *testing.T) {
+	tests := []struct {
+		name string
+		info lockInfo
+	}{
+		{
+			name: "dead process",
+			info: lockInfo{PID: 0, Created: time.Now()},
+		},
+		{
+			name: "too old",
+			info: lockInfo{PID: os.Getpid(), Created: time.Now().Add(-2 * staleLockAge)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), LockFile)
+			data, _ := json.Marshal(tt.info)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Failed to write lock file: %v", err)
+			}
+
+			lock, err := AcquireLock(path)
+			if err != nil {
+				t.Fatalf("AcquireLock() over stale lock error = %v", err)
+			}
+			_ = lock.Release()
+		})
+	}
+}
+
+func TestAcquireLock_Unreadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("Failed to write lock file: %v", err)
+	}
+
+	// Possibly still being written by its owner
+	if _, err := AcquireLock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("AcquireLock() over a new empty lock error = %v, want ErrLocked", err)
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() over an old empty lock error = %v", err)
+	}
+	_ = lock.Release()
+}
+
+func TestAcquireLock_Race(t *testing.T) {
+	stale, _ := json.Marshal(lockInfo{PID: 0, Created: time.Now()})
+
+	for _, existing := range [][]byte{nil, stale} {
+		for range 20 {
+			dir := t.TempDir()
+			path := filepath.Join(dir, LockFile)
+			if existing != nil {
+				if err := os.WriteFile(path, existing, 0644); err != nil {
+					t.Fatalf("Failed to write lock file: %v", err)
+				}
+			}
+
+			const runs = 8
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			errs := make(chan error, runs)
+			for range runs {
+				wg.Go(func() {
+					<-start
+					_, err := AcquireLock(path)
+					errs <- err
+				})
+			}
+			close(start)
+			wg.Wait()
+			close(errs)
+
+			held := 0
+			for err := range errs {
+				switch {
+				case err == nil:
+					held++
+				case !errors.Is(err, ErrLocked):
+					t.Errorf("AcquireLock() error = %v, want nil or ErrLocked", err)
+				}
+			}
+			if held != 1 {
+				t.Fatalf("%d of %d concurrent AcquireLock() calls got the lock (stale lock %v), want 1",
+					held, runs, existing != nil)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("files left next to the lock: %v", entries)
+			}
+		}
+	}
+}
+
+func TestForceUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+
+	if _, err := AcquireLock(path); err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+	if err := ForceUnlock(path); err != nil {
+		t.Fatalf("ForceUnlock() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("ForceUnlock() did not remove the lock file")
+	}
+
+	// Removing a missing lock is not an error
+	if err := ForceUnlock(path); err != nil {
+		t.Errorf("ForceUnlock() on missing lock error = %v", err)
+	}
+}
+
+func TestRepository_LockPath(t *testing.T) {
+	dir := initRepo(t)
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	path, err := repo.LockPath()
+	if err != nil {
+		t.Fatalf("LockPath() error = %v", err)
+	}
+
+	gitDir, _ := filepath.EvalSymlinks(filepath.Join(dir, ".git"))
+	got, _ := filepath.EvalSymlinks(filepath.Dir(path))
+	if got != gitDir || filepath.Base(path) != LockFile {
+		t.Errorf("LockPath() = %q, want %q", path, filepath.Join(gitDir, LockFile))
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package git
+
+import "os"
+
+// processAlive reports whether a process with the given PID exists.
+// On Windows, FindProcess fails if the process doesn't exist.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
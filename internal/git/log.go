@@ -0,0 +1,96 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commit is a commit as listed by Log.
+type Commit struct {
+	Hash      string
+	Subject   string
+	Signature string // Signature status from LogSignatures (%G?), e.g. "G" or "N"
+}
+
+// Signed reports whether the commit has a good signature: "G", or "U"
+// (good, but from a key of unknown validity). Only set by LogSignatures.
+func (c Commit) Signed() bool {
+	return c.Signature == "G" || c.Signature == "U"
+}
+
+// Log returns the commits reachable from to but not from from,
+// newest first. If from is empty, the full history of to is returned.
+func (r *Repository) Log(from, to string) ([]Commit, error) {
+	return r.log(from, to)
+}
+
+// LogNoMerges is like Log but leaves out merge commits, whose subjects
+// are generated by git rather than written by hand.
+func (r *Repository) LogNoMerges(from, to string) ([]Commit, error) {
+	return r.log(from, to, "--no-merges")
+}
+
+// LogSignatures is like Log but also verifies each commit's signature,
+// setting Commit.Signature to git's %G? status.
+func (r *Repository) LogSignatures(from, to string) ([]Commit, error) {
+	return r.logFormat("--format=%H%x1f%s%x1f%G?%x1e", from, to)
+}
+
+// log runs "git log" over from..to with extra options.
+func (r *Repository) log(from, to string, options ...string) ([]Commit, error) {
+	return r.logFormat("--format=%H%x1f%s%x1e", from, to, options...)
+}
+
+// logFormat runs "git log" over from..to with a format whose fields
+// parseLog understands, and extra options.
+func (r *Repository) logFormat(format, from, to string, options ...string) ([]Commit, error) {
+	rangeSpec := to
+	if from != "" {
+		rangeSpec = from + ".." + to
+	}
+
+	// Unit and record separators keep subjects with any text parseable
+	args := append([]string{"log", format}, options...)
+	output, err := r.exec.RunSilent(append(args, rangeSpec)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(output), nil
+}
+
+// FirstCommit returns the SHA of the first commit in HEAD's history.
+func (r *Repository) FirstCommit() (string, error) {
+	return r.FirstCommitFrom("HEAD")
+}
+
+// FirstCommitFrom returns the SHA of the first commit in ref's history.
+// If the history has several root commits (e.g., from merging unrelated
+// histories), the earliest is returned.
+func (r *Repository) FirstCommitFrom(ref string) (string, error) {
+	// Roots are listed newest first; --reverse puts the earliest first
+	output, err := r.exec.RunSilent("rev-list", "--max-parents=0", "--reverse", ref)
+	if err != nil {
+		return "", err
+	}
+	first, _, _ := strings.Cut(output, "\n")
+	if first == "" {
+		return "", fmt.Errorf("%s has no commits", ref)
+	}
+	return first, nil
+}
+
+// parseLog parses "git log --format=%H%x1f%s%x1e" output, with an
+// optional %G? field after the subject.
+func parseLog(output string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		hash, rest, _ := strings.Cut(record, "\x1f")
+		subject, signature, _ := strings.Cut(rest, "\x1f")
+		commits = append(commits, Commit{Hash: hash, Subject: subject, Signature: signature})
+	}
+	return commits
+}
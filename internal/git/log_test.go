@@ -0,0 +1,112 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepository_Log(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		"log --format=%H%x1f%s%x1e v1.2.0..HEAD": {
+			stdout: "bbb\x1ffix: handle a | b\x1e\naaa\x1ffeat: add Log\x1e\n",
+		},
+	}}
+
+	got, err := newFakeRepo(f).Log("v1.2.0", "HEAD")
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	want := []Commit{
+		{Hash: "bbb", Subject: "fix: handle a | b"},
+		{Hash: "aaa", Subject: "feat: add Log"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Log() = %#v, want %#v", got, want)
+	}
+}
+
+func TestRepository_LogSignatures(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		"log --format=%H%x1f%s%x1f%G?%x1e v1.2.0..HEAD": {
+			stdout: "ccc\x1ffix: unsigned\x1fN\x1e\nbbb\x1ffeat: untrusted key\x1fU\x1e\naaa\x1ffeat: signed\x1fG\x1e\n",
+		},
+	}}
+
+	got, err := newFakeRepo(f).LogSignatures("v1.2.0", "HEAD")
+	if err != nil {
+		t.Fatalf("LogSignatures() error = %v", err)
+	}
+	want := []Commit{
+		{Hash: "ccc", Subject: "fix: unsigned", Signature: "N"},
+		{Hash: "bbb", Subject: "feat: untrusted key", Signature: "U"},
+		{Hash: "aaa", Subject: "feat: signed", Signature: "G"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LogSignatures() = %#v, want %#v", got, want)
+	}
+	for i, signed := range []bool{false, true, true} {
+		if got[i].Signed() != signed {
+			t.Errorf("%s Signed() = %v, want %v", got[i].Hash, got[i].Signed(), signed)
+		}
+	}
+}
+
+func TestRepository_Log_FullHistory(t *testing.T) {
+	f := &fakeRunner{}
+	if _, err := newFakeRepo(f).Log("", "HEAD"); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if want := "log --format=%H%x1f%s%x1e HEAD"; f.calls[0] != want {
+		t.Errorf("Log() ran %q, want %q", f.calls[0], want)
+	}
+}
+
+func TestRepository_LogNoMerges(t *testing.T) {
+	f := &fakeRunner{}
+	if _, err := newFakeRepo(f).LogNoMerges("v1.2.0", "develop"); err != nil {
+		t.Fatalf("LogNoMerges() error = %v", err)
+	}
+	if want := "log --format=%H%x1f%s%x1e --no-merges v1.2.0..develop"; f.calls[0] != want {
+		t.Errorf("LogNoMerges() ran %q, want %q", f.calls[0], want)
+	}
+}
+
+func TestRepository_FirstCommit(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	want := runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "second")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	got, err := repo.FirstCommit()
+	if err != nil {
+		t.Fatalf("FirstCommit() error = %v", err)
+	}
+	if got+"\n" != want {
+		t.Errorf("FirstCommit() = %q, want %q", got, want)
+	}
+}
+
+func TestRepository_FirstCommitFrom_MultipleRoots(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		// --reverse lists the earliest root first
+		"rev-list --max-parents=0 --reverse v1.0.0": {stdout: "aaa\nbbb\n"},
+		"rev-list --max-parents=0 --reverse empty":  {stdout: ""},
+	}}
+	repo := newFakeRepo(f)
+
+	got, err := repo.FirstCommitFrom("v1.0.0")
+	if err != nil {
+		t.Fatalf("FirstCommitFrom() error = %v", err)
+	}
+	if got != "aaa" {
+		t.Errorf("FirstCommitFrom() = %q, want the earliest root %q", got, "aaa")
+	}
+
+	if _, err := repo.FirstCommitFrom("empty"); err == nil {
+		t.Error("FirstCommitFrom() expected error when there are no commits")
+	}
+}
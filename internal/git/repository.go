@@ -2,19 +2,38 @@ package git
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Repository represents a git repository and provides high-level operations.
 type Repository struct {
-	exec *Executor
+	exec        *Executor
+	namespace   string   // Tag namespace (e.g., "mytool" for "mytool-1.2.0")
+	ignoreDirty []string // Path patterns of changes that don't count as uncommitted
+
+	switchOnce sync.Once // Guards hasSwitch, checked on first use
+	hasSwitch  bool      // git has "git switch" (2.23 and later)
 }
 
 // NewRepository creates a Repository for the given directory.
 // If dir is empty, it uses the current working directory.
 func NewRepository(dir string, dryRun, verbose bool) (*Repository, error) {
+	return NewRepositoryWithGitDir(dir, "", dryRun, verbose)
+}
+
+// NewRepositoryWithGitDir is like NewRepository for a work tree whose git
+// directory is gitDir rather than dir/.git. If gitDir is empty, GIT_DIR is
+// used when set, otherwise git finds the directory itself.
+func NewRepositoryWithGitDir(dir, gitDir string, dryRun, verbose bool) (*Repository, error) {
 	if dir == "" {
 		var err error
 		dir, err = os.Getwd()
@@ -22,15 +41,78 @@ func NewRepository(dir string, dryRun, verbose bool) (*Repository, error) {
 			return nil, fmt.Errorf("failed to get working directory: %w", err)
 		}
 	}
+	if gitDir == "" {
+		gitDir = os.Getenv("GIT_DIR")
+	}
 
-	gitDir := filepath.Join(dir, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	exec := NewExecutor(dir, dryRun, verbose)
+	if gitDir != "" {
+		// Relative paths are relative to where mkrel runs, not to dir
+		abs, err := filepath.Abs(gitDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid git directory %s: %w", gitDir, err)
+		}
+		exec.SetGitDir(abs)
+	}
+
+	inside, err := exec.RunSilent("rev-parse", "--is-inside-work-tree")
+	if err != nil || inside != "true" {
+		if gitDir != "" {
+			return nil, fmt.Errorf("not a git repository: %s (git dir %s)", dir, gitDir)
+		}
 		return nil, fmt.Errorf("not a git repository: %s", dir)
 	}
 
-	return &Repository{
-		exec: NewExecutor(dir, dryRun, verbose),
-	}, nil
+	return &Repository{exec: exec}, nil
+}
+
+// SetNamespace scopes version tags to a namespace: tags are formatted as
+// "<namespace>-<version>" and only such tags are considered by LatestTag
+// and LatestTagFrom. An empty namespace restores the default "v" tags.
+func (r *Repository) SetNamespace(namespace string) {
+	r.namespace = namespace
+}
+
+// SetIgnoreDirty makes HasUncommittedChanges and StatusFiles skip
+// untracked files at paths matching patterns, such as generated files.
+// A pattern without a slash matches the file name in any directory
+// ("*.log"), "dir/**" matches everything under dir, and other patterns
+// match the whole path ("dist/*.js"). Changes to tracked files always
+// count, staged or not.
+func (r *Repository) SetIgnoreDirty(patterns []string) {
+	r.ignoreDirty = patterns
+}
+
+// SetDebug enables printing the output of each command that modifies
+// the repository. See Executor.SetDebug.
+func (r *Repository) SetDebug(debug bool) {
+	r.exec.SetDebug(debug)
+}
+
+// SetTimeout stops git commands that run longer than timeout (0 = no
+// limit). See Executor.SetTimeout.
+func (r *Repository) SetTimeout(timeout time.Duration) {
+	r.exec.SetTimeout(timeout)
+}
+
+// SetLogger logs each git command at debug level (nil = no logging).
+// See Executor.SetLogger.
+func (r *Repository) SetLogger(logger *slog.Logger) {
+	r.exec.SetLogger(logger)
+}
+
+// EnableCache caches branch and tag queries (BranchExists, TagExists,
+// ListBranches, ListTags, ListVersionTags) until the next command that
+// changes the repository. Only enable it when nothing else changes the
+// repository meanwhile, such as for a single mkrel command.
+func (r *Repository) EnableCache() {
+	r.exec.EnableCache()
+}
+
+// SetOutput sets where echoed commands and debug output are written
+// (default: os.Stdout).
+func (r *Repository) SetOutput(w io.Writer) {
+	r.exec.out = w
 }
 
 // CurrentBranch returns the name of the current branch.
@@ -38,33 +120,176 @@ func (r *Repository) CurrentBranch() (string, error) {
 	return r.exec.Run("rev-parse", "--abbrev-ref", "HEAD")
 }
 
+// ResolveRef returns the full commit SHA that ref (branch, tag, or HEAD) points to.
+func (r *Repository) ResolveRef(ref string) (string, error) {
+	// ^{commit} peels annotated tags to the commit they point at
+	return r.exec.RunSilent("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+}
+
+// GitDir returns the absolute path of the repository's git directory.
+func (r *Repository) GitDir() (string, error) {
+	return r.exec.RunSilent("rev-parse", "--absolute-git-dir")
+}
+
+// Root returns the absolute path of the working tree's top directory.
+func (r *Repository) Root() (string, error) {
+	return r.exec.RunSilent("rev-parse", "--show-toplevel")
+}
+
+// FileAt returns the contents of a file (relative to the repository
+// root) at ref, with surrounding whitespace trimmed. ok is false if the
+// file doesn't exist at ref. A ref that doesn't exist is an error.
+func (r *Repository) FileAt(ref, path string) (content string, ok bool, err error) {
+	// Decided by exit status and output, not git's (translated) messages
+	if _, err := r.exec.RunSilent("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		if exitCode(err) == 1 {
+			return "", false, fmt.Errorf("ref %s not found", ref)
+		}
+		return "", false, err
+	}
+	path = filepath.ToSlash(path)
+	entry, err := r.exec.RunSilent("ls-tree", "--full-tree", ref, "--", path)
+	if err != nil {
+		return "", false, err
+	}
+	if entry == "" {
+		return "", false, nil
+	}
+	content, err = r.exec.RunSilent("show", ref+":"+path)
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// CommitsSinceTag returns the number of commits on HEAD that aren't
+// reachable from tag, i.e., the unreleased changes since that release.
+// Merge commits don't count, so merging a release back into develop
+// doesn't look like a change.
+func (r *Repository) CommitsSinceTag(tag string) (int, error) {
+	output, err := r.exec.RunSilent("rev-list", "--count", "--no-merges", tag+"..HEAD")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(output)
+}
+
+// HasCommits checks if the repository has at least one commit.
+// A freshly initialized repository has no HEAD to count from.
+func (r *Repository) HasCommits() (bool, error) {
+	_, err := r.exec.RunSilent("rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	if err != nil {
+		// Exit status 1 means HEAD doesn't resolve yet; anything else is a failure
+		if exitCode(err) == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // BranchExists checks if a branch exists (local or remote).
 func (r *Repository) BranchExists(name string) bool {
-	_, err := r.exec.RunSilent("show-ref", "--verify", "--quiet", "refs/heads/"+name)
+	_, err := r.exec.RunCached("show-ref", "--verify", "--quiet", "refs/heads/"+name)
 	return err == nil
 }
 
+// ExistingBranches reports which of the named local branches exist, with
+// one git call instead of one per name.
+func (r *Repository) ExistingBranches(names []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(names))
+	if len(names) == 0 {
+		return exists, nil
+	}
+	args := []string{"for-each-ref", "--format=%(refname:strip=2)"}
+	for _, name := range names {
+		exists[name] = false
+		args = append(args, "refs/heads/"+name)
+	}
+	output, err := r.exec.RunCached(args...)
+	if err != nil {
+		return nil, err
+	}
+	// A pattern also matches the branches under it (refs/heads/dev matches
+	// dev/x), so keep the exact names only
+	for _, branch := range strings.Split(output, "\n") {
+		if _, ok := exists[branch]; ok {
+			exists[branch] = true
+		}
+	}
+	return exists, nil
+}
+
 // ListBranches returns branches matching a prefix (e.g., "release/").
 func (r *Repository) ListBranches(prefix string) ([]string, error) {
-	output, err := r.exec.RunSilent("branch", "--list", "--no-color", prefix+"*")
+	output, err := r.exec.RunCached("branch", "--list", "--no-color", prefix+"*")
 	if err != nil {
 		return nil, err
 	}
+	return parseBranches(output), nil
+}
 
-	if output == "" {
-		return []string{}, nil
+// MergedBranches returns the local branches whose tips are reachable
+// from target, i.e. fully merged into it. target itself isn't included.
+func (r *Repository) MergedBranches(target string) ([]string, error) {
+	output, err := r.exec.RunSilent("branch", "--list", "--no-color", "--merged", target)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse output: each line is "  branch-name" or "* branch-name" (current)
-	var branches []string
+	branches := []string{}
+	for _, branch := range parseBranches(output) {
+		if branch != target {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// parseBranches parses "git branch --list" output. Each line is
+// "  branch-name", "* branch-name" (current), or "+ branch-name"
+// (checked out in another worktree). A detached HEAD is listed as
+// "* (HEAD detached at ...)" and skipped.
+func parseBranches(output string) []string {
+	branches := []string{}
 	for _, line := range strings.Split(output, "\n") {
 		branch := strings.TrimSpace(line)
 		branch = strings.TrimPrefix(branch, "* ") // Remove current branch marker
-		if branch != "" {
+		branch = strings.TrimPrefix(branch, "+ ") // Remove other worktree marker
+		if branch != "" && !strings.HasPrefix(branch, "(") {
 			branches = append(branches, branch)
 		}
 	}
-	return branches, nil
+	return branches
+}
+
+// Upstream returns the upstream of a local branch (e.g., "origin/main").
+// Returns empty string if the branch has no upstream configured.
+func (r *Repository) Upstream(branch string) (string, error) {
+	// The pattern also matches branches below it ("main/x"), so look for
+	// the exact ref
+	ref := "refs/heads/" + branch
+	output, err := r.exec.RunSilent("for-each-ref", "--format=%(refname)%09%(upstream:short)", ref)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if name, upstream, _ := strings.Cut(line, "\t"); name == ref {
+			return upstream, nil
+		}
+	}
+	return "", fmt.Errorf("branch %s not found", branch)
+}
+
+// RemoteURL returns the URL of a remote (e.g., "git@github.com:org/repo.git").
+func (r *Repository) RemoteURL(remote string) (string, error) {
+	return r.exec.RunSilent("remote", "get-url", remote)
+}
+
+// AddRemote adds a remote with the given URL.
+func (r *Repository) AddRemote(name, url string) error {
+	_, err := r.exec.Run("remote", "add", name, url)
+	return err
 }
 
 // CreateBranch creates a new branch from a base branch.
@@ -73,39 +298,338 @@ func (r *Repository) CreateBranch(name, base string) error {
 	return err
 }
 
+// CheckoutNew creates a branch from base and switches to it, like
+// CreateBranch, but fails with a clear error if the branch already exists
+// (e.g., left over from an earlier release that wasn't cleaned up).
+func (r *Repository) CheckoutNew(name, base string) error {
+	if r.BranchExists(name) {
+		return fmt.Errorf("branch %s already exists (finish or delete it, or use a different version)", name)
+	}
+	return r.SwitchCreate(name, base)
+}
+
 // Checkout switches to the specified branch.
 func (r *Repository) Checkout(branch string) error {
 	_, err := r.exec.Run("checkout", branch)
 	return err
 }
 
+// Switch switches to a branch with "git switch", which unlike checkout
+// never treats the name as a path or detaches HEAD at a tag. Before git
+// 2.23 it falls back to checkout.
+func (r *Repository) Switch(branch string) error {
+	if !r.canSwitch() {
+		return r.Checkout(branch)
+	}
+	_, err := r.exec.Run("switch", branch)
+	return err
+}
+
+// SwitchCreate creates a branch from base and switches to it with
+// "git switch -c", falling back to CreateBranch before git 2.23.
+func (r *Repository) SwitchCreate(name, base string) error {
+	if !r.canSwitch() {
+		return r.CreateBranch(name, base)
+	}
+	_, err := r.exec.Run("switch", "-c", name, base)
+	return err
+}
+
+// canSwitch reports whether git has "git switch". The git version is
+// only checked once.
+func (r *Repository) canSwitch() bool {
+	r.switchOnce.Do(func() {
+		major, minor, err := r.GitVersion()
+		r.hasSwitch = err == nil && (major > 2 || major == 2 && minor >= 23)
+	})
+	return r.hasSwitch
+}
+
+// GitVersion returns the major and minor version of git (2 and 43 for
+// "git version 2.43.0").
+func (r *Repository) GitVersion() (major, minor int, err error) {
+	output, err := r.exec.RunSilent("version")
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseGitVersion(output)
+}
+
+// parseGitVersion parses the output of "git version", such as
+// "git version 2.39.3 (Apple Git-146)" or "git version 2.43.0.windows.1".
+func parseGitVersion(output string) (major, minor int, err error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "git" || fields[1] != "version" {
+		return 0, 0, fmt.Errorf("unexpected git version output: %q", output)
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("unexpected git version: %q", fields[2])
+	}
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected git version: %q", fields[2])
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected git version: %q", fields[2])
+	}
+	return major, minor, nil
+}
+
+// RenameBranch renames a local branch. Its config section (e.g., a
+// recorded hotfix base) moves with it.
+func (r *Repository) RenameBranch(oldName, newName string) error {
+	_, err := r.exec.Run("branch", "-m", oldName, newName)
+	return err
+}
+
 // DeleteBranch deletes a local branch.
 func (r *Repository) DeleteBranch(name string) error {
 	_, err := r.exec.Run("branch", "-d", name)
 	return err
 }
 
+// DeleteBranchForce deletes a local branch even if it isn't merged.
+func (r *Repository) DeleteBranchForce(name string) error {
+	_, err := r.exec.Run("branch", "-D", name)
+	return err
+}
+
+// SetBranchConfig stores a value in the branch's git config section.
+// Git removes the section when the branch is deleted.
+func (r *Repository) SetBranchConfig(branch, key, value string) error {
+	_, err := r.exec.Run("config", "branch."+branch+"."+key, value)
+	return err
+}
+
+// BranchConfig reads a value from the branch's git config section.
+// Returns empty string if the key is not set.
+func (r *Repository) BranchConfig(branch, key string) (string, error) {
+	return r.ConfigGet("branch." + branch + "." + key)
+}
+
+// ConfigGet reads a git config value (e.g., "init.defaultBranch").
+// Returns empty string if the key is not set.
+func (r *Repository) ConfigGet(key string) (string, error) {
+	output, err := r.exec.RunSilent("config", "--get", key)
+	if err != nil {
+		// git config exits with 1 when the key is not set
+		if exitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+// IsAncestor reports whether ancestor is an ancestor of (or the same
+// commit as) descendant.
+func (r *Repository) IsAncestor(ancestor, descendant string) (bool, error) {
+	_, err := r.exec.RunSilent("merge-base", "--is-ancestor", ancestor, descendant)
+	if err != nil {
+		// Exit status 1 means "not an ancestor"; anything else is a failure
+		if exitCode(err) == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (r *Repository) MergeBase(a, b string) (string, error) {
+	return r.exec.RunSilent("merge-base", a, b)
+}
+
 // Merge merges a branch into the current branch.
 // noFF forces a merge commit even for fast-forward merges.
 func (r *Repository) Merge(branch string, noFF bool) error {
+	return r.MergeWithMessage(branch, "", noFF)
+}
+
+// MergeWithMessage merges a branch into the current branch like Merge,
+// with message as the merge commit message (empty = git's default).
+func (r *Repository) MergeWithMessage(branch, message string, noFF bool) error {
 	args := []string{"merge"}
 	if noFF {
 		args = append(args, "--no-ff")
 	}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
 	args = append(args, branch)
 
 	_, err := r.exec.Run(args...)
 	return err
 }
 
-// HasUncommittedChanges checks if there are uncommitted changes.
+// CherryPick applies commits, in order, onto the current branch. The new
+// commits record where they came from ("cherry picked from commit ...").
+// If one doesn't apply cleanly, the cherry-pick is left in progress and
+// the error wraps ErrCherryPickConflict.
+func (r *Repository) CherryPick(commits ...string) error {
+	args := append([]string{"cherry-pick", "-x"}, commits...)
+	_, err := r.exec.Run(args...)
+	if err == nil {
+		return nil
+	}
+	if _, headErr := r.exec.RunSilent("rev-parse", "-q", "--verify", "CHERRY_PICK_HEAD"); headErr == nil {
+		return fmt.Errorf("%w: %w", ErrCherryPickConflict, err)
+	}
+	return err
+}
+
+// IsShallow reports whether the repository is a shallow clone, whose
+// history (and the tags on it) stops at a cut-off depth.
+func (r *Repository) IsShallow() (bool, error) {
+	output, err := r.exec.RunSilent("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return output == "true", nil
+}
+
+// HasUncommittedChanges checks if there are uncommitted changes, other
+// than those ignored with SetIgnoreDirty.
 func (r *Repository) HasUncommittedChanges() (bool, error) {
-	// git status --porcelain returns empty if clean
-	output, err := r.exec.RunSilent("status", "--porcelain")
+	entries, err := r.StatusFiles()
 	if err != nil {
 		return false, err
 	}
-	return output != "", nil
+	return len(entries) > 0, nil
+}
+
+// HasStagedChanges reports whether the index differs from HEAD, i.e.
+// whether "git commit" has anything to commit.
+func (r *Repository) HasStagedChanges() (bool, error) {
+	_, err := r.exec.RunSilent("diff", "--cached", "--quiet")
+	if err == nil {
+		return false, nil
+	}
+	// git diff --quiet exits with 1 when there are differences
+	if exitCode(err) == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+// ResetHard moves the current branch to ref and makes the work tree
+// match it ("git reset --hard"). It is for explicit recovery paths such as
+// undo only, and refuses to run with uncommitted changes, which it would
+// discard.
+func (r *Repository) ResetHard(ref string) error {
+	if err := r.checkResettable(ref); err != nil {
+		return err
+	}
+	_, err := r.exec.Run("reset", "--hard", ref)
+	return err
+}
+
+// checkResettable fails if a reset to ref would lose changes: any change
+// to a tracked file, or an untracked file (even one ignored with
+// SetIgnoreDirty) that ref has and would overwrite. Other untracked files
+// are left alone by the reset.
+func (r *Repository) checkResettable(ref string) error {
+	entries, err := r.statusFiles()
+	if err != nil {
+		return err
+	}
+	var untracked []string
+	for _, entry := range entries {
+		if entry.Status != "??" {
+			return fmt.Errorf("refusing to reset to %s: uncommitted changes would be lost", ref)
+		}
+		untracked = append(untracked, entry.Path)
+	}
+	if len(untracked) == 0 {
+		return nil
+	}
+	args := append([]string{"ls-tree", "-r", "--name-only", "--full-tree", ref, "--"}, untracked...)
+	output, err := r.exec.RunSilent(args...)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return fmt.Errorf("refusing to reset to %s: uncommitted changes would be lost", ref)
+	}
+	return nil
+}
+
+// StatusEntry is one changed path reported by "git status --porcelain".
+type StatusEntry struct {
+	Status string // Two-letter XY code (e.g., " M", "A ", "??")
+	Path   string // Path relative to the repository root
+}
+
+// StatusFiles returns the uncommitted changes in the working tree, other
+// than those ignored with SetIgnoreDirty.
+func (r *Repository) StatusFiles() ([]StatusEntry, error) {
+	entries, err := r.statusFiles()
+	if err != nil || len(r.ignoreDirty) == 0 {
+		return entries, err
+	}
+	var kept []StatusEntry
+	for _, entry := range entries {
+		if entry.Status != "??" || !matchAnyPath(r.ignoreDirty, entry.Path) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// statusFiles returns all uncommitted changes in the working tree.
+func (r *Repository) statusFiles() ([]StatusEntry, error) {
+	output, err := r.exec.RunSilentRaw("status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(output), nil
+}
+
+// matchAnyPath reports whether a repository path matches any of the
+// patterns, as described for SetIgnoreDirty. Untracked directories are
+// reported with a trailing slash ("dist/") and match "dist/**".
+func matchAnyPath(patterns []string, name string) bool {
+	trimmed := strings.TrimSuffix(name, "/")
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if trimmed == dir || strings.HasPrefix(trimmed, dir+"/") {
+				return true
+			}
+			continue
+		}
+		target := trimmed
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(trimmed)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatus parses "git status --porcelain" (v1) output.
+// Each line is "XY PATH", or "XY ORIG -> PATH" for renames and copies.
+func parseStatus(output string) []StatusEntry {
+	var entries []StatusEntry
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		if idx := strings.Index(path, " -> "); idx != -1 {
+			path = path[idx+len(" -> "):]
+		}
+		entries = append(entries, StatusEntry{Status: line[:2], Path: path})
+	}
+	return entries
+}
+
+// Add stages files for the next commit.
+func (r *Repository) Add(paths ...string) error {
+	args := append([]string{"add", "--"}, paths...)
+	_, err := r.exec.Run(args...)
+	return err
 }
 
 // Commit creates a commit with the given message.
@@ -114,22 +638,68 @@ func (r *Repository) Commit(message string) error {
 	return err
 }
 
-// GetDevelopBranch finds the develop branch (might be "develop" or "development").
-func (r *Repository) GetDevelopBranch() (string, error) {
-	for _, name := range []string{"develop", "development", "dev"} {
-		if r.BranchExists(name) {
+// DevelopCandidates are the names GetDevelopBranch tries by default.
+var DevelopCandidates = []string{"develop", "development", "dev"}
+
+// GetDevelopBranch finds the develop branch: the first of candidates that
+// exists, or of DevelopCandidates if none are given.
+func (r *Repository) GetDevelopBranch(candidates ...string) (string, error) {
+	if len(candidates) == 0 {
+		candidates = DevelopCandidates
+	}
+	exists, err := r.ExistingBranches(candidates)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range candidates {
+		if exists[name] {
 			return name, nil
 		}
 	}
-	return "", fmt.Errorf("no develop branch found (tried: develop, development, dev)")
+	return "", fmt.Errorf("no develop branch found (tried: %s)", strings.Join(candidates, ", "))
 }
 
-// GetMainBranch finds the main branch (might be "main" or "master").
-func (r *Repository) GetMainBranch() (string, error) {
-	for _, name := range []string{"main", "master"} {
-		if r.BranchExists(name) {
+// GetMainBranch finds the main branch: the remote's default branch
+// (refs/remotes/<remote>/HEAD) or init.defaultBranch if that branch
+// exists locally, otherwise "main" or "master". Candidates in exclude
+// (the develop branch, which is often the remote's default in git-flow
+// repositories) are skipped.
+func (r *Repository) GetMainBranch(remote string, exclude ...string) (string, error) {
+	var candidates []string
+	if name, err := r.RemoteDefaultBranch(remote); err == nil && name != "" {
+		candidates = append(candidates, name)
+	}
+	if name, err := r.ConfigGet("init.defaultBranch"); err == nil && name != "" {
+		candidates = append(candidates, name)
+	}
+	candidates = append(candidates, "main", "master")
+	candidates = slices.DeleteFunc(candidates, func(name string) bool {
+		return slices.Contains(exclude, name)
+	})
+
+	exists, err := r.ExistingBranches(candidates)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range candidates {
+		if exists[name] {
 			return name, nil
 		}
 	}
-	return "", fmt.Errorf("no main branch found (tried: main, master)")
+	return "", fmt.Errorf("no main branch found (tried: %s HEAD, init.defaultBranch, main, master)", remote)
+}
+
+// RemoteDefaultBranch returns the branch the remote's HEAD points to
+// (e.g., "trunk" for refs/remotes/origin/HEAD -> origin/trunk).
+// Returns empty string if the remote HEAD isn't known locally.
+func (r *Repository) RemoteDefaultBranch(remote string) (string, error) {
+	output, err := r.exec.RunSilent("symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		// symbolic-ref --quiet exits with 1 when the ref isn't symbolic or doesn't exist
+		if exitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimPrefix(output, remote+"/"), nil
 }
@@ -0,0 +1,903 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// initRepo creates an empty git repository in a temp directory.
+func initRepo(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	return dir
+}
+
+// runGit runs a git command in dir with a fixed identity, failing the test on error.
+func runGit(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestRepository_FileAt(t *testing.T) {
+	dir := initRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "VERSION")
+	runGit(t, dir, "commit", "-m", "initial")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if got, ok, err := repo.FileAt("HEAD", "VERSION"); err != nil || !ok || got != "1.2.3" {
+		t.Errorf("FileAt(VERSION) = %q, %v, %v; want 1.2.3", got, ok, err)
+	}
+	if _, ok, err := repo.FileAt("HEAD", "missing"); err != nil || ok {
+		t.Errorf("FileAt(missing) = %v, %v; want not found", ok, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("draft\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := repo.FileAt("HEAD", "notes.txt"); err != nil || ok {
+		t.Errorf("FileAt(untracked) = %v, %v; want not found", ok, err)
+	}
+	if _, _, err := repo.FileAt("no-such-ref", "VERSION"); err == nil {
+		t.Error("FileAt(no-such-ref) error = nil, want an error")
+	}
+}
+
+func TestRepository_HasCommits(t *testing.T) {
+	dir := initRepo(t)
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if got, err := repo.HasCommits(); err != nil || got {
+		t.Errorf("HasCommits() = %v, %v for empty repository, want false", got, err)
+	}
+
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+
+	if got, err := repo.HasCommits(); err != nil || !got {
+		t.Errorf("HasCommits() = %v, %v after initial commit, want true", got, err)
+	}
+
+	// Other failures aren't mistaken for an empty repository
+	f := &fakeRunner{results: map[string]fakeResult{
+		"rev-parse --verify --quiet HEAD^{commit}": {stderr: "fatal: not a git repository", err: exitError(128)},
+	}}
+	if _, err := newFakeRepo(f).HasCommits(); err == nil {
+		t.Error("HasCommits() error = nil for a failing git, want it returned")
+	}
+}
+
+func TestRepository_CommitsSinceTag(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "develop")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "release")
+	runGit(t, dir, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	// Merging the release back into develop is not a change
+	runGit(t, dir, "checkout", "-q", "develop")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge main", "main")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	for want := 0; want <= 2; want++ {
+		if want > 0 {
+			runGit(t, dir, "commit", "--allow-empty", "-m", "change")
+		}
+		got, err := repo.CommitsSinceTag("v1.0.0")
+		if err != nil {
+			t.Fatalf("CommitsSinceTag() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("CommitsSinceTag() = %d, want %d", got, want)
+		}
+	}
+
+	if _, err := repo.CommitsSinceTag("v9.9.9"); err == nil {
+		t.Error("CommitsSinceTag() expected error for a missing tag")
+	}
+}
+
+func TestRepository_ResolveRef(t *testing.T) {
+	const sha = "3f786850e387550fdab836ed7e6dc881de23001b"
+	f := &fakeRunner{results: map[string]fakeResult{
+		"rev-parse --verify --quiet v1.2.0^{commit}":  {stdout: sha + "\n"},
+		"rev-parse --verify --quiet missing^{commit}": {err: errors.New("exit status 1")},
+	}}
+	repo := newFakeRepo(f)
+
+	got, err := repo.ResolveRef("v1.2.0")
+	if err != nil {
+		t.Fatalf("ResolveRef() error = %v", err)
+	}
+	if got != sha {
+		t.Errorf("ResolveRef() = %q, want %q", got, sha)
+	}
+
+	if _, err := repo.ResolveRef("missing"); err == nil {
+		t.Error("ResolveRef() expected error for unknown ref")
+	}
+}
+
+func TestRepository_IsAncestor(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "first")
+	runGit(t, dir, "branch", "old")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "second")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	tests := []struct {
+		a, b    string
+		want    bool
+		wantErr bool
+	}{
+		{a: "old", b: "main", want: true},
+		{a: "main", b: "old", want: false},
+		{a: "main", b: "main", want: true},
+		{a: "missing", b: "main", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := repo.IsAncestor(tt.a, tt.b)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("IsAncestor(%s, %s) error = %v, wantErr %v", tt.a, tt.b, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_IsAncestor_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{name: "exit 0", want: true},
+		{name: "exit 1", err: exitError(1), want: false},
+		{name: "exit 128", err: exitError(128), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"merge-base --is-ancestor v1.0.0 main": {err: tt.err},
+			}}
+
+			got, err := newFakeRepo(f).IsAncestor("v1.0.0", "main")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsAncestor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsAncestor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []StatusEntry
+	}{
+		{
+			name:   "clean",
+			output: "",
+			want:   nil,
+		},
+		{
+			name:   "modified in worktree keeps leading space",
+			output: " M README.md\n",
+			want:   []StatusEntry{{Status: " M", Path: "README.md"}},
+		},
+		{
+			name:   "mixed",
+			output: "M  go.mod\nA  internal/new.go\n?? notes.txt\n D old.go\n",
+			want: []StatusEntry{
+				{Status: "M ", Path: "go.mod"},
+				{Status: "A ", Path: "internal/new.go"},
+				{Status: "??", Path: "notes.txt"},
+				{Status: " D", Path: "old.go"},
+			},
+		},
+		{
+			name:   "rename",
+			output: "R  old name.go -> new name.go\n",
+			want:   []StatusEntry{{Status: "R ", Path: "new name.go"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseStatus(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatus() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_StatusFiles(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		"status --porcelain": {stdout: " M README.md\n?? notes.txt\n"},
+	}}
+
+	got, err := newFakeRepo(f).StatusFiles()
+	if err != nil {
+		t.Fatalf("StatusFiles() error = %v", err)
+	}
+	want := []StatusEntry{
+		{Status: " M", Path: "README.md"},
+		{Status: "??", Path: "notes.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StatusFiles() = %#v, want %#v", got, want)
+	}
+}
+
+func TestRepository_IgnoreDirty(t *testing.T) {
+	dir := initRepo(t)
+	for _, name := range []string{"app.go", "build.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("v1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "initial")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.SetIgnoreDirty([]string{"dist/**", "*.log"})
+
+	// Generated files: an untracked directory and log
+	if err := os.MkdirAll(filepath.Join(dir, "dist", "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"dist/bin/app": "binary", "debug.log": "output\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dirty, err := repo.HasUncommittedChanges(); err != nil || dirty {
+		t.Errorf("HasUncommittedChanges() = %v, %v; want false with ignored changes only", dirty, err)
+	}
+	if err := repo.ResetHard("HEAD"); err != nil {
+		t.Errorf("ResetHard() error = %v, want untracked files HEAD doesn't have left alone", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "debug.log")); err != nil {
+		t.Errorf("ResetHard() removed the untracked debug.log: %v", err)
+	}
+
+	// Changes to tracked files count even when they match, staged or not
+	if err := os.WriteFile(filepath.Join(dir, "build.log"), []byte("v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := repo.StatusFiles()
+	if want := []StatusEntry{{Status: " M", Path: "build.log"}}; err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("StatusFiles() = %v, %v; want %v", entries, err, want)
+	}
+	runGit(t, dir, "add", "build.log")
+	if entries, err := repo.StatusFiles(); err != nil || len(entries) != 1 || entries[0].Path != "build.log" {
+		t.Errorf("StatusFiles() = %v, %v; want the staged build.log", entries, err)
+	}
+	if err := repo.ResetHard("HEAD"); err == nil {
+		t.Error("ResetHard() succeeded with a change it would discard")
+	}
+}
+
+func TestMatchAnyPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"dist/**", "dist/", true},
+		{"dist/**", "dist/app/main.js", true},
+		{"dist/**", "dist", true},
+		{"dist/**", "distro/main.js", false},
+		{"dist/**", "web/dist/main.js", false},
+		{"*.log", "build.log", true},
+		{"*.log", "logs/build.log", true},
+		{"*.log", "build.log.txt", false},
+		{"dist/*.js", "dist/main.js", true},
+		{"dist/*.js", "dist/app/main.js", false},
+		{"go.sum", "go.sum", true},
+		{"go.sum", "go.mod", false},
+	}
+	for _, tt := range tests {
+		if got := matchAnyPath([]string{tt.pattern}, tt.path); got != tt.want {
+			t.Errorf("matchAnyPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_MergedBranches(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		"branch --list --no-color --merged develop": {
+			stdout: "* develop\n  feature/done\n+ feature/other-worktree\n  main\n",
+		},
+		"branch --list --no-color --merged main": {
+			stdout: "* (HEAD detached at 3f78685)\n  main\n",
+		},
+	}}
+	repo := newFakeRepo(f)
+
+	got, err := repo.MergedBranches("develop")
+	if err != nil {
+		t.Fatalf("MergedBranches() error = %v", err)
+	}
+	want := []string{"feature/done", "feature/other-worktree", "main"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergedBranches(develop) = %v, want %v", got, want)
+	}
+
+	got, err = repo.MergedBranches("main")
+	if err != nil {
+		t.Fatalf("MergedBranches() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("MergedBranches(main) = %v, want none", got)
+	}
+}
+
+func TestRepository_Upstream(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+
+	remote := t.TempDir()
+	runGit(t, remote, "init", "--bare")
+	runGit(t, dir, "remote", "add", "origin", remote)
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	// No upstream configured is not an error
+	upstream, err := repo.Upstream("main")
+	if err != nil {
+		t.Fatalf("Upstream() error = %v", err)
+	}
+	if upstream != "" {
+		t.Errorf("Upstream() = %q, want empty", upstream)
+	}
+
+	if err := repo.Push("origin", true, "main"); err != nil {
+		t.Fatalf("Push(setUpstream) error = %v", err)
+	}
+
+	upstream, err = repo.Upstream("main")
+	if err != nil {
+		t.Fatalf("Upstream() error = %v", err)
+	}
+	if upstream != "origin/main" {
+		t.Errorf("Upstream() = %q, want %q", upstream, "origin/main")
+	}
+
+	if _, err := repo.Upstream("missing"); err == nil {
+		t.Error("Upstream() of a missing branch should fail")
+	}
+}
+
+func TestRepository_CheckoutNew(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "release/1.3.0")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	err = repo.CheckoutNew("release/1.3.0", "main")
+	if err == nil || !strings.Contains(err.Error(), "release/1.3.0 already exists") {
+		t.Fatalf("CheckoutNew() error = %v, want an already-exists error", err)
+	}
+	if got, _ := repo.CurrentBranch(); got != "main" {
+		t.Errorf("CurrentBranch() = %q after a failed CheckoutNew, want main", got)
+	}
+
+	if err := repo.CheckoutNew("release/1.4.0", "main"); err != nil {
+		t.Fatalf("CheckoutNew() error = %v", err)
+	}
+	if got, _ := repo.CurrentBranch(); got != "release/1.4.0" {
+		t.Errorf("CurrentBranch() = %q, want release/1.4.0", got)
+	}
+}
+
+func TestRepository_Switch(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "develop")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if err := repo.Switch("develop"); err != nil {
+		t.Fatalf("Switch() error = %v", err)
+	}
+	if got, _ := repo.CurrentBranch(); got != "develop" {
+		t.Errorf("CurrentBranch() = %q, want develop", got)
+	}
+	if err := repo.SwitchCreate("release/1.0.0", "main"); err != nil {
+		t.Fatalf("SwitchCreate() error = %v", err)
+	}
+	if got, _ := repo.CurrentBranch(); got != "release/1.0.0" {
+		t.Errorf("CurrentBranch() = %q, want release/1.0.0", got)
+	}
+	if err := repo.Switch("missing"); err == nil {
+		t.Error("Switch() expected error for a missing branch")
+	}
+}
+
+func TestRepository_Switch_GitVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		wantSwitch string
+		wantCreate string
+	}{
+		{"git version 2.43.0", "switch develop", "switch -c release/1.0.0 main"},
+		{"git version 2.23.0", "switch develop", "switch -c release/1.0.0 main"},
+		{"git version 3.0.1", "switch develop", "switch -c release/1.0.0 main"},
+		{"git version 2.22.5", "checkout develop", "checkout -b release/1.0.0 main"},
+		{"git version 1.8.3.1", "checkout develop", "checkout -b release/1.0.0 main"},
+		{"unknown", "checkout develop", "checkout -b release/1.0.0 main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{"version": {stdout: tt.version}}}
+			repo := newFakeRepo(f)
+
+			if err := repo.Switch("develop"); err != nil {
+				t.Fatalf("Switch() error = %v", err)
+			}
+			if err := repo.SwitchCreate("release/1.0.0", "main"); err != nil {
+				t.Fatalf("SwitchCreate() error = %v", err)
+			}
+			want := []string{"version", tt.wantSwitch, tt.wantCreate}
+			if !reflect.DeepEqual(f.calls, want) {
+				t.Errorf("calls = %q, want %q", f.calls, want)
+			}
+		})
+	}
+}
+
+func TestParseGitVersion(t *testing.T) {
+	tests := []struct {
+		output       string
+		major, minor int
+		wantErr      bool
+	}{
+		{output: "git version 2.43.0", major: 2, minor: 43},
+		{output: "git version 2.39.3 (Apple Git-146)", major: 2, minor: 39},
+		{output: "git version 2.43.0.windows.1", major: 2, minor: 43},
+		{output: "git version 3", wantErr: true},
+		{output: "git version x.y", wantErr: true},
+		{output: "hub version 2.14.2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		major, minor, err := parseGitVersion(tt.output)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseGitVersion(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+			continue
+		}
+		if major != tt.major || minor != tt.minor {
+			t.Errorf("parseGitVersion(%q) = %d.%d, want %d.%d", tt.output, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+func TestRepository_DeleteBranch(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	for _, branch := range []string{"merged", "squashed"} {
+		runGit(t, dir, "branch", branch)
+	}
+	// A squash merge leaves the branch's own commit unmerged
+	runGit(t, dir, "checkout", "-q", "squashed")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "fix")
+	runGit(t, dir, "checkout", "-q", "main")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if err := repo.DeleteBranch("merged"); err != nil {
+		t.Errorf("DeleteBranch(merged) error = %v", err)
+	}
+	if err := repo.DeleteBranch("squashed"); err == nil {
+		t.Error("DeleteBranch(squashed) expected error for an unmerged branch")
+	}
+	if !repo.BranchExists("squashed") {
+		t.Fatal("DeleteBranch(squashed) deleted an unmerged branch")
+	}
+	if err := repo.DeleteBranchForce("squashed"); err != nil {
+		t.Errorf("DeleteBranchForce(squashed) error = %v", err)
+	}
+	if repo.BranchExists("merged") || repo.BranchExists("squashed") {
+		t.Error("branches still exist after deleting them")
+	}
+}
+
+func TestRepository_ConfigGet(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		"config --get commit.gpgsign":  {stdout: "true\n"},
+		"config --get user.signingkey": {err: exitError(1)},
+		"config --get bad..key":        {stderr: "error: invalid key: bad..key", err: exitError(2)},
+	}}
+	repo := newFakeRepo(f)
+
+	if got, err := repo.ConfigGet("commit.gpgsign"); err != nil || got != "true" {
+		t.Errorf("ConfigGet(commit.gpgsign) = %q, %v; want true", got, err)
+	}
+	if got, err := repo.ConfigGet("user.signingkey"); err != nil || got != "" {
+		t.Errorf("ConfigGet(unset) = %q, %v; want empty and no error", got, err)
+	}
+	if _, err := repo.ConfigGet("bad..key"); err == nil {
+		t.Error("ConfigGet(bad..key) expected error")
+	}
+}
+
+func TestRepository_GetMainBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteHead    string
+		defaultBranch string
+		branches      []string
+		want          string
+	}{
+		{"main", "", "", []string{"main"}, "main"},
+		{"master", "", "", []string{"master"}, "master"},
+		{"remote HEAD", "origin/trunk", "", []string{"main", "trunk"}, "trunk"},
+		{"remote HEAD first", "origin/trunk", "stable", []string{"stable", "trunk"}, "trunk"},
+		{"init.defaultBranch", "", "trunk", []string{"main", "trunk"}, "trunk"},
+		{"missing default", "origin/trunk", "trunk", []string{"master"}, "master"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"symbolic-ref --quiet --short refs/remotes/origin/HEAD": {err: exitError(1)},
+				"config --get init.defaultBranch":                       {err: exitError(1)},
+			}}
+			if tt.remoteHead != "" {
+				f.results["symbolic-ref --quiet --short refs/remotes/origin/HEAD"] = fakeResult{stdout: tt.remoteHead}
+			}
+			if tt.defaultBranch != "" {
+				f.results["config --get init.defaultBranch"] = fakeResult{stdout: tt.defaultBranch}
+			}
+			var candidates []string
+			if tt.remoteHead != "" {
+				candidates = append(candidates, strings.TrimPrefix(tt.remoteHead, "origin/"))
+			}
+			if tt.defaultBranch != "" {
+				candidates = append(candidates, tt.defaultBranch)
+			}
+			candidates = append(candidates, "main", "master")
+			f.results[branchQuery(candidates...)] = fakeResult{stdout: strings.Join(tt.branches, "\n")}
+
+			got, err := newFakeRepo(f).GetMainBranch("origin")
+			if err != nil {
+				t.Fatalf("GetMainBranch() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetMainBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_GetMainBranch_SkipsDevelop(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "-m", "main")
+	runGit(t, dir, "branch", "develop")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/develop", "HEAD")
+	runGit(t, dir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	if got, err := repo.GetMainBranch("origin"); err != nil || got != "develop" {
+		t.Errorf("GetMainBranch() = %q, %v; want develop", got, err)
+	}
+	if got, err := repo.GetMainBranch("origin", "develop"); err != nil || got != "main" {
+		t.Errorf("GetMainBranch(exclude develop) = %q, %v; want main", got, err)
+	}
+}
+
+func TestRepository_GetMainBranch_RemoteHead(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "-m", "trunk")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/trunk", "HEAD")
+	runGit(t, dir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/trunk")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	got, err := repo.GetMainBranch("origin")
+	if err != nil {
+		t.Fatalf("GetMainBranch() error = %v", err)
+	}
+	if got != "trunk" {
+		t.Errorf("GetMainBranch() = %q, want %q", got, "trunk")
+	}
+}
+
+// branchQuery is the git call ExistingBranches makes for names.
+func branchQuery(names ...string) string {
+	query := "for-each-ref --format=%(refname:strip=2)"
+	for _, name := range names {
+		query += " refs/heads/" + name
+	}
+	return query
+}
+
+func TestRepository_ExistingBranches(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		// dev/login matches the refs/heads/dev pattern but isn't dev
+		branchQuery("main", "dev", "next"): {stdout: "main\ndev/login\nnext"},
+	}}
+	repo := newFakeRepo(f)
+
+	got, err := repo.ExistingBranches([]string{"main", "dev", "next"})
+	if err != nil {
+		t.Fatalf("ExistingBranches() error = %v", err)
+	}
+	want := map[string]bool{"main": true, "dev": false, "next": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExistingBranches() = %v, want %v", got, want)
+	}
+	if len(f.calls) != 1 {
+		t.Errorf("calls = %q, want a single git call", f.calls)
+	}
+
+	f.results[branchQuery("main")] = fakeResult{err: exitError(128)}
+	if _, err := repo.ExistingBranches([]string{"main"}); err == nil {
+		t.Error("ExistingBranches() succeeded when git failed")
+	}
+}
+
+func TestRepository_GetDevelopBranch(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{
+		branchQuery("develop", "development", "dev"): {stdout: "main\ndev"},
+		branchQuery("next", "integration"):           {stdout: "integration"},
+	}}
+	repo := newFakeRepo(f)
+
+	if got, err := repo.GetDevelopBranch(); err != nil || got != "dev" {
+		t.Errorf("GetDevelopBranch() = %q, %v; want dev", got, err)
+	}
+	if got, err := repo.GetDevelopBranch("next", "integration"); err != nil || got != "integration" {
+		t.Errorf("GetDevelopBranch(next, integration) = %q, %v; want integration", got, err)
+	}
+	if _, err := repo.GetDevelopBranch("next"); err == nil || !strings.Contains(err.Error(), "tried: next") {
+		t.Errorf("GetDevelopBranch(next) error = %v, want one listing the candidates", err)
+	}
+}
+
+func TestRepository_AddRemote(t *testing.T) {
+	dir := initRepo(t)
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if _, err := repo.RemoteURL("origin"); err == nil {
+		t.Fatal("RemoteURL() succeeded before the remote was added")
+	}
+	remotes := map[string]string{
+		"origin":   "git@github.com:org/repo.git",
+		"upstream": "https://example.com/org/repo.git",
+	}
+	for name, url := range remotes {
+		if err := repo.AddRemote(name, url); err != nil {
+			t.Fatalf("AddRemote(%q) error = %v", url, err)
+		}
+		got, err := repo.RemoteURL(name)
+		if err != nil {
+			t.Fatalf("RemoteURL() error = %v", err)
+		}
+		if got != url {
+			t.Errorf("RemoteURL() = %q, want %q", got, url)
+		}
+	}
+}
+
+func TestNewRepositoryWithGitDir(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+
+	// Move the git directory out of the work tree
+	gitDir := filepath.Join(t.TempDir(), "repo.git")
+	if err := os.Rename(filepath.Join(dir, ".git"), gitDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRepository(dir, false, false); err == nil {
+		t.Fatal("NewRepository() succeeded without the git directory")
+	}
+
+	repo, err := NewRepositoryWithGitDir(dir, gitDir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepositoryWithGitDir() error = %v", err)
+	}
+	if branch, err := repo.CurrentBranch(); err != nil || branch != "main" {
+		t.Errorf("CurrentBranch() = %q, %v; want main", branch, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, err := repo.HasUncommittedChanges(); err != nil || !dirty {
+		t.Errorf("HasUncommittedChanges() = %v, %v; want true with an untracked file in the work tree", dirty, err)
+	}
+
+	// GIT_DIR works the same way
+	t.Setenv("GIT_DIR", gitDir)
+	if _, err := NewRepository(dir, false, false); err != nil {
+		t.Errorf("NewRepository() with GIT_DIR error = %v", err)
+	}
+}
+
+func TestRepository_CherryPick(t *testing.T) {
+	f := &fakeRunner{}
+	if err := newFakeRepo(f).CherryPick("aaa", "bbb"); err != nil {
+		t.Fatalf("CherryPick() error = %v", err)
+	}
+	if want := []string{"cherry-pick -x aaa bbb"}; !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+
+	tests := []struct {
+		name         string
+		pickHead     error // Result of looking up CHERRY_PICK_HEAD
+		wantConflict bool
+	}{
+		{name: "conflict", wantConflict: true},
+		{name: "other failure", pickHead: exitError(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"cherry-pick -x aaa":                     {err: exitError(1), stderr: "error: could not apply aaa"},
+				"rev-parse -q --verify CHERRY_PICK_HEAD": {err: tt.pickHead},
+			}}
+			err := newFakeRepo(f).CherryPick("aaa")
+			if err == nil {
+				t.Fatal("CherryPick() succeeded")
+			}
+			if got := errors.Is(err, ErrCherryPickConflict); got != tt.wantConflict {
+				t.Errorf("CherryPick() error = %v, conflict %v, want %v", err, got, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestRepository_IsShallow(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "first")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "second")
+	shallowDir := filepath.Join(t.TempDir(), "shallow")
+	runGit(t, dir, "clone", "-q", "--depth", "1", "file://"+dir, shallowDir)
+
+	for path, want := range map[string]bool{dir: false, shallowDir: true} {
+		repo, err := NewRepository(path, false, false)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		if got, err := repo.IsShallow(); err != nil || got != want {
+			t.Errorf("IsShallow(%s) = %v, %v; want %v", filepath.Base(path), got, err, want)
+		}
+	}
+}
+
+func TestRepository_ResetHard(t *testing.T) {
+	f := &fakeRunner{}
+	if err := newFakeRepo(f).ResetHard("abc1234"); err != nil {
+		t.Fatalf("ResetHard() error = %v", err)
+	}
+	if want := []string{"status --porcelain", "reset --hard abc1234"}; !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+
+	// Uncommitted changes are never discarded
+	f = &fakeRunner{results: map[string]fakeResult{
+		"status --porcelain": {stdout: " M README.md\n"},
+	}}
+	if err := newFakeRepo(f).ResetHard("abc1234"); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("ResetHard() error = %v, want one about uncommitted changes", err)
+	}
+	if slices.Contains(f.calls, "reset --hard abc1234") {
+		t.Error("ResetHard() reset despite uncommitted changes")
+	}
+}
+
+func TestRepository_HasStagedChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  fakeResult
+		want    bool
+		wantErr bool
+	}{
+		{name: "nothing staged", want: false},
+		{name: "staged", result: fakeResult{err: exitError(1)}, want: true},
+		{name: "git error", result: fakeResult{err: exitError(128)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{"diff --cached --quiet": tt.result}}
+			got, err := newFakeRepo(f).HasStagedChanges()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HasStagedChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("HasStagedChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_MergeWithMessage(t *testing.T) {
+	f := &fakeRunner{}
+	repo := newFakeRepo(f)
+
+	if err := repo.Merge("release/1.3.0", true); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if err := repo.MergeWithMessage("release/1.3.0", "Merge release/1.3.0 into main", true); err != nil {
+		t.Fatalf("MergeWithMessage() error = %v", err)
+	}
+
+	want := []string{
+		"merge --no-ff release/1.3.0",
+		"merge --no-ff -m Merge release/1.3.0 into main release/1.3.0",
+	}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+}
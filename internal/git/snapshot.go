@@ -0,0 +1,233 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotFile is the name of the undo snapshot inside the git directory.
+const SnapshotFile = "mkrel-undo.json"
+
+// ErrNoSnapshot is returned when there is no snapshot to restore.
+var ErrNoSnapshot = errors.New("nothing to undo")
+
+// Snapshot records the state of the refs an operation is about to change,
+// so it can be rolled back locally.
+type Snapshot struct {
+	Operation string    `json:"operation"` // e.g., "release finish"
+	Created   time.Time `json:"created"`
+	Head      string    `json:"head"` // Branch checked out before the operation
+
+	// Release identifies the release the operation is for (e.g., its
+	// tag), so that snapshots of an operation run in parts can be merged.
+	Release string `json:"release,omitempty"`
+
+	// Branches maps each affected branch to its commit SHA.
+	Branches map[string]string `json:"branches"`
+
+	// BranchConfig holds per-branch config values to restore
+	// (branch -> key -> value), lost when a branch is deleted.
+	BranchConfig map[string]map[string]string `json:"branch_config,omitempty"`
+
+	// Tag is the tag the operation creates, deleted on restore. A tag
+	// that existed before the operation isn't recorded.
+	Tag string `json:"tag,omitempty"`
+
+	// Tags maps tags the operation moves to the object each pointed at
+	// before, or "" for one that didn't exist (see RecordTags). Restoring
+	// puts them back, or deletes them.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// SnapshotPath returns the path of the undo snapshot for this repository.
+func (r *Repository) SnapshotPath() (string, error) {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, SnapshotFile), nil
+}
+
+// TakeSnapshot records the current commit of each existing branch and
+// the checked-out branch. Branches that don't exist are skipped, and so
+// is tag if it already exists, since the operation won't create it.
+func (r *Repository) TakeSnapshot(operation, tag string, branches ...string) (*Snapshot, error) {
+	head, err := r.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{
+		Operation: operation,
+		Created:   time.Now(),
+		Head:      head,
+		Branches:  make(map[string]string),
+	}
+	if tag != "" && !r.TagExists(tag) {
+		s.Tag = tag
+	}
+	for _, branch := range branches {
+		if branch == "" || !r.BranchExists(branch) {
+			continue
+		}
+		sha, err := r.ResolveRef(branch)
+		if err != nil {
+			return nil, err
+		}
+		s.Branches[branch] = sha
+	}
+	return s, nil
+}
+
+// RecordTags adds tags the operation will move (e.g., a tag replaced with
+// --retag) to the snapshot, with the object each points at now, so
+// restoring puts them back rather than deleting them.
+func (r *Repository) RecordTags(s *Snapshot, names ...string) error {
+	for _, name := range names {
+		if s.Tags == nil {
+			s.Tags = make(map[string]string)
+		}
+		if !r.TagExists(name) {
+			s.Tags[name] = ""
+			continue
+		}
+		// Not peeled: an annotated tag is restored with its message
+		object, err := r.exec.RunSilent("rev-parse", "--verify", "--quiet", "refs/tags/"+name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve tag %s: %w", name, err)
+		}
+		s.Tags[name] = object
+	}
+	return nil
+}
+
+// Merge adds the refs later records and s doesn't to s, for an operation
+// run in parts (e.g., "release finish --only merge", then "--only tag").
+// Refs s already records keep their earlier state, so restoring undoes
+// every part.
+func (s *Snapshot) Merge(later *Snapshot) {
+	for branch, sha := range later.Branches {
+		if _, ok := s.Branches[branch]; !ok {
+			if s.Branches == nil {
+				s.Branches = make(map[string]string)
+			}
+			s.Branches[branch] = sha
+		}
+	}
+	for branch, values := range later.BranchConfig {
+		if _, ok := s.BranchConfig[branch]; !ok {
+			if s.BranchConfig == nil {
+				s.BranchConfig = make(map[string]map[string]string)
+			}
+			s.BranchConfig[branch] = values
+		}
+	}
+	if s.Tag == "" {
+		s.Tag = later.Tag
+	}
+	for name, object := range later.Tags {
+		if _, ok := s.Tags[name]; !ok {
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			s.Tags[name] = object
+		}
+	}
+}
+
+// SaveSnapshot writes a snapshot to path, replacing any previous one.
+func SaveSnapshot(path string, s *Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write undo snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads the snapshot at path.
+// Returns ErrNoSnapshot if there is none.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read undo snapshot: %w", err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse undo snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// RestoreSnapshot resets the snapshot's branches to their recorded
+// commits (recreating deleted ones), deletes its tag, puts moved tags
+// back, and checks out the branch that was checked out before. Only
+// local refs are changed.
+func (r *Repository) RestoreSnapshot(s *Snapshot) error {
+	// Check before changing anything, so a dirty tree leaves no half-restored state
+	current, _ := r.CurrentBranch()
+	if ref, ok := s.Branches[current]; ok {
+		if err := r.checkResettable(ref); err != nil {
+			return err
+		}
+	}
+
+	if s.Tag != "" && r.TagExists(s.Tag) {
+		if err := r.DeleteTag(s.Tag); err != nil {
+			return err
+		}
+	}
+	for name, object := range s.Tags {
+		if object == "" {
+			if r.TagExists(name) {
+				if err := r.DeleteTag(name); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if _, err := r.exec.Run("update-ref", "refs/tags/"+name, object); err != nil {
+			return err
+		}
+	}
+
+	branches := make([]string, 0, len(s.Branches))
+	for branch := range s.Branches {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	// The checked-out branch is reset together with the work tree; git
+	// won't force-move it with "git branch"
+	for _, branch := range branches {
+		if branch == current {
+			if err := r.ResetHard(s.Branches[branch]); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := r.exec.Run("branch", "--force", branch, s.Branches[branch]); err != nil {
+			return err
+		}
+	}
+	for branch, values := range s.BranchConfig {
+		for key, value := range values {
+			if err := r.SetBranchConfig(branch, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.Checkout(s.Head)
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSnapshot_Missing(t *testing.T) {
+	_, err := LoadSnapshot(filepath.Join(t.TempDir(), SnapshotFile))
+	if !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("LoadSnapshot() error = %v, want ErrNoSnapshot", err)
+	}
+}
+
+func TestRepository_Snapshot(t *testing.T) {
+	dir := initRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "notes.txt")
+	runGit(t, dir, "commit", "-m", "initial")
+	runGit(t, dir, "branch", "develop")
+	runGit(t, dir, "checkout", "-q", "-b", "release/1.0.0")
+	runGit(t, dir, "rm", "-q", "notes.txt")
+	runGit(t, dir, "commit", "-m", "bump")
+	runGit(t, dir, "config", "branch.release/1.0.0.mkrelBase", "develop")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	snapshot, err := repo.TakeSnapshot("release finish", "v1.0.0", "release/1.0.0", "main", "develop", "missing")
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+	if snapshot.Head != "release/1.0.0" {
+		t.Errorf("Head = %q, want release/1.0.0", snapshot.Head)
+	}
+	if len(snapshot.Branches) != 3 {
+		t.Errorf("Branches = %v, want the three existing branches", snapshot.Branches)
+	}
+
+	// A tag that already exists isn't the operation's to delete
+	runGit(t, dir, "tag", "v0.9.0")
+	if existing, err := repo.TakeSnapshot("hotfix finish", "v0.9.0", "main"); err != nil || existing.Tag != "" {
+		t.Errorf("TakeSnapshot() tag = %q, %v, want an existing tag left out", existing.Tag, err)
+	}
+	snapshot.BranchConfig = map[string]map[string]string{"release/1.0.0": {"mkrelBase": "develop"}}
+
+	path, err := repo.SnapshotPath()
+	if err != nil {
+		t.Fatalf("SnapshotPath() error = %v", err)
+	}
+	if err := SaveSnapshot(path, snapshot); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	before := map[string]string{}
+	for _, branch := range []string{"main", "develop", "release/1.0.0"} {
+		before[branch] = runGit(t, dir, "rev-parse", branch)
+	}
+
+	// Simulate a finish: merge, tag, and delete the release branch
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge release", "release/1.0.0")
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "branch", "-D", "release/1.0.0")
+
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+
+	// An untracked file that the reset would overwrite stops the restore
+	// before anything changes
+	notesPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notesPath, []byte("local notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RestoreSnapshot(loaded); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("RestoreSnapshot() error = %v, want uncommitted changes", err)
+	}
+	if !repo.TagExists("v1.0.0") {
+		t.Error("RestoreSnapshot() deleted the tag before failing")
+	}
+	if err := os.Remove(notesPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// An ignored untracked file that the reset leaves alone doesn't
+	repo.SetIgnoreDirty([]string{"*.log"})
+	logPath := filepath.Join(dir, "build.log")
+	if err := os.WriteFile(logPath, []byte("output\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RestoreSnapshot(loaded); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if _, err := os.Stat(logPath); err != nil {
+		t.Errorf("RestoreSnapshot() removed the untracked build.log: %v", err)
+	}
+
+	for branch, sha := range before {
+		if got := runGit(t, dir, "rev-parse", branch); got != sha {
+			t.Errorf("%s = %s, want %s", branch, strings.TrimSpace(got), strings.TrimSpace(sha))
+		}
+	}
+	if repo.TagExists("v1.0.0") {
+		t.Error("RestoreSnapshot() did not delete tag v1.0.0")
+	}
+	if head, _ := repo.CurrentBranch(); head != "release/1.0.0" {
+		t.Errorf("CurrentBranch() = %q, want release/1.0.0", head)
+	}
+	if base, _ := repo.BranchConfig("release/1.0.0", "mkrelBase"); base != "develop" {
+		t.Errorf("BranchConfig() = %q, want develop", base)
+	}
+}
@@ -2,8 +2,9 @@ package git
 
 import (
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // CreateTag creates an annotated tag with a message.
@@ -12,17 +13,87 @@ func (r *Repository) CreateTag(name, message string) error {
 	return err
 }
 
+// CreateTagFromStdin creates an annotated tag whose message is piped to
+// git on stdin. Use it for long or multi-line messages such as release
+// notes, which are unwieldy as a command-line argument.
+func (r *Repository) CreateTagFromStdin(name, message string) error {
+	// --cleanup=verbatim keeps Markdown headings ("## ...") that git
+	// would otherwise strip as comments
+	_, err := r.exec.RunWithInput(message, "tag", "-a", name, "--cleanup=verbatim", "-F", "-")
+	return err
+}
+
+// MoveTag creates an annotated tag on commit, replacing any existing tag
+// of that name (git tag -f).
+func (r *Repository) MoveTag(name, commit, message string) error {
+	_, err := r.exec.Run("tag", "-f", "-a", name, "-m", message, commit)
+	return err
+}
+
 // TagExists checks if a tag exists.
 func (r *Repository) TagExists(name string) bool {
-	_, err := r.exec.RunSilent("show-ref", "--verify", "--quiet", "refs/tags/"+name)
+	_, err := r.exec.RunCached("show-ref", "--verify", "--quiet", "refs/tags/"+name)
 	return err == nil
 }
 
 // LatestTag returns the most recent tag.
 // Returns empty string if no tags exist.
 func (r *Repository) LatestTag() (string, error) {
+	return r.describeTag(nil)
+}
+
+// LatestTagFrom returns the most recent tag reachable from ref.
+// Returns empty string if no tags are reachable.
+func (r *Repository) LatestTagFrom(ref string) (string, error) {
+	return r.describeTag(nil, ref)
+}
+
+// LatestVersionTag returns the most recent tag reachable from ref (HEAD
+// if empty) whose version valid accepts, skipping tags that don't name a
+// version (e.g., "nightly" or "build-123").
+// Returns empty string if there is no such tag.
+func (r *Repository) LatestVersionTag(ref string, valid func(version string) bool) (string, error) {
+	var refs []string
+	if ref != "" {
+		refs = []string{ref}
+	}
+
+	// Exclude rejected tags rather than describing their parent, so a
+	// version tag on the same commit as a rejected one is still found
+	var exclude []string
+	for {
+		tag, err := r.describeTag(exclude, refs...)
+		if err != nil || tag == "" {
+			return tag, err
+		}
+		if v, ok := r.TagVersion(tag); ok && valid(v) {
+			return tag, nil
+		}
+		exclude = append(exclude, tag)
+	}
+}
+
+// matchVersionTags returns "git describe" options limiting it to tags
+// that can name versions: those in the namespace if one is set, and
+// otherwise those starting with a digit, with or without the "v" prefix.
+func (r *Repository) matchVersionTags() []string {
+	if r.namespace != "" {
+		return []string{"--match", r.namespace + "-*"}
+	}
+	return []string{"--match", "v[0-9]*", "--match", "[0-9]*"}
+}
+
+// describeTag finds the most recent tag reachable from ref (default HEAD),
+// limited to the namespace if one is set and skipping excluded tags.
+func (r *Repository) describeTag(exclude []string, ref ...string) (string, error) {
 	// git describe --tags --abbrev=0 gets the most recent tag
-	output, err := r.exec.RunSilent("describe", "--tags", "--abbrev=0")
+	args := append([]string{"describe", "--tags", "--abbrev=0"}, r.matchVersionTags()...)
+	for _, tag := range exclude {
+		args = append(args, "--exclude", tag)
+	}
+	args = append(args, ref...)
+
+	output, err := r.exec.RunSilent(args...)
 	if err != nil {
 		// No tags exist - this is not an error for our use case
 		if strings.Contains(err.Error(), "No names found") ||
@@ -34,14 +105,55 @@ func (r *Repository) LatestTag() (string, error) {
 	return output, nil
 }
 
-// ListTags returns all tags, optionally filtered by prefix.
-func (r *Repository) ListTags(prefix string) ([]string, error) {
-	args := []string{"tag", "--list"}
+// Describe returns a version for HEAD in "git describe" form, with the
+// namespace or "v" prefix of the tag removed: "1.2.3" on a tagged commit,
+// "1.2.3-5-gabc1234" five commits later, or just the abbreviated commit
+// SHA if there are no version tags yet. Other tags (e.g., "deployed")
+// are ignored.
+func (r *Repository) Describe() (string, error) {
+	args := append([]string{"describe", "--tags", "--always"}, r.matchVersionTags()...)
+	output, err := r.exec.RunSilent(args...)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := r.describeTag(nil)
+	if err != nil {
+		return "", err
+	}
+	if rest, ok := strings.CutPrefix(output, tag); ok && tag != "" {
+		v, _ := r.TagVersion(tag)
+		return v + rest, nil
+	}
+	return output, nil
+}
+
+// Sort keys for ListTags (see "git help tag", --sort).
+const (
+	SortVersion     = "version:refname"  // Oldest version first
+	SortVersionDesc = "-version:refname" // Newest version first
+	SortCreatorDate = "creatordate"      // Oldest tag (or tagged commit) first
+)
+
+// ListTags returns all tags, optionally filtered by prefix, ordered by
+// sortKey (SortVersion if empty). Version sorting orders prereleases
+// before their release (1.2.0-rc.0 before 1.2.0).
+func (r *Repository) ListTags(prefix, sortKey string) ([]string, error) {
+	if sortKey == "" {
+		sortKey = SortVersion
+	}
+
+	var args []string
+	if strings.TrimPrefix(sortKey, "-") == SortVersion {
+		// Without this, git sorts 1.2.0-rc.0 after 1.2.0
+		args = append(args, "-c", "versionsort.suffix=-")
+	}
+	args = append(args, "tag", "--list", "--sort="+sortKey)
 	if prefix != "" {
 		args = append(args, prefix+"*")
 	}
 
-	output, err := r.exec.RunSilent(args...)
+	output, err := r.exec.RunCached(args...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,25 +161,193 @@ func (r *Repository) ListTags(prefix string) ([]string, error) {
 	if output == "" {
 		return []string{}, nil
 	}
+	return strings.Split(output, "\n"), nil
+}
+
+// ListVersionTags returns up to limit version tags, highest version
+// first: tags in the namespace whose version (see TagVersion) valid
+// accepts. A limit of 0 or less returns them all. Only as many tags as
+// needed are read, so the latest few are quick to find even among
+// thousands of tags.
+func (r *Repository) ListVersionTags(limit int, valid func(version string) bool) ([]string, error) {
+	pattern := "refs/tags/"
+	if r.namespace != "" {
+		pattern += r.namespace + "-*"
+	}
 
-	tags := strings.Split(output, "\n")
-	// Sort tags (git doesn't guarantee order)
-	sort.Strings(tags)
-	return tags, nil
+	// Ask for limit tags, and for twice as many while too few are versions
+	for count := limit; ; count *= 2 {
+		args := []string{"-c", "versionsort.suffix=-",
+			"for-each-ref", "--sort=-" + SortVersion, "--format=%(refname:strip=2)"}
+		if limit > 0 {
+			args = append(args, "--count="+strconv.Itoa(count))
+		}
+		output, err := r.exec.RunCached(append(args, pattern)...)
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		if output != "" {
+			names = strings.Split(output, "\n")
+		}
+		tags := []string{}
+		for _, tag := range names {
+			if v, ok := r.TagVersion(tag); ok && valid(v) {
+				tags = append(tags, tag)
+				if len(tags) == limit {
+					return tags, nil
+				}
+			}
+		}
+		// Fewer tags than asked for: there are no more
+		if limit <= 0 || len(names) < count {
+			return tags, nil
+		}
+	}
 }
 
-// Push pushes refs (branches, tags) to a remote.
-func (r *Repository) Push(remote string, refs ...string) error {
-	args := append([]string{"push", remote}, refs...)
-	_, err := r.exec.Run(args...)
-	return err
+// LatestRemoteTag returns the highest version tag on a remote (limited to
+// the namespace if one is set) whose version (see TagVersion) valid
+// accepts, without fetching anything. Returns empty string if the remote
+// has no such tags.
+func (r *Repository) LatestRemoteTag(remote string, valid func(version string) bool) (string, error) {
+	pattern := "refs/tags/*"
+	if r.namespace != "" {
+		pattern = "refs/tags/" + r.namespace + "-*"
+	}
+	output, err := r.exec.RunSilent("-c", "versionsort.suffix=-",
+		"ls-remote", "--tags", "--sort=-version:refname", remote, pattern)
+	if err != nil {
+		return "", err
+	}
+	return parseLatestRemoteTag(output, func(tag string) bool {
+		v, ok := r.TagVersion(tag)
+		return ok && valid(v)
+	}), nil
 }
 
-// PushWithTags pushes refs and all tags to a remote.
-func (r *Repository) PushWithTags(remote string, refs ...string) error {
-	args := append([]string{"push", "--follow-tags", remote}, refs...)
-	_, err := r.exec.Run(args...)
-	return err
+// parseLatestRemoteTag returns the first tag in "git ls-remote --tags"
+// output that isVersion accepts. Lines look like
+// "<sha><TAB>refs/tags/v1.2.0" (plus a peeled "refs/tags/v1.2.0^{}" line
+// for annotated tags).
+func parseLatestRemoteTag(output string, isVersion func(tag string) bool) string {
+	for _, line := range strings.Split(output, "\n") {
+		_, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		tag := strings.TrimSuffix(strings.TrimPrefix(ref, "refs/tags/"), "^{}")
+		if isVersion(tag) {
+			return tag
+		}
+	}
+	return ""
+}
+
+// TagMessage returns the message of an annotated tag (e.g., its release
+// notes). Returns empty string for lightweight tags, which have none.
+func (r *Repository) TagMessage(tag string) (string, error) {
+	output, err := r.exec.RunSilent("tag", "--list", "--format=%(objecttype)%0a%(contents)", tag)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return "", fmt.Errorf("tag %s not found", tag)
+	}
+	return parseTagMessage(output), nil
+}
+
+// parseTagMessage extracts the message from "%(objecttype)%0a%(contents)"
+// output. For lightweight tags the object is a commit, whose message
+// %(contents) would show instead, so it is ignored.
+func parseTagMessage(output string) string {
+	objectType, contents, _ := strings.Cut(output, "\n")
+	if objectType != "tag" {
+		return ""
+	}
+	return strings.TrimSpace(contents)
+}
+
+// TagDate returns the author date of the commit a tag points to.
+func (r *Repository) TagDate(tag string) (time.Time, error) {
+	output, err := r.exec.RunSilent("log", "-1", "--format=%aI", tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := time.Parse(time.RFC3339, output)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse date of tag %s: %w", tag, err)
+	}
+	return date, nil
+}
+
+// Push pushes refs (branches, tags) to a remote. If setUpstream is set,
+// the pushed branches track their remote branches (git push -u).
+// Rejections by branch protection wrap ErrProtectedBranch.
+func (r *Repository) Push(remote string, setUpstream bool, refs ...string) error {
+	_, err := r.exec.Run(pushArgs(remote, setUpstream, refs)...)
+	return classifyPushError(err)
+}
+
+// PushDryRun checks that pushing refs to a remote would succeed, without
+// pushing anything. It returns an error listing any rejected refs (e.g.,
+// non-fast-forward updates) or why the remote couldn't be reached.
+// It runs even in dry-run mode, since it changes nothing.
+func (r *Repository) PushDryRun(remote string, refs ...string) error {
+	args := append([]string{"push", "--dry-run", "--porcelain", remote}, refs...)
+	stdout, stderr, err := r.exec.RunSilentCapture(args...)
+	if rejected := parsePushRejections(stdout); len(rejected) > 0 {
+		return fmt.Errorf("push to %s would be rejected:\n  %s", redact(remote), strings.Join(rejected, "\n  "))
+	}
+	if err != nil {
+		return commandError(args, err, stderr)
+	}
+	return nil
+}
+
+// parsePushRejections returns a description of each ref rejected in
+// "git push --porcelain" output. Rejected lines look like
+// "!<TAB>refs/heads/main:refs/heads/main<TAB>[rejected] (fetch first)".
+func parsePushRejections(output string) []string {
+	var rejected []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || fields[0] != "!" {
+			continue
+		}
+		_, dst, _ := strings.Cut(fields[1], ":")
+		dst = strings.TrimPrefix(strings.TrimPrefix(dst, "refs/heads/"), "refs/tags/")
+		rejected = append(rejected, dst+": "+fields[2])
+	}
+	return rejected
+}
+
+// PushWithTags pushes refs and the annotated tags pointing into them to a
+// remote. If setUpstream is set, the pushed branches track their remote
+// branches (git push -u).
+func (r *Repository) PushWithTags(remote string, setUpstream bool, refs ...string) error {
+	_, err := r.exec.Run(pushArgs(remote, setUpstream, refs, "--follow-tags")...)
+	return classifyPushError(err)
+}
+
+// PushAtomic pushes refs to a remote in one atomic push: either the
+// remote accepts every ref or it updates none (git push --atomic).
+func (r *Repository) PushAtomic(remote string, setUpstream bool, refs ...string) error {
+	_, err := r.exec.Run(pushArgs(remote, setUpstream, refs, "--atomic")...)
+	return classifyPushError(err)
+}
+
+// pushArgs builds the arguments of a "git push" of refs to remote,
+// with any extra flags.
+func pushArgs(remote string, setUpstream bool, refs []string, flags ...string) []string {
+	args := append([]string{"push"}, flags...)
+	if setUpstream {
+		args = append(args, "-u")
+	}
+	args = append(args, remote)
+	return append(args, refs...)
 }
 
 // FetchTags fetches all tags from a remote.
@@ -76,6 +356,15 @@ func (r *Repository) FetchTags(remote string) error {
 	return err
 }
 
+// FetchUnshallow turns a shallow clone into a complete one, fetching
+// the rest of the history and all tags from a remote. The fetch isn't
+// subject to the timeout (see SetTimeout), since it may take long in a
+// large repository.
+func (r *Repository) FetchUnshallow(remote string) error {
+	_, err := r.exec.RunUntimed("fetch", "--unshallow", "--tags", remote)
+	return err
+}
+
 // GetTagsOnCommit returns tags pointing to a specific commit.
 func (r *Repository) GetTagsOnCommit(commit string) ([]string, error) {
 	output, err := r.exec.RunSilent("tag", "--points-at", commit)
@@ -90,6 +379,16 @@ func (r *Repository) GetTagsOnCommit(commit string) ([]string, error) {
 	return strings.Split(output, "\n"), nil
 }
 
+// TagPointsAt reports whether tag points at commit (a full SHA).
+// Annotated tags are peeled to the commit they tag.
+func (r *Repository) TagPointsAt(tag, commit string) (bool, error) {
+	tagged, err := r.ResolveRef("refs/tags/" + tag)
+	if err != nil {
+		return false, fmt.Errorf("tag %s not found", tag)
+	}
+	return tagged == commit, nil
+}
+
 // GetCurrentTags returns tags pointing to HEAD.
 func (r *Repository) GetCurrentTags() ([]string, error) {
 	return r.GetTagsOnCommit("HEAD")
@@ -104,7 +403,7 @@ func (r *Repository) DeleteTag(name string) error {
 // VersionTagPrefix returns the prefix used for version tags (e.g., "v").
 // This checks existing tags to determine the pattern.
 func (r *Repository) VersionTagPrefix() (string, error) {
-	tags, err := r.ListTags("")
+	tags, err := r.ListTags("", "")
 	if err != nil {
 		return "", err
 	}
@@ -132,6 +431,10 @@ func (r *Repository) VersionTagPrefix() (string, error) {
 
 // FormatTag formats a version string with the appropriate prefix.
 func (r *Repository) FormatTag(version string) (string, error) {
+	if r.namespace != "" {
+		return r.namespace + "-" + strings.TrimPrefix(version, "v"), nil
+	}
+
 	prefix, err := r.VersionTagPrefix()
 	if err != nil {
 		return "", fmt.Errorf("failed to determine tag prefix: %w", err)
@@ -144,3 +447,13 @@ func (r *Repository) FormatTag(version string) (string, error) {
 
 	return prefix + version, nil
 }
+
+// TagVersion returns the version a tag names, without the namespace or
+// "v" prefix. ok is false for tags outside the namespace.
+func (r *Repository) TagVersion(tag string) (version string, ok bool) {
+	if r.namespace != "" {
+		version, ok = strings.CutPrefix(tag, r.namespace+"-")
+		return version, ok
+	}
+	return strings.TrimPrefix(tag, "v"), true
+}
@@ -0,0 +1,557 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepository_CreateTagFromStdin(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	message := "## 1.0.0 (2025-01-01)\n\n### Features\n\n- it's \"quoted\" & $(not) expanded\n"
+	if err := repo.CreateTagFromStdin("v1.0.0", message); err != nil {
+		t.Fatalf("CreateTagFromStdin() error = %v", err)
+	}
+
+	got := runGit(t, dir, "tag", "-l", "--format=%(contents)", "v1.0.0")
+	// git for-each-ref appends a newline after the contents
+	if got != message+"\n" {
+		t.Errorf("tag message = %q, want %q", got, message)
+	}
+}
+
+func TestRepository_LatestVersionTag(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "second")
+	runGit(t, dir, "tag", "v1.1.0")
+	runGit(t, dir, "tag", "nightly")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "third")
+	runGit(t, dir, "tag", "build-123")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	isVersion := func(v string) bool { return strings.Count(v, ".") == 2 }
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", "v1.1.0"},
+		{"HEAD~1", "v1.1.0"},
+		{"HEAD~2", "v1.0.0"},
+	}
+	for _, tt := range tests {
+		got, err := repo.LatestVersionTag(tt.ref, isVersion)
+		if err != nil {
+			t.Fatalf("LatestVersionTag(%q) error = %v", tt.ref, err)
+		}
+		if got != tt.want {
+			t.Errorf("LatestVersionTag(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+
+	none, err := repo.LatestVersionTag("", func(string) bool { return false })
+	if err != nil || none != "" {
+		t.Errorf("LatestVersionTag() = %q, %v, want no tag", none, err)
+	}
+}
+
+func TestRepository_TagDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdout  string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "offset",
+			stdout: "2025-12-25T10:30:00+01:00\n",
+			want:   time.Date(2025, 12, 25, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "utc",
+			stdout: "2025-12-25T09:30:00Z\n",
+			want:   time.Date(2025, 12, 25, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "garbage",
+			stdout:  "Thu Dec 25 10:30:00 2025\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"log -1 --format=%aI v1.0.0": {stdout: tt.stdout},
+			}}
+
+			got, err := newFakeRepo(f).TagDate("v1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TagDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("TagDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_TagVersion(t *testing.T) {
+	tests := []struct {
+		namespace string
+		tag       string
+		want      string
+		wantOK    bool
+	}{
+		{namespace: "", tag: "v1.2.0", want: "1.2.0", wantOK: true},
+		{namespace: "", tag: "2025.12.25", want: "2025.12.25", wantOK: true},
+		{namespace: "mytool", tag: "mytool-1.2.0", want: "1.2.0", wantOK: true},
+		{namespace: "mytool", tag: "v1.2.0", want: "v1.2.0", wantOK: false},
+		{namespace: "mytool", tag: "othertool-1.2.0", want: "othertool-1.2.0", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace+"/"+tt.tag, func(t *testing.T) {
+			repo := newFakeRepo(&fakeRunner{})
+			repo.SetNamespace(tt.namespace)
+
+			got, ok := repo.TagVersion(tt.tag)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("TagVersion(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePushRejections(t *testing.T) {
+	output := "To /tmp/origin.git\n" +
+		"=\trefs/heads/develop:refs/heads/develop\t[up to date]\n" +
+		"!\trefs/heads/main:refs/heads/main\t[rejected] (non-fast-forward)\n" +
+		"!\trefs/tags/v1.0.0:refs/tags/v1.0.0\t[rejected] (already exists)\n" +
+		"Done\n"
+
+	got := parsePushRejections(output)
+	want := []string{
+		"main: [rejected] (non-fast-forward)",
+		"v1.0.0: [rejected] (already exists)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePushRejections() = %q, want %q", got, want)
+	}
+
+	if got := parsePushRejections("To /tmp/origin.git\n*\trefs/heads/main:refs/heads/main\t[new branch]\nDone\n"); len(got) != 0 {
+		t.Errorf("parsePushRejections() = %q, want none", got)
+	}
+}
+
+func TestRepository_Push_SetUpstream(t *testing.T) {
+	tests := []struct {
+		name        string
+		push        func(r *Repository) error
+		wantCommand string
+	}{
+		{
+			name:        "push",
+			push:        func(r *Repository) error { return r.Push("origin", false, "main") },
+			wantCommand: "push origin main",
+		},
+		{
+			name:        "push -u",
+			push:        func(r *Repository) error { return r.Push("origin", true, "main") },
+			wantCommand: "push -u origin main",
+		},
+		{
+			name:        "with tags",
+			push:        func(r *Repository) error { return r.PushWithTags("origin", false, "main", "develop") },
+			wantCommand: "push --follow-tags origin main develop",
+		},
+		{
+			name:        "with tags -u",
+			push:        func(r *Repository) error { return r.PushWithTags("origin", true, "main", "develop") },
+			wantCommand: "push --follow-tags -u origin main develop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{}
+			if err := tt.push(newFakeRepo(f)); err != nil {
+				t.Fatalf("push error = %v", err)
+			}
+			if len(f.calls) != 1 || f.calls[0] != tt.wantCommand {
+				t.Errorf("ran %q, want %q", f.calls, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestRepository_Describe(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	// No tags: just the short SHA
+	sha := strings.TrimSpace(runGit(t, dir, "rev-parse", "--short", "HEAD"))
+	if got, err := repo.Describe(); err != nil || got != sha {
+		t.Errorf("Describe() = %q, %v, want %q", got, err, sha)
+	}
+
+	runGit(t, dir, "tag", "v1.2.3")
+	if got, err := repo.Describe(); err != nil || got != "1.2.3" {
+		t.Errorf("Describe() = %q, %v, want %q", got, err, "1.2.3")
+	}
+
+	runGit(t, dir, "commit", "--allow-empty", "-m", "second")
+	runGit(t, dir, "tag", "deployed")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "third")
+	sha = strings.TrimSpace(runGit(t, dir, "rev-parse", "--short", "HEAD"))
+	// Nearer tags that aren't versions don't count
+	if got, err := repo.Describe(); err != nil || got != "1.2.3-2-g"+sha {
+		t.Errorf("Describe() = %q, %v, want %q", got, err, "1.2.3-2-g"+sha)
+	}
+}
+
+func TestRepository_Push_ProtectedBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		stderr        string
+		wantProtected bool
+	}{
+		{
+			name: "github",
+			stderr: "remote: error: GH006: Protected branch update failed for refs/heads/main.\n" +
+				"remote: error: Changes must be made through a pull request.\n" +
+				" ! [remote rejected] main -> main (protected branch hook declined)\n",
+			wantProtected: true,
+		},
+		{
+			name:          "gitlab",
+			stderr:        "remote: GitLab: You are not allowed to push code to protected branches on this project.\n",
+			wantProtected: true,
+		},
+		{
+			name:          "non-fast-forward",
+			stderr:        " ! [rejected]        main -> main (fetch first)\n",
+			wantProtected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"push --follow-tags origin main": {stderr: tt.stderr, err: exitError(1)},
+			}}
+
+			err := newFakeRepo(f).PushWithTags("origin", false, "main")
+			if err == nil {
+				t.Fatal("PushWithTags() expected error")
+			}
+			if got := errors.Is(err, ErrProtectedBranch); got != tt.wantProtected {
+				t.Errorf("errors.Is(err, ErrProtectedBranch) = %v, want %v (err: %v)", got, tt.wantProtected, err)
+			}
+		})
+	}
+}
+
+func TestRepository_ListTags_Sort(t *testing.T) {
+	dir := initRepo(t)
+	// Tags are created out of version order, one commit (and date) each
+	for i, tag := range []string{"v1.10.0", "v1.2.0-rc.0", "v1.9.0", "v1.2.0"} {
+		t.Setenv("GIT_COMMITTER_DATE", fmt.Sprintf("2025-01-0%dT12:00:00Z", i+1))
+		runGit(t, dir, "commit", "--allow-empty", "-m", tag)
+		runGit(t, dir, "tag", tag)
+	}
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sortKey string
+		want    []string
+	}{
+		{"", []string{"v1.2.0-rc.0", "v1.2.0", "v1.9.0", "v1.10.0"}},
+		{SortVersion, []string{"v1.2.0-rc.0", "v1.2.0", "v1.9.0", "v1.10.0"}},
+		{SortVersionDesc, []string{"v1.10.0", "v1.9.0", "v1.2.0", "v1.2.0-rc.0"}},
+		{SortCreatorDate, []string{"v1.10.0", "v1.2.0-rc.0", "v1.9.0", "v1.2.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortKey, func(t *testing.T) {
+			got, err := repo.ListTags("", tt.sortKey)
+			if err != nil {
+				t.Fatalf("ListTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_LatestRemoteTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		stdout    string
+		want      string
+	}{
+		{
+			name: "annotated",
+			stdout: "2222222222222222222222222222222222222222\trefs/tags/v1.10.0^{}\n" +
+				"1111111111111111111111111111111111111111\trefs/tags/v1.10.0\n" +
+				"3333333333333333333333333333333333333333\trefs/tags/v1.9.0\n",
+			want: "v1.10.0",
+		},
+		{
+			name:   "lightweight",
+			stdout: "3333333333333333333333333333333333333333\trefs/tags/v1.9.0\n",
+			want:   "v1.9.0",
+		},
+		{
+			name:      "namespace",
+			namespace: "mytool",
+			stdout:    "1111111111111111111111111111111111111111\trefs/tags/mytool-2.0.0\n",
+			want:      "mytool-2.0.0",
+		},
+		{
+			// Version sorting puts names that aren't versions first
+			name: "not versions",
+			stdout: "4444444444444444444444444444444444444444\trefs/tags/nightly\n" +
+				"5555555555555555555555555555555555555555\trefs/tags/vnext\n" +
+				"3333333333333333333333333333333333333333\trefs/tags/v1.9.0\n",
+			want: "v1.9.0",
+		},
+		{
+			name:   "no tags",
+			stdout: "",
+			want:   "",
+		},
+	}
+
+	valid := func(v string) bool { return v != "" && v[0] >= '0' && v[0] <= '9' }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := "refs/tags/*"
+			if tt.namespace != "" {
+				pattern = "refs/tags/" + tt.namespace + "-*"
+			}
+			f := &fakeRunner{results: map[string]fakeResult{
+				"-c versionsort.suffix=- ls-remote --tags --sort=-version:refname origin " + pattern: {stdout: tt.stdout},
+			}}
+			repo := newFakeRepo(f)
+			repo.SetNamespace(tt.namespace)
+
+			got, err := repo.LatestRemoteTag("origin", valid)
+			if err != nil {
+				t.Fatalf("LatestRemoteTag() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LatestRemoteTag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_TagMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdout  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "annotated",
+			stdout: "tag\n## 1.2.0\n\n### Features\n\n- add widgets\n",
+			want:   "## 1.2.0\n\n### Features\n\n- add widgets",
+		},
+		{
+			name:   "lightweight",
+			stdout: "commit\nfeat: add widgets\n",
+			want:   "",
+		},
+		{
+			name:    "missing",
+			stdout:  "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"tag --list --format=%(objecttype)%0a%(contents) v1.2.0": {stdout: tt.stdout},
+			}}
+
+			got, err := newFakeRepo(f).TagMessage("v1.2.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TagMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TagMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_FetchTags(t *testing.T) {
+	f := &fakeRunner{results: map[string]fakeResult{}}
+	repo := newFakeRepo(f)
+
+	if err := repo.FetchTags("origin"); err != nil {
+		t.Fatalf("FetchTags() error = %v", err)
+	}
+
+	want := []string{"fetch --tags origin"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+}
+
+func TestRepository_TagPointsAt(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name    string
+		result  fakeResult
+		want    bool
+		wantErr bool
+	}{
+		{name: "same commit", result: fakeResult{stdout: commit + "\n"}, want: true},
+		{name: "other commit", result: fakeResult{stdout: "fedcba9876543210fedcba9876543210fedcba98\n"}, want: false},
+		{name: "missing", result: fakeResult{err: exitError(1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRunner{results: map[string]fakeResult{
+				"rev-parse --verify --quiet refs/tags/v1.2.0^{commit}": tt.result,
+			}}
+
+			got, err := newFakeRepo(f).TagPointsAt("v1.2.0", commit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TagPointsAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TagPointsAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_ListVersionTags(t *testing.T) {
+	const list = "-c versionsort.suffix=- for-each-ref --sort=-version:refname --format=%(refname:strip=2)"
+	isVersion := func(v string) bool { return v != "" && v[0] >= '0' && v[0] <= '9' }
+
+	f := &fakeRunner{results: map[string]fakeResult{
+		list + " --count=2 refs/tags/": {stdout: "latest\nv1.2.0\n"},
+		list + " --count=4 refs/tags/": {stdout: "latest\nv1.2.0\nv1.1.0\nv1.0.0\n"},
+		list + " refs/tags/":           {stdout: "latest\nv1.2.0\nv1.1.0\nv1.0.0\n"},
+		list + " --count=5 refs/tags/": {stdout: "latest\nv1.2.0\nv1.1.0\nv1.0.0\n"},
+	}}
+	repo := newFakeRepo(f)
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		// "latest" takes a slot, so a second, larger page is read
+		{limit: 2, want: []string{"v1.2.0", "v1.1.0"}},
+		// Fewer tags than the limit: no second page
+		{limit: 5, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		{limit: 0, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+	}
+	for _, tt := range tests {
+		f.calls = nil
+		got, err := repo.ListVersionTags(tt.limit, isVersion)
+		if err != nil {
+			t.Fatalf("ListVersionTags(%d) error = %v", tt.limit, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListVersionTags(%d) = %q, want %q", tt.limit, got, tt.want)
+		}
+		if tt.limit == 5 && len(f.calls) != 1 {
+			t.Errorf("ListVersionTags(5) ran %d commands, want 1", len(f.calls))
+		}
+	}
+}
+
+// newTaggedRepo creates a repository with n version tags (v1.0.0 to
+// v1.0.<n-1>) on a single commit, written straight to packed-refs.
+func newTaggedRepo(tb testing.TB, n int) string {
+	tb.Helper()
+	dir := initRepo(tb)
+	runGit(tb, dir, "commit", "--allow-empty", "-m", "initial")
+	head := strings.TrimSpace(runGit(tb, dir, "rev-parse", "HEAD"))
+
+	refs := make([]string, n)
+	for i := range n {
+		refs[i] = fmt.Sprintf("refs/tags/v1.0.%d", i)
+	}
+	slices.Sort(refs)
+
+	var b strings.Builder
+	b.WriteString("# pack-refs with: peeled fully-peeled sorted\n")
+	for _, ref := range refs {
+		fmt.Fprintf(&b, "%s %s\n", head, ref)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "packed-refs"), []byte(b.String()), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return dir
+}
+
+func BenchmarkListVersionTags(b *testing.B) {
+	dir := newTaggedRepo(b, 5000)
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		b.Fatalf("NewRepository() error = %v", err)
+	}
+	valid := func(string) bool { return true }
+
+	b.Run("ListTags", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := repo.ListTags("", ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ListVersionTags/all", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := repo.ListVersionTags(0, valid); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ListVersionTags/10", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := repo.ListVersionTags(10, valid); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a working tree of the repository, as listed by
+// "git worktree list".
+type Worktree struct {
+	Path   string // Absolute path of the working tree
+	Head   string // Commit SHA checked out
+	Branch string // Branch checked out; empty if detached or bare
+	Bare   bool
+}
+
+// Worktrees returns the repository's working trees, the main one first.
+func (r *Repository) Worktrees() ([]Worktree, error) {
+	output, err := r.exec.RunSilent("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktrees(output), nil
+}
+
+// parseWorktrees parses "git worktree list --porcelain" output: blocks of
+// "worktree <path>", "HEAD <sha>", and "branch refs/heads/<name>" (or
+// "detached" or "bare") lines, separated by blank lines.
+func parseWorktrees(output string) []Worktree {
+	var worktrees []Worktree
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		if key == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value})
+			continue
+		}
+		if len(worktrees) == 0 {
+			continue
+		}
+		wt := &worktrees[len(worktrees)-1]
+		switch key {
+		case "HEAD":
+			wt.Head = value
+		case "branch":
+			wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			wt.Bare = true
+		}
+	}
+	return worktrees
+}
+
+// OtherWorktree returns the path of another working tree that has branch
+// checked out, or empty string if there is none. Git refuses to check out
+// a branch that is checked out elsewhere.
+func (r *Repository) OtherWorktree(branch string) (string, error) {
+	worktrees, err := r.Worktrees()
+	if err != nil {
+		return "", err
+	}
+	current, err := r.Root()
+	if err != nil {
+		return "", err
+	}
+
+	for _, wt := range worktrees {
+		if wt.Branch == branch && filepath.Clean(wt.Path) != filepath.Clean(current) {
+			return wt.Path, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseWorktrees(t *testing.T) {
+	output := "worktree /src/app\n" +
+		"HEAD 1111111111111111111111111111111111111111\n" +
+		"branch refs/heads/develop\n" +
+		"\n" +
+		"worktree /src/app-main\n" +
+		"HEAD 2222222222222222222222222222222222222222\n" +
+		"branch refs/heads/main\n" +
+		"\n" +
+		"worktree /src/app-review\n" +
+		"HEAD 3333333333333333333333333333333333333333\n" +
+		"detached"
+
+	want := []Worktree{
+		{Path: "/src/app", Head: "1111111111111111111111111111111111111111", Branch: "develop"},
+		{Path: "/src/app-main", Head: "2222222222222222222222222222222222222222", Branch: "main"},
+		{Path: "/src/app-review", Head: "3333333333333333333333333333333333333333"},
+	}
+	if got := parseWorktrees(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWorktrees() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRepository_OtherWorktree(t *testing.T) {
+	dir := initRepo(t)
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, dir, "branch", "develop")
+
+	other := filepath.Join(t.TempDir(), "develop")
+	runGit(t, dir, "worktree", "add", "-q", other, "develop")
+
+	repo, err := NewRepository(dir, false, false)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	// Checked out in this worktree is fine
+	if got, err := repo.OtherWorktree("main"); err != nil || got != "" {
+		t.Errorf("OtherWorktree(main) = %q, %v, want none", got, err)
+	}
+
+	got, err := repo.OtherWorktree("develop")
+	if err != nil {
+		t.Fatalf("OtherWorktree() error = %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(other); got != want {
+		t.Errorf("OtherWorktree(develop) = %q, want %q", got, want)
+	}
+}
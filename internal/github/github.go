@@ -0,0 +1,240 @@
+// Package github talks to the GitHub API for the repository's remote,
+// e.g., to open release pull requests.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// apiURL is the GitHub API base URL. Tests point it at a local server.
+var apiURL = "https://api.github.com"
+
+// Repo identifies a GitHub repository.
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// String returns the repository as "owner/name".
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// CompareURL returns the web page for opening a pull request from head
+// into base by hand.
+func (r Repo) CompareURL(base, head string) string {
+	return fmt.Sprintf("https://github.com/%s/compare/%s...%s?expand=1", r, base, head)
+}
+
+// scpRemote matches SCP-like remote URLs ("git@github.com:owner/repo.git").
+var scpRemote = regexp.MustCompile(`^[\w.-]+@([\w.-]+):(.+)$`)
+
+// ParseRemote returns the GitHub repository a remote URL points to, for
+// HTTPS ("https://github.com/owner/repo.git") and SSH
+// ("git@github.com:owner/repo.git", "ssh://git@github.com/owner/repo")
+// remotes.
+func ParseRemote(remoteURL string) (Repo, error) {
+	var host, path string
+	if m := scpRemote.FindStringSubmatch(remoteURL); m != nil {
+		host, path = m[1], m[2]
+	} else if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	}
+	if host != "github.com" {
+		return Repo{}, fmt.Errorf("remote %s is not a GitHub repository", remoteURL)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Repo{}, fmt.Errorf("remote %s is not a GitHub repository", remoteURL)
+	}
+	return Repo{Owner: parts[0], Name: parts[1]}, nil
+}
+
+// Token returns the GitHub token from GITHUB_TOKEN or GH_TOKEN.
+// Returns empty string if neither is set.
+func Token() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// Client is an authenticated GitHub API client.
+type Client struct {
+	token string
+	http  *http.Client
+}
+
+// apiTimeout bounds API calls. Asset uploads take as long as they need.
+const apiTimeout = 30 * time.Second
+
+// NewClient creates a client authenticating with token.
+func NewClient(token string) *Client {
+	return &Client{token: token, http: &http.Client{}}
+}
+
+// PullRequest describes a pull request to open.
+type PullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"` // Branch with the changes
+	Base  string `json:"base"` // Branch to merge into
+	Body  string `json:"body"`
+}
+
+// CreatePullRequest opens a pull request and returns its web URL.
+func (c *Client) CreatePullRequest(ctx context.Context, repo Repo, pr PullRequest) (string, error) {
+	var created struct {
+		URL string `json:"html_url"`
+	}
+	if err := c.post(ctx, "/repos/"+repo.String()+"/pulls", pr, &created); err != nil {
+		return "", fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return created.URL, nil
+}
+
+// Release describes a release to create for an existing tag.
+type Release struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// CreatedRelease is a release created by CreateRelease.
+type CreatedRelease struct {
+	URL       string `json:"html_url"`
+	APIURL    string `json:"url"`        // API URL, for PublishDraft and DeleteRelease
+	UploadURL string `json:"upload_url"` // URL template for UploadAsset
+}
+
+// CreateRelease creates a release.
+func (c *Client) CreateRelease(ctx context.Context, repo Repo, release Release) (*CreatedRelease, error) {
+	var created CreatedRelease
+	if err := c.post(ctx, "/repos/"+repo.String()+"/releases", release, &created); err != nil {
+		return nil, fmt.Errorf("failed to create release: %w", err)
+	}
+	return &created, nil
+}
+
+// PublishDraft publishes a draft release and returns it as published
+// (a draft's web URL changes once it is published).
+func (c *Client) PublishDraft(ctx context.Context, release *CreatedRelease) (*CreatedRelease, error) {
+	var published CreatedRelease
+	if err := c.send(ctx, http.MethodPatch, release.APIURL, map[string]bool{"draft": false}, &published); err != nil {
+		return nil, fmt.Errorf("failed to publish release: %w", err)
+	}
+	return &published, nil
+}
+
+// DeleteRelease deletes a release. Its tag is left alone.
+func (c *Client) DeleteRelease(ctx context.Context, release *CreatedRelease) error {
+	if err := c.send(ctx, http.MethodDelete, release.APIURL, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete release: %w", err)
+	}
+	return nil
+}
+
+// UploadAsset attaches the file at path to a release, named after the
+// file's base name.
+func (c *Client) UploadAsset(ctx context.Context, release *CreatedRelease, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// The upload URL is a template: ".../assets{?name,label}"
+	base, _, _ := strings.Cut(release.UploadURL, "{")
+	uploadURL := base + "?name=" + url.QueryEscape(filepath.Base(path))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var uploaded struct{}
+	return c.do(req, &uploaded)
+}
+
+// post sends a JSON request to an API path and decodes the response
+// into out.
+func (c *Client) post(ctx context.Context, path string, in, out any) error {
+	return c.send(ctx, http.MethodPost, apiURL+path, in, out)
+}
+
+// send sends a request to an API URL, with in as the JSON body (none if
+// nil), and decodes the response into out (ignored if nil).
+func (c *Client) send(ctx context.Context, method, apiURL string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.do(req, out)
+}
+
+// do sends an authenticated request and decodes the JSON response into
+// out, unless out is nil. API errors include GitHub's message.
+func (c *Client) do(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		msg := apiErr.Message
+		for _, e := range apiErr.Errors {
+			if e.Message != "" {
+				msg += ": " + e.Message
+			}
+		}
+		return fmt.Errorf("GitHub returned %s: %s", resp.Status, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
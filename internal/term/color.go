@@ -0,0 +1,43 @@
+// Package term decides whether terminal output may contain colors.
+package term
+
+import (
+	"io"
+	"os"
+	"regexp"
+)
+
+// ColorEnabled reports whether output may contain ANSI colors. Colors
+// are off with --no-color (noColor), when NO_COLOR is set to any
+// non-empty value (see https://no-color.org), or when TERM is "dumb".
+func ColorEnabled(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+// ansiPattern matches ANSI escape sequences: CSI sequences such as
+// colors ("\x1b[31m") and OSC sequences such as hyperlinks.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// Strip removes ANSI escape sequences from s.
+func Strip(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// plainWriter strips ANSI escape sequences before writing.
+type plainWriter struct {
+	w io.Writer
+}
+
+// Plain returns a writer that removes ANSI escape sequences, such as the
+// colors git adds with color.ui=always, from everything written to w.
+func Plain(w io.Writer) io.Writer {
+	return &plainWriter{w: w}
+}
+
+// Write strips p and writes it, reporting all of p as written on success.
+func (p *plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, Strip(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
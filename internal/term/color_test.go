@@ -0,0 +1,55 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+
+	t.Setenv("NO_COLOR", "")
+	if !ColorEnabled(false) {
+		t.Error("ColorEnabled(false) = false without NO_COLOR, want true")
+	}
+	if ColorEnabled(true) {
+		t.Error("ColorEnabled(true) = true, want false for --no-color")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(false) {
+		t.Error("ColorEnabled(false) = true with NO_COLOR set, want false")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "dumb")
+	if ColorEnabled(false) {
+		t.Error("ColorEnabled(false) = true with TERM=dumb, want false")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	var buf bytes.Buffer
+	out := &buf
+	var w io.Writer = out
+	if !ColorEnabled(false) {
+		w = Plain(out)
+	}
+
+	fmt.Fprintln(w, "\x1b[1;31merror:\x1b[m The branch 'release/1.2.0' is not fully merged.")
+	fmt.Fprintln(w, "\x1b[33mhint:\x1b[0m see \x1b]8;;https://example.com\x07the docs\x1b]8;;\x07")
+
+	got := buf.String()
+	if strings.Contains(got, "\x1b") {
+		t.Errorf("output contains ANSI escapes: %q", got)
+	}
+	want := "error: The branch 'release/1.2.0' is not fully merged.\nhint: see the docs\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckInterval is how long the result of an update check is reused
+// before GitHub is asked again.
+const CheckInterval = 24 * time.Hour
+
+// checkState is the cached result of the last update check.
+type checkState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// StatePath returns the file caching the last update check:
+// update-check.json in the user cache directory (e.g., ~/.cache/mkrel).
+// Returns empty string if there is no cache directory.
+func StatePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mkrel", "update-check.json")
+}
+
+// LatestVersion returns the version of the latest mkrel release, from the
+// state file at path if it was checked within CheckInterval, otherwise from
+// GitHub. A fresh result is saved to the state file (unless path is empty).
+func LatestVersion(ctx context.Context, path string) (string, error) {
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			var state checkState
+			if json.Unmarshal(data, &state) == nil && time.Since(state.CheckedAt) < CheckInterval {
+				return state.Latest, nil
+			}
+		}
+	}
+
+	release, err := Latest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if path != "" {
+		// The cache is only an optimization, so failing to write it is fine
+		data, _ := json.Marshal(checkState{CheckedAt: time.Now(), Latest: release.Version()})
+		if os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+			_ = os.WriteFile(path, data, 0o644)
+		}
+	}
+	return release.Version(), nil
+}
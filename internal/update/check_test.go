@@ -0,0 +1,62 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatestVersion_Cache(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"tag_name": "v1.3.0"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	old := latestURL
+	latestURL = srv.URL
+	t.Cleanup(func() { latestURL = old })
+
+	path := filepath.Join(t.TempDir(), "mkrel", "update-check.json")
+	writeState := func(checkedAt time.Time) {
+		data, _ := json.Marshal(checkState{CheckedAt: checkedAt, Latest: "1.2.0"})
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Checked recently: the cached version is used
+	writeState(time.Now().Add(-time.Hour))
+	got, err := LatestVersion(context.Background(), path)
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+	if got != "1.2.0" || requests != 0 {
+		t.Errorf("LatestVersion() = %q after %d requests, want the cached 1.2.0", got, requests)
+	}
+
+	// Stale: GitHub is asked and the cache refreshed
+	writeState(time.Now().Add(-2 * CheckInterval))
+	got, err = LatestVersion(context.Background(), path)
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+	if got != "1.3.0" || requests != 1 {
+		t.Errorf("LatestVersion() = %q after %d requests, want 1.3.0 from GitHub", got, requests)
+	}
+
+	got, _ = LatestVersion(context.Background(), path)
+	if got != "1.3.0" || requests != 1 {
+		t.Errorf("LatestVersion() = %q after %d requests, want the refreshed cache", got, requests)
+	}
+}
@@ -0,0 +1,270 @@
+// Package update finds new mkrel releases on GitHub and installs them
+// over the running binary.
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// latestURL is the GitHub API endpoint for the latest mkrel release.
+// Tests point it at a local server.
+var latestURL = "https://api.github.com/repos/kloudlabs-io/mkrel/releases/latest"
+
+// checksumsAsset is the release asset listing the SHA-256 of each archive
+// (see checksum.name_template in .goreleaser.yml).
+const checksumsAsset = "checksums.txt"
+
+// maxDownload caps the size of a downloaded asset.
+const maxDownload = 100 << 20
+
+var client = &http.Client{Timeout: 60 * time.Second}
+
+// Release is a published mkrel release.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release's version, without the "v" prefix.
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// asset returns the asset with the given name, or nil.
+func (r *Release) asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// Latest returns the latest mkrel release.
+func Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for updates: GitHub returned %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, errors.New("failed to parse release: no tag name")
+	}
+	return &release, nil
+}
+
+// ArchiveName returns the name of the release archive for a platform,
+// following the GoReleaser name_template (e.g., mkrel_1.2.0_linux_amd64.tar.gz).
+func ArchiveName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("mkrel_%s_%s_%s%s", version, goos, goarch, ext)
+}
+
+// Install downloads the release archive for this platform, verifies it
+// against the release checksums, and replaces the binary at exe with the
+// one inside.
+func Install(release *Release, exe string) error {
+	// Fail before downloading anything if the binary can't be replaced
+	if err := checkWritable(filepath.Dir(exe)); err != nil {
+		return err
+	}
+
+	name := ArchiveName(release.Version(), runtime.GOOS, runtime.GOARCH)
+	archive := release.asset(name)
+	if archive == nil {
+		return fmt.Errorf("release %s has no download for %s/%s", release.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums := release.asset(checksumsAsset)
+	if checksums == nil {
+		return fmt.Errorf("release %s has no %s to verify the download", release.Tag, checksumsAsset)
+	}
+
+	sums, err := download(checksums.URL)
+	if err != nil {
+		return err
+	}
+	data, err := download(archive.URL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(name, data, sums); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(name, data)
+	if err != nil {
+		return err
+	}
+	return replace(exe, binary)
+}
+
+// download fetches a release asset.
+func download(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", path.Base(url), resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against the SHA-256 listed for name in a
+// checksums file ("<hex>  <name>" per line).
+func verifyChecksum(name string, data, checksums []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, fields[0]) {
+			return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, fields[0])
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s in %s", name, checksumsAsset)
+}
+
+// extractBinary returns the mkrel binary from a release archive.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	binary := "mkrel"
+	if strings.HasSuffix(name, ".zip") {
+		binary = "mkrel.exe"
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		for _, file := range r.File {
+			if path.Base(file.Name) != binary {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = rc.Close() }()
+			return io.ReadAll(io.LimitReader(rc, maxDownload))
+		}
+		return nil, fmt.Errorf("%s not found in %s", binary, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", binary, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+			return io.ReadAll(io.LimitReader(tr, maxDownload))
+		}
+	}
+}
+
+// checkWritable returns a clear error if files can't be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".mkrel-update-*")
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("cannot replace mkrel in %s: permission denied (rerun with sudo, or update with the tool you installed it with)", dir)
+		}
+		return fmt.Errorf("cannot replace mkrel in %s: %w", dir, err)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// replace atomically swaps the binary at exe for the new one, keeping
+// its permissions. Windows can't overwrite a running executable, so the
+// old one is moved aside first.
+func replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".mkrel-update-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// tarGz builds a .tar.gz archive holding one file.
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveRelease starts a server publishing a release 1.2.0 with the given
+// archive and checksums, and points latestURL at it.
+func serveRelease(t *testing.T, archive []byte, checksums string) *Release {
+	t.Helper()
+	name := ArchiveName("1.2.0", runtime.GOOS, runtime.GOARCH)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [
+			{"name": %q, "browser_download_url": "%s/archive"},
+			{"name": "checksums.txt", "browser_download_url": "%s/checksums"}]}`,
+			name, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/archive", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write(archive) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, checksums) })
+
+	old := latestURL
+	latestURL = srv.URL + "/latest"
+	t.Cleanup(func() { latestURL = old })
+
+	release, err := Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	return release
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives are zip files on Windows")
+	}
+
+	archive := tarGz(t, "mkrel", []byte("new binary"))
+	sum := sha256.Sum256(archive)
+	name := ArchiveName("1.2.0", runtime.GOOS, runtime.GOARCH)
+
+	tests := []struct {
+		name      string
+		checksums string
+		wantErr   string
+	}{
+		{"verified", hex.EncodeToString(sum[:]) + "  " + name + "\n", ""},
+		{"mismatch", strings.Repeat("0", 64) + "  " + name + "\n", "checksum mismatch"},
+		{"missing", hex.EncodeToString(sum[:]) + "  other.tar.gz\n", "no checksum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := serveRelease(t, archive, tt.checksums)
+			if release.Version() != "1.2.0" {
+				t.Errorf("Version() = %q, want %q", release.Version(), "1.2.0")
+			}
+
+			exe := filepath.Join(t.TempDir(), "mkrel")
+			if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Install(release, exe)
+			got, _ := os.ReadFile(exe)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Install() error = %v, want %q", err, tt.wantErr)
+				}
+				if string(got) != "old binary" {
+					t.Error("Install() replaced the binary despite failing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if string(got) != "new binary" {
+				t.Errorf("binary = %q, want the new one", got)
+			}
+		})
+	}
+}
+
+func TestInstall_NotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs a directory the user can't write to")
+	}
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "mkrel")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o755) })
+
+	err := Install(&Release{Tag: "v1.2.0"}, exe)
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Install() error = %v, want a permission error", err)
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	if got := ArchiveName("1.2.0", "linux", "amd64"); got != "mkrel_1.2.0_linux_amd64.tar.gz" {
+		t.Errorf("ArchiveName() = %q", got)
+	}
+	if got := ArchiveName("1.2.0", "windows", "arm64"); got != "mkrel_1.2.0_windows_arm64.zip" {
+		t.Errorf("ArchiveName() = %q", got)
+	}
+}
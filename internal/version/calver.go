@@ -56,7 +56,8 @@ func (c *CalVer) IsValid(version string) bool {
 
 // Next calculates the next version.
 // For releases: uses today's date (YYYY.MM.DD)
-// For hotfixes: appends -N suffix (YYYY.MM.DD-1, YYYY.MM.DD-2, etc.)
+// For hotfixes: appends -N suffix (YYYY.MM.DD-1, YYYY.MM.DD-2, etc.),
+// or uses today's date if there is no current version
 func (c *CalVer) Next(current string, bump BumpType) (string, error) {
 	now := c.now()
 	today := fmt.Sprintf("%d.%02d.%02d", now.Year(), now.Month(), now.Day())
@@ -75,8 +76,13 @@ func (c *CalVer) Next(current string, bump BumpType) (string, error) {
 	}
 }
 
-// nextHotfix calculates the next hotfix version.
+// nextHotfix calculates the next hotfix version. With no release yet,
+// there is nothing to number a hotfix after, so it is today's version.
 func (c *CalVer) nextHotfix(current, today string) (string, error) {
+	if current == "" {
+		return today, nil
+	}
+
 	// Parse current version
 	matches := calverPattern.FindStringSubmatch(current)
 	if matches == nil {
@@ -110,6 +116,13 @@ func (c *CalVer) RemovePrerelease(version string) string {
 	return version
 }
 
+// NextN returns the next n versions after current. Hotfixes count up
+// ("2025.12.25-1", "2025.12.25-2", ...), but a release is always today's
+// date, so it is returned once.
+func (c *CalVer) NextN(current string, bump BumpType, n int) ([]string, error) {
+	return nextN(c, current, bump, n)
+}
+
 // FormatForToday returns today's date as a CalVer version.
 func (c *CalVer) FormatForToday() string {
 	now := c.now()
@@ -158,6 +158,20 @@ func TestCalVer_Next_Hotfix(t *testing.T) {
 			bump:    BumpPatch,
 			want:    "2025.12.26-1",
 		},
+		{
+			name:    "no release yet",
+			current: "",
+			today:   time.Date(2025, 12, 26, 10, 0, 0, 0, time.UTC),
+			bump:    BumpHotfix,
+			want:    "2025.12.26",
+		},
+		{
+			name:    "no release yet, BumpPatch",
+			current: "",
+			today:   time.Date(2025, 12, 26, 10, 0, 0, 0, time.UTC),
+			bump:    BumpPatch,
+			want:    "2025.12.26",
+		},
 		{
 			name:    "invalid current version starts fresh",
 			current: "invalid",
@@ -2,6 +2,7 @@ package version
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -115,6 +116,12 @@ func (s *SemVer) RemovePrerelease(version string) string {
 	return newV.String()
 }
 
+// NextN returns the next n versions after current, e.g., successive
+// minors: "1.3.0", "1.4.0", ...
+func (s *SemVer) NextN(current string, bump BumpType, n int) ([]string, error) {
+	return nextN(s, current, bump, n)
+}
+
 // IncrementPrerelease increments the prerelease number.
 // e.g., "1.0.0-rc.0" -> "1.0.0-rc.1"
 func (s *SemVer) IncrementPrerelease(version string) (string, error) {
@@ -147,3 +154,32 @@ func (s *SemVer) IncrementPrerelease(version string) (string, error) {
 	newV, _ := v.SetPrerelease(newPre)
 	return newV.String(), nil
 }
+
+// Channels are the prerelease channels, in promotion order.
+var Channels = []string{"alpha", "beta", "rc"}
+
+// SetChannel moves a version to a prerelease channel, keeping the base
+// version: a new channel starts at 0 ("1.3.0-alpha.2" -> "1.3.0-beta.0"),
+// while the same channel increments the counter ("1.3.0-beta.0" ->
+// "1.3.0-beta.1"). Going back to an earlier channel fails unless force
+// is set.
+func (s *SemVer) SetChannel(version, channel string, force bool) (string, error) {
+	target := slices.Index(Channels, channel)
+	if target == -1 {
+		return "", fmt.Errorf("unknown channel: %s (use %s)", channel, strings.Join(Channels, ", "))
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version: %w", err)
+	}
+
+	current, _, _ := strings.Cut(v.Prerelease(), ".")
+	if current == channel {
+		return s.IncrementPrerelease(version)
+	}
+	if from := slices.Index(Channels, current); from > target && !force {
+		return "", fmt.Errorf("can't go back from %s to %s (use --force to do it anyway)", current, channel)
+	}
+	return s.SetPrerelease(s.RemovePrerelease(version), channel+".0"), nil
+}
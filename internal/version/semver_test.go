@@ -319,3 +319,38 @@ func TestSemVer_IncrementPrerelease(t *testing.T) {
 		})
 	}
 }
+
+func TestSemVer_SetChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		channel string
+		force   bool
+		want    string
+		wantErr bool
+	}{
+		{name: "start alpha", version: "1.3.0", channel: "alpha", want: "1.3.0-alpha.0"},
+		{name: "alpha to beta", version: "1.3.0-alpha.2", channel: "beta", want: "1.3.0-beta.0"},
+		{name: "beta to rc", version: "1.3.0-beta.1", channel: "rc", want: "1.3.0-rc.0"},
+		{name: "alpha to rc", version: "1.3.0-alpha.0", channel: "rc", want: "1.3.0-rc.0"},
+		{name: "same channel", version: "1.3.0-beta.0", channel: "beta", want: "1.3.0-beta.1"},
+		{name: "unknown current", version: "1.3.0-dev.4", channel: "alpha", want: "1.3.0-alpha.0"},
+		{name: "backwards", version: "1.3.0-rc.0", channel: "beta", wantErr: true},
+		{name: "backwards forced", version: "1.3.0-rc.0", channel: "alpha", force: true, want: "1.3.0-alpha.0"},
+		{name: "unknown channel", version: "1.3.0", channel: "gamma", wantErr: true},
+		{name: "invalid version", version: "latest", channel: "beta", wantErr: true},
+	}
+
+	s := NewSemVer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SetChannel(tt.version, tt.channel, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetChannel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SetChannel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
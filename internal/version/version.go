@@ -1,7 +1,14 @@
 // Package version handles semantic and calendar versioning.
 package version
 
-import "fmt"
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
 
 // BumpType indicates what kind of version bump to perform.
 type BumpType string
@@ -40,6 +47,10 @@ type Versioner interface {
 
 	// RemovePrerelease removes prerelease suffix.
 	RemovePrerelease(version string) string
+
+	// NextN returns the next n versions after current, each bumped from
+	// the one before (see nextN).
+	NextN(current string, bump BumpType, n int) ([]string, error)
 }
 
 // New creates a Versioner for the specified scheme.
@@ -54,6 +65,31 @@ func New(scheme Scheme, latestTagFn func() (string, error)) (Versioner, error) {
 	}
 }
 
+// nextN returns the next n versions after current, each bumped from the
+// one before with v.Next (SemVer minor: 1.3.0, 1.4.0, ...; CalVer hotfix:
+// 2025.12.25-1, 2025.12.25-2, ...). CalVer releases are today's date
+// whatever the current version, so the sequence stops at the first
+// repeated version: NextN returns that date once.
+func nextN(v Versioner, current string, bump BumpType, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid count %d: must be at least 1", n)
+	}
+
+	versions := make([]string, 0, n)
+	for range n {
+		next, err := v.Next(current, bump)
+		if err != nil {
+			return nil, err
+		}
+		if next == current {
+			break
+		}
+		versions = append(versions, next)
+		current = next
+	}
+	return versions, nil
+}
+
 // ParseScheme converts a string to a Scheme.
 func ParseScheme(s string) (Scheme, error) {
 	switch s {
@@ -65,3 +101,120 @@ func ParseScheme(s string) (Scheme, error) {
 		return "", fmt.Errorf("unknown scheme: %s (use 'calver' or 'semver')", s)
 	}
 }
+
+// ParseBumpType converts a string to a BumpType.
+func ParseBumpType(s string) (BumpType, error) {
+	switch bump := BumpType(strings.ToLower(s)); bump {
+	case BumpMinor, BumpPatch, BumpHotfix:
+		return bump, nil
+	default:
+		return "", fmt.Errorf("unknown bump type: %s (use 'minor', 'patch', or 'hotfix')", s)
+	}
+}
+
+// IsPrerelease reports whether a version has a prerelease component
+// (e.g., "1.3.0-rc.0"). CalVer versions are never prereleases: their
+// "-N" suffix marks a hotfix, not a prerelease.
+func IsPrerelease(s string, scheme Scheme) bool {
+	if scheme != SchemeSemVer {
+		return false
+	}
+
+	v, err := semver.NewVersion(strings.TrimPrefix(s, "v"))
+	if err != nil {
+		return false
+	}
+	return v.Prerelease() != ""
+}
+
+// IsValid reports whether s is a valid version in the scheme. Unknown
+// schemes accept nothing.
+func IsValid(s string, scheme Scheme) bool {
+	return Validate(s, scheme) == nil
+}
+
+// Validate returns an error explaining why s isn't a valid version in the
+// scheme, or nil if it is.
+func Validate(s string, scheme Scheme) error {
+	v, err := New(scheme, nil)
+	if err != nil {
+		return err
+	}
+	if v.IsValid(s) {
+		return nil
+	}
+
+	switch scheme {
+	case SchemeCalVer:
+		return fmt.Errorf("invalid calver version %q: expected YYYY.MM.DD or YYYY.MM.DD-N (e.g., 2025.12.25)", s)
+	default:
+		return fmt.Errorf("invalid semver version %q: expected MAJOR.MINOR.PATCH with an optional prerelease (e.g., 1.2.3 or 1.3.0-rc.0)", s)
+	}
+}
+
+// IsHotfix reports whether v is a hotfix of the release previous: with
+// SemVer, a patch bump (same major and minor, higher patch); with CalVer,
+// a version with a -N hotfix suffix, since hotfixes take the date they
+// were made rather than the release's. Invalid versions aren't hotfixes.
+func IsHotfix(v, previous string, scheme Scheme) bool {
+	v, previous = strings.TrimPrefix(v, "v"), strings.TrimPrefix(previous, "v")
+
+	switch scheme {
+	case SchemeSemVer:
+		cur, err := semver.NewVersion(v)
+		if err != nil {
+			return false
+		}
+		prev, err := semver.NewVersion(previous)
+		if err != nil {
+			return false
+		}
+		return cur.Major() == prev.Major() && cur.Minor() == prev.Minor() && cur.Patch() > prev.Patch()
+
+	case SchemeCalVer:
+		m := calverPattern.FindStringSubmatch(v)
+		return m != nil && m[4] != "" && calverPattern.MatchString(previous)
+
+	default:
+		return false
+	}
+}
+
+// Compare compares two versions in the scheme, returning -1, 0, or 1 if
+// a is older than, the same as, or newer than b. A "v" prefix is ignored.
+func Compare(a, b string, scheme Scheme) (int, error) {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+
+	switch scheme {
+	case SchemeSemVer:
+		va, err := semver.NewVersion(a)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q: %w", a, err)
+		}
+		vb, err := semver.NewVersion(b)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q: %w", b, err)
+		}
+		return va.Compare(vb), nil
+
+	case SchemeCalVer:
+		ma, mb := calverPattern.FindStringSubmatch(a), calverPattern.FindStringSubmatch(b)
+		if ma == nil {
+			return 0, fmt.Errorf("invalid version %q", a)
+		}
+		if mb == nil {
+			return 0, fmt.Errorf("invalid version %q", b)
+		}
+		// The date parts are zero-padded, so they compare as strings;
+		// a hotfix number (none = 0) breaks ties
+		if c := strings.Compare(ma[1]+ma[2]+ma[3], mb[1]+mb[2]+mb[3]); c != 0 {
+			return c, nil
+		}
+		ha, _ := strconv.Atoi(ma[4])
+		hb, _ := strconv.Atoi(mb[4])
+		return cmp.Compare(ha, hb), nil
+
+	default:
+		return 0, fmt.Errorf("unknown versioning scheme: %s", scheme)
+	}
+}
@@ -1,7 +1,10 @@
 package version
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -73,3 +76,190 @@ func TestParseScheme(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		version  string
+		scheme   Scheme
+		wantErr  bool
+		contains string
+	}{
+		{"1.2.3", SchemeSemVer, false, ""},
+		{"v1.3.0-rc.0", SchemeSemVer, false, ""},
+		{"1.2.x", SchemeSemVer, true, "MAJOR.MINOR.PATCH"},
+		{"2025.12.25", SchemeCalVer, false, ""},
+		{"2025.12.25-2", SchemeCalVer, false, ""},
+		{"2025.1.5", SchemeCalVer, true, "YYYY.MM.DD"},
+		{"1.2.3", SchemeCalVer, true, "YYYY.MM.DD"},
+		{"1.2.3", Scheme("unknown"), true, "unknown versioning scheme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scheme)+"/"+tt.version, func(t *testing.T) {
+			err := Validate(tt.version, tt.scheme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q, %v) error = %v, wantErr %v", tt.version, tt.scheme, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Validate() error = %q, want it to mention %q", err, tt.contains)
+			}
+		})
+	}
+}
+
+func TestParseBumpType(t *testing.T) {
+	for _, s := range []string{"minor", "patch", "hotfix", "Minor"} {
+		if _, err := ParseBumpType(s); err != nil {
+			t.Errorf("ParseBumpType(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseBumpType("major"); err == nil {
+		t.Error("ParseBumpType(\"major\") expected error")
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		version string
+		scheme  Scheme
+		want    bool
+	}{
+		{"1.3.0-rc.0", SchemeSemVer, true},
+		{"v1.3.0-beta.2", SchemeSemVer, true},
+		{"1.3.0", SchemeSemVer, false},
+		{"v1.3.0", SchemeSemVer, false},
+		{"1.3.0+build.5", SchemeSemVer, false},
+		{"not-a-version", SchemeSemVer, false},
+		{"2025.12.25", SchemeCalVer, false},
+		{"2025.12.25-1", SchemeCalVer, false},
+		{"1.3.0-rc.0", SchemeCalVer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scheme)+"/"+tt.version, func(t *testing.T) {
+			if got := IsPrerelease(tt.version, tt.scheme); got != tt.want {
+				t.Errorf("IsPrerelease(%q, %v) = %v, want %v", tt.version, tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		version string
+		scheme  Scheme
+		want    bool
+	}{
+		{"1.3.0", SchemeSemVer, true},
+		{"1.3.0-rc.0", SchemeSemVer, true},
+		{"nightly", SchemeSemVer, false},
+		{"build-123", SchemeSemVer, false},
+		{"2025.12.25", SchemeCalVer, true},
+		{"2025.12.25-1", SchemeCalVer, true},
+		{"nightly", SchemeCalVer, false},
+		{"1.3.0", SchemeCalVer, false},
+		{"1.3.0", Scheme("unknown"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scheme)+"/"+tt.version, func(t *testing.T) {
+			if got := IsValid(tt.version, tt.scheme); got != tt.want {
+				t.Errorf("IsValid(%q, %v) = %v, want %v", tt.version, tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		scheme  Scheme
+		want    int
+		wantErr bool
+	}{
+		{"1.2.3", "1.2.3", SchemeSemVer, 0, false},
+		{"v1.10.0", "1.9.0", SchemeSemVer, 1, false},
+		{"1.3.0-rc.0", "1.3.0", SchemeSemVer, -1, false},
+		{"2025.12.25", "2025.12.25", SchemeCalVer, 0, false},
+		{"2025.12.25-1", "2025.12.25", SchemeCalVer, 1, false},
+		{"2025.12.25-2", "2025.12.25-10", SchemeCalVer, -1, false},
+		{"2025.11.30", "2025.12.01", SchemeCalVer, -1, false},
+		{"dev", "1.0.0", SchemeSemVer, 0, true},
+		{"1.0.0", "2025.12.25", SchemeCalVer, 0, true},
+		{"1.0.0", "1.0.0", "unknown", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			got, err := Compare(tt.a, tt.b, tt.scheme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextN(t *testing.T) {
+	today := time.Date(2025, 12, 26, 10, 30, 0, 0, time.UTC)
+	calver := &CalVer{now: func() time.Time { return today }}
+	semver := NewSemVer(nil)
+
+	tests := []struct {
+		name    string
+		v       Versioner
+		current string
+		bump    BumpType
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{"semver minor", semver, "1.2.3", BumpMinor, 3, []string{"1.3.0", "1.4.0", "1.5.0"}, false},
+		{"semver patch", semver, "1.2.3", BumpPatch, 2, []string{"1.2.4", "1.2.5"}, false},
+		{"semver first release", semver, "", BumpMinor, 2, []string{"0.1.0", "0.2.0"}, false},
+		{"calver hotfix today", calver, "2025.12.26", BumpHotfix, 3, []string{"2025.12.26-1", "2025.12.26-2", "2025.12.26-3"}, false},
+		{"calver hotfix earlier day", calver, "2025.12.20-4", BumpHotfix, 2, []string{"2025.12.26-1", "2025.12.26-2"}, false},
+		{"calver release once", calver, "2025.12.20", BumpMinor, 3, []string{"2025.12.26"}, false},
+		{"zero count", semver, "1.2.3", BumpMinor, 0, nil, true},
+		{"invalid current", semver, "dev", BumpMinor, 2, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.v.NextN(tt.current, tt.bump, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NextN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHotfix(t *testing.T) {
+	tests := []struct {
+		v, previous string
+		scheme      Scheme
+		want        bool
+	}{
+		{"1.2.1", "1.2.0", SchemeSemVer, true},
+		{"v1.2.4", "v1.2.3", SchemeSemVer, true},
+		{"1.3.0", "1.2.1", SchemeSemVer, false},
+		{"2.0.0", "1.9.9", SchemeSemVer, false},
+		{"1.2.0", "1.2.0", SchemeSemVer, false},
+		{"2025.12.25-1", "2025.12.25", SchemeCalVer, true},
+		{"2025.12.26-1", "2025.12.20", SchemeCalVer, true},
+		{"2025.12.26", "2025.12.20", SchemeCalVer, false},
+		{"dev", "1.2.0", SchemeSemVer, false},
+		{"1.2.1", "1.2.0", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHotfix(tt.v, tt.previous, tt.scheme); got != tt.want {
+			t.Errorf("IsHotfix(%q, %q, %s) = %v, want %v", tt.v, tt.previous, tt.scheme, got, tt.want)
+		}
+	}
+}